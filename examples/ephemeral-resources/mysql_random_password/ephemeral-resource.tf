@@ -0,0 +1,12 @@
+ephemeral "mysql_random_password" "app" {
+  length = 24
+}
+
+resource "mysql_user" "app" {
+  name = "app-user"
+  host = "app.example.com"
+  auth_option {
+    auth_string_wo         = ephemeral.mysql_random_password.app.result
+    auth_string_wo_version = 1
+  }
+}
@@ -1,3 +1,7 @@
 # Grant privileges can be imported by specifying `database@table@name@host`
 # All parts are required.
 terraform import mysql_grant_privilege.my-database-app-user db@*@app-user@app.example.com
+
+# Grant privileges on stored routines are imported with the object type prefix,
+# i.e. `FUNCTION@database@function@name@host` or `PROCEDURE@database@procedure@name@host`.
+terraform import mysql_grant_privilege.app-user-procedure PROCEDURE@app@refresh_stats@app-user@app.example.com
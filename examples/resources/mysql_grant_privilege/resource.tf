@@ -24,3 +24,18 @@ resource "mysql_grant_privilege" "writer-role" {
     host = "app.example.com"
   }
 }
+
+resource "mysql_grant_privilege" "app-user-procedure" {
+  privilege {
+    priv_type = "EXECUTE"
+  }
+  on {
+    object_type = "PROCEDURE"
+    database    = "app"
+    table       = "refresh_stats"
+  }
+  to {
+    name = mysql_user.app-user.name
+    host = "app.example.com"
+  }
+}
@@ -0,0 +1,3 @@
+# Grants can be imported by specifying the user `name@host`
+# Both name and host are required.
+terraform import mysql_grants.app-user app-user@%
@@ -0,0 +1,47 @@
+resource "mysql_user" "app-user" {
+  name = "app_user"
+  auth_option {
+    auth_string = "app-password"
+  }
+}
+
+resource "mysql_role" "reader" {
+  name = "reader"
+}
+
+resource "mysql_grants" "app-user" {
+  user = mysql_user.app-user.name
+  host = mysql_user.app-user.host
+
+  grant {
+    database = "*"
+    table    = "*"
+    privilege {
+      priv_type = "PROCESS"
+    }
+  }
+
+  grant {
+    database = "app"
+    table    = "*"
+    privilege {
+      priv_type = "SELECT"
+    }
+    privilege {
+      priv_type = "INSERT"
+    }
+  }
+
+  grant {
+    database = "app"
+    table    = "users"
+    privilege {
+      priv_type = "UPDATE"
+      columns   = ["name", "email"]
+    }
+  }
+
+  role {
+    name = mysql_role.reader.name
+  }
+}
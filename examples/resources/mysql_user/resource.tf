@@ -1,4 +1,4 @@
-# use random password
+# use random password, which is saved in auth_option.generated_password
 resource "mysql_user" "test" {
   name = "app-user"
   host = "app.example.com"
@@ -7,6 +7,89 @@ resource "mysql_user" "test" {
   }
 }
 
+# use random password encrypted with a PGP key, which is saved in auth_option.encrypted_password
+# decrypt it with `base64 -d | gpg -d`
+resource "mysql_user" "encrypted" {
+  name = "app-user"
+  host = "app.example.com"
+  auth_option {
+    random_password = true
+    pgp_key         = filebase64("app-user.gpg")
+  }
+}
+
+# use write-only password, which is not saved in the state (Terraform 1.11 or later)
+# increment auth_string_wo_version to change the password
+resource "mysql_user" "write-only" {
+  name = "app-user"
+  host = "app.example.com"
+  auth_option {
+    auth_string_wo         = var.app_user_password
+    auth_string_wo_version = 1
+  }
+}
+
+# limit the resources and enforce a password policy
+resource "mysql_user" "limited" {
+  name                     = "report-user"
+  max_queries_per_hour     = 1000
+  max_user_connections     = 5
+  password_expire_interval = 90
+  password_history         = 5
+  failed_login_attempts    = 3
+  password_lock_time       = 1
+  auth_option {
+    random_password = true
+  }
+}
+
+# tag the user so that audits can trace it (MySQL 8.0.21 or later)
+resource "mysql_user" "tagged" {
+  name    = "batch-user"
+  comment = "nightly batch"
+  attributes = {
+    team   = "data-platform"
+    ticket = "OPS-1234"
+  }
+  auth_option {
+    random_password = true
+  }
+}
+
+# require a client certificate issued by the given CA
+resource "mysql_user" "tls" {
+  name = "service-user"
+  tls_requirement {
+    issuer  = "/C=US/O=Example/CN=Example CA"
+    subject = "/C=US/O=Example/CN=service-user"
+  }
+  auth_option {
+    random_password = true
+  }
+}
+
+# use multi-factor authentication (MySQL 8.0.27 or later)
+resource "mysql_user" "mfa" {
+  name = "mfa-user"
+  auth_option {
+    auth_string_wo         = var.mfa_user_password
+    auth_string_wo_version = 1
+  }
+  additional_factor {
+    plugin             = "authentication_ldap_sasl"
+    stored_auth_string = "uid=mfa-user,ou=People,dc=example,dc=com"
+  }
+}
+
+# use passwordless FIDO authentication; the user logs in with the initial password to register the device
+resource "mysql_user" "fido" {
+  name = "fido-user"
+  auth_option {
+    plugin              = "authentication_webauthn"
+    initial_auth_string = var.fido_user_initial_password
+  }
+}
+
 # use RDS IAM DB Auth
 # see https://docs.aws.amazon.com/AmazonRDS/latest/AuroraUserGuide/UsingWithRDS.IAMDBAuth.html
 resource "mysql_user" "rds-user" {
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	rdsIAMService       = "rds-db"
+	rdsIAMTokenLifetime = 15 * time.Minute
+	// emptyPayloadHash is the hex encoded SHA-256 of an empty payload.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// awsRDSIAMAuthModel describes the `aws_rds_iam_auth` block of the provider
+// configuration.
+type awsRDSIAMAuthModel struct {
+	Region types.String `tfsdk:"region"`
+}
+
+// newRDSIAMTokenSource returns a token source issuing RDS IAM authentication
+// tokens for addr and user.
+func newRDSIAMTokenSource(addr, user string, data awsRDSIAMAuthModel) (tokenSource, error) {
+	region := data.Region.ValueString()
+	if region == "" {
+		region = awsRegionFromEnv()
+	}
+	if region == "" {
+		return nil, fmt.Errorf("region must be set, or AWS_REGION or AWS_DEFAULT_REGION environment variables must be set")
+	}
+	creds, err := awsCredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rdsIAMTokenSource{
+		endpoint:    addr,
+		region:      region,
+		user:        user,
+		credentials: creds,
+		now:         time.Now,
+	}, nil
+}
+
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// awsCredentialsFromEnv reads the credentials from the standard AWS
+// environment variables.
+func awsCredentialsFromEnv() (awsCredentials, error) {
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables must be set")
+	}
+	return creds, nil
+}
+
+// awsRegionFromEnv returns the region from AWS_REGION or AWS_DEFAULT_REGION.
+func awsRegionFromEnv() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// rdsIAMTokenSource issues RDS IAM authentication tokens, which are SigV4
+// presigned URLs for the `connect` action without the scheme.
+type rdsIAMTokenSource struct {
+	endpoint    string
+	region      string
+	user        string
+	credentials awsCredentials
+	now         func() time.Time
+}
+
+var _ tokenSource = &rdsIAMTokenSource{}
+
+func (s *rdsIAMTokenSource) Token(_ context.Context) (string, time.Time, error) {
+	now := s.now().UTC()
+	return buildRDSAuthToken(s.endpoint, s.region, s.user, s.credentials, now), now.Add(rdsIAMTokenLifetime), nil
+}
+
+// buildRDSAuthToken builds the token in the same way as BuildAuthToken of
+// the AWS SDK. endpoint must be a `host:port` pair.
+func buildRDSAuthToken(endpoint, region, user string, creds awsCredentials, now time.Time) string {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := strings.Join([]string{date, region, rdsIAMService, "aws4_request"}, "/")
+
+	query := url.Values{}
+	query.Set("Action", "connect")
+	query.Set("DBUser", user)
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int(rdsIAMTokenLifetime.Seconds())))
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	query.Set("X-Amz-SignedHeaders", "host")
+	canonicalQuery := sigV4Query(query)
+
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		canonicalQuery,
+		"host:" + endpoint + "\n",
+		"host",
+		emptyPayloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, value := range []string{date, region, rdsIAMService, "aws4_request"} {
+		key = hmacSHA256(key, value)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return endpoint + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// sigV4Query encodes the query sorted by key, escaping spaces as `%20` as
+// required by SigV4.
+func sigV4Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, sigV4Escape(key)+"="+sigV4Escape(query.Get(key)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func sigV4Escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(value))
+	return h.Sum(nil)
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// azureOSSRDBMSResource is the resource of Azure Database for MySQL.
+	azureOSSRDBMSResource = "https://ossrdbms-aad.database.windows.net"
+	azureAuthorityHost    = "https://login.microsoftonline.com"
+	azureIMDSHost         = "http://169.254.169.254"
+)
+
+// azureEntraIDAuthModel describes the `azure_entra_id_auth` block of the
+// provider configuration.
+type azureEntraIDAuthModel struct {
+	TenantID     types.String `tfsdk:"tenant_id"`
+	ClientID     types.String `tfsdk:"client_id"`
+	ClientSecret types.String `tfsdk:"client_secret"`
+}
+
+// newAzureEntraIDTokenSource returns a token source issuing Entra ID access
+// tokens for Azure Database for MySQL. A service principal is used when the
+// client secret is given, otherwise the managed identity of the host.
+func newAzureEntraIDTokenSource(data azureEntraIDAuthModel) (tokenSource, error) {
+	tenantID := stringWithEnv(data.TenantID, "AZURE_TENANT_ID")
+	clientID := stringWithEnv(data.ClientID, "AZURE_CLIENT_ID")
+	clientSecret := stringWithEnv(data.ClientSecret, "AZURE_CLIENT_SECRET")
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	if clientSecret == "" {
+		return &azureManagedIdentityTokenSource{
+			baseURL:  azureIMDSHost,
+			clientID: clientID,
+			client:   client,
+			now:      time.Now,
+		}, nil
+	}
+
+	if tenantID == "" || clientID == "" {
+		return nil, fmt.Errorf("tenant_id and client_id must be set with client_secret")
+	}
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = azureAuthorityHost
+	}
+	return &azureClientSecretTokenSource{
+		baseURL:      strings.TrimSuffix(authorityHost, "/"),
+		tenantID:     tenantID,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       client,
+		now:          time.Now,
+	}, nil
+}
+
+// azureClientSecretTokenSource issues access tokens with the client
+// credentials flow of a service principal.
+type azureClientSecretTokenSource struct {
+	baseURL      string
+	tenantID     string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+	now          func() time.Time
+}
+
+var _ tokenSource = &azureClientSecretTokenSource{}
+
+func (s *azureClientSecretTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", s.clientID)
+	form.Set("client_secret", s.clientSecret)
+	form.Set("scope", azureOSSRDBMSResource+"/.default")
+
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", s.baseURL, url.PathEscape(s.tenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return fetchOAuthToken(s.client, req, s.now())
+}
+
+// azureManagedIdentityTokenSource issues access tokens of the managed identity
+// from the Azure Instance Metadata Service.
+type azureManagedIdentityTokenSource struct {
+	baseURL string
+	// clientID selects a user-assigned identity. The system-assigned identity
+	// is used if empty.
+	clientID string
+	client   *http.Client
+	now      func() time.Time
+}
+
+var _ tokenSource = &azureManagedIdentityTokenSource{}
+
+func (s *azureManagedIdentityTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", azureOSSRDBMSResource)
+	if s.clientID != "" {
+		query.Set("client_id", s.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	return fetchOAuthToken(s.client, req, s.now())
+}
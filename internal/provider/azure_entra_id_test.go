@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestAzureClientSecretTokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" ||
+			r.FormValue("client_id") != "client" ||
+			r.FormValue("client_secret") != "secret" ||
+			r.FormValue("scope") != "https://ossrdbms-aad.database.windows.net/.default" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token_type":"Bearer","expires_in":3599,"access_token":"sp-token"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := &azureClientSecretTokenSource{
+		baseURL:      server.URL,
+		tenantID:     "tenant",
+		clientID:     "client",
+		clientSecret: "secret",
+		client:       server.Client(),
+		now:          func() time.Time { return now },
+	}
+	token, expiry, err := source.Token(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "sp-token" || !expiry.Equal(now.Add(3599*time.Second)) {
+		t.Errorf("unexpected token: %s, %s", token, expiry)
+	}
+}
+
+func TestAzureManagedIdentityTokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/metadata/identity/oauth2/token" || r.Header.Get("Metadata") != "true" ||
+			query.Get("resource") != "https://ossrdbms-aad.database.windows.net" ||
+			query.Get("client_id") != "identity" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"mi-token","expires_in":"86399","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := &azureManagedIdentityTokenSource{
+		baseURL:  server.URL,
+		clientID: "identity",
+		client:   server.Client(),
+		now:      func() time.Time { return now },
+	}
+	token, expiry, err := source.Token(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "mi-token" || !expiry.Equal(now.Add(86399*time.Second)) {
+		t.Errorf("unexpected token: %s, %s", token, expiry)
+	}
+}
+
+func TestNewAzureEntraIDTokenSource(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+
+	source, err := newAzureEntraIDTokenSource(azureEntraIDAuthModel{
+		TenantID:     types.StringNull(),
+		ClientID:     types.StringNull(),
+		ClientSecret: types.StringNull(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := source.(*azureManagedIdentityTokenSource); !ok {
+		t.Errorf("managed identity must be used without client secret: %#v", source)
+	}
+
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	if _, err := newAzureEntraIDTokenSource(azureEntraIDAuthModel{
+		TenantID:     types.StringNull(),
+		ClientID:     types.StringNull(),
+		ClientSecret: types.StringNull(),
+	}); err == nil {
+		t.Error("client secret without tenant_id and client_id must be an error")
+	}
+
+	source, err = newAzureEntraIDTokenSource(azureEntraIDAuthModel{
+		TenantID:     types.StringValue("tenant"),
+		ClientID:     types.StringValue("client"),
+		ClientSecret: types.StringNull(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servicePrincipal, ok := source.(*azureClientSecretTokenSource); !ok || servicePrincipal.clientSecret != "secret" {
+		t.Errorf("service principal must be used with client secret: %#v", source)
+	}
+}
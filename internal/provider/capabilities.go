@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// Capabilities are the features of the server which the resources depend on.
+type Capabilities struct {
+	// Roles is true when CREATE ROLE is supported.
+	Roles bool
+	// RandomPassword is true when `IDENTIFIED BY RANDOM PASSWORD` is supported.
+	RandomPassword bool
+	// MultiFactorAuth is true when users can have more than one
+	// authentication factor.
+	MultiFactorAuth bool
+	// DynamicPrivileges is true when privileges such as SYSTEM_VARIABLES_ADMIN
+	// can be granted.
+	DynamicPrivileges bool
+	// PartialRevokes is true when the partial_revokes variable is supported.
+	PartialRevokes bool
+	// SetPersist is true when `SET PERSIST` is supported.
+	SetPersist bool
+	// DefaultAuthenticationPlugin is true when the
+	// default_authentication_plugin variable exists.
+	DefaultAuthenticationPlugin bool
+	// NoAutoCreateUser is true when GRANT creates missing users unless the
+	// sql_mode contains NO_AUTO_CREATE_USER.
+	NoAutoCreateUser bool
+	// ResourceLimits is true when accounts can have limits such as
+	// MAX_QUERIES_PER_HOUR.
+	ResourceLimits bool
+	// PasswordExpire is true when `PASSWORD EXPIRE INTERVAL n DAY` is
+	// supported.
+	PasswordExpire bool
+	// PasswordReuse is true when PASSWORD HISTORY and PASSWORD REUSE
+	// INTERVAL are supported.
+	PasswordReuse bool
+	// PasswordRequireCurrent is true when PASSWORD REQUIRE CURRENT is
+	// supported.
+	PasswordRequireCurrent bool
+	// FailedLoginTracking is true when FAILED_LOGIN_ATTEMPTS and
+	// PASSWORD_LOCK_TIME are supported.
+	FailedLoginTracking bool
+	// TLSRequirement is true when accounts can require TLS with REQUIRE
+	// clauses, which are read back from mysql.user or mysql.global_priv.
+	TLSRequirement bool
+	// UserAttributes is true when accounts can have a COMMENT and an
+	// ATTRIBUTE, which are kept in the metadata of mysql.user.User_attributes.
+	UserAttributes bool
+}
+
+// newCapabilities returns the capabilities of the server of the version and
+// the flavor. Suffixes such as `-log` are ignored.
+func newCapabilities(v *version.Version, flavor Flavor) Capabilities {
+	atLeast := func(min string) bool {
+		return v.Core().GreaterThanOrEqual(version.Must(version.NewVersion(min)))
+	}
+	switch flavor {
+	case FlavorMariaDB:
+		return Capabilities{
+			Roles:          atLeast("10.0.5"),
+			ResourceLimits: true,
+			PasswordExpire: atLeast("10.4.3"),
+			TLSRequirement: true,
+		}
+	case FlavorTiDB:
+		// TiDB reports a MySQL 8.0 compatible version.
+		return Capabilities{
+			Roles:                       true,
+			DynamicPrivileges:           true,
+			DefaultAuthenticationPlugin: true,
+			PasswordExpire:              true,
+			PasswordReuse:               true,
+			FailedLoginTracking:         true,
+		}
+	default:
+		return Capabilities{
+			Roles:                       atLeast("8.0.0"),
+			RandomPassword:              atLeast("8.0.18"),
+			MultiFactorAuth:             atLeast("8.0.27"),
+			DynamicPrivileges:           atLeast("8.0.0"),
+			PartialRevokes:              atLeast("8.0.16"),
+			SetPersist:                  atLeast("8.0.0"),
+			DefaultAuthenticationPlugin: !atLeast("8.4.0"),
+			NoAutoCreateUser:            atLeast("5.7.5") && !atLeast("8.0.0"),
+			ResourceLimits:              true,
+			PasswordExpire:              atLeast("5.7.4"),
+			PasswordReuse:               atLeast("8.0.3"),
+			PasswordRequireCurrent:      atLeast("8.0.13"),
+			FailedLoginTracking:         atLeast("8.0.19"),
+			TLSRequirement:              true,
+			UserAttributes:              atLeast("8.0.21"),
+		}
+	}
+}
+
+// serverName returns the flavor and the version of the server, e.g.
+// `MySQL 8.0.36`.
+func (c *OneConnection) serverName() string {
+	return fmt.Sprintf("%s %s", c.Flavor, c.Version.Core())
+}
+
+// notSupported returns the detail of the diagnostic for a feature which the
+// server does not support.
+func (c *OneConnection) notSupported(feature string) string {
+	return fmt.Sprintf("%s is not supported on %s.", feature, c.serverName())
+}
+
+// checkCapability returns an error unless the server supports feature. The
+// error is reported on attribute, or on the resource when attribute is empty.
+// The check is skipped when the server cannot be reached while planning.
+func checkCapability(ctx context.Context, conf *MySQLConfiguration, attribute path.Path, feature string, supported func(Capabilities) bool) diag.Diagnostics {
+	conn := getPlanConnection(ctx, conf)
+	if conn == nil || supported(conn.Capabilities) {
+		return nil
+	}
+	if attribute.Equal(path.Empty()) {
+		return diag.Diagnostics{diag.NewErrorDiagnostic("Unsupported feature", conn.notSupported(feature))}
+	}
+	return diag.Diagnostics{diag.NewAttributeErrorDiagnostic(attribute, "Unsupported feature", conn.notSupported(feature))}
+}
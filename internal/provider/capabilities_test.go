@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestNewCapabilities(t *testing.T) {
+	cases := []struct {
+		version  string
+		flavor   Flavor
+		expected Capabilities
+	}{
+		{"5.6.51", FlavorMySQL, Capabilities{DefaultAuthenticationPlugin: true, ResourceLimits: true, TLSRequirement: true}},
+		{"5.7.4", FlavorMySQL, Capabilities{DefaultAuthenticationPlugin: true, ResourceLimits: true, PasswordExpire: true, TLSRequirement: true}},
+		{"5.7.44-log", FlavorMySQL, Capabilities{DefaultAuthenticationPlugin: true, NoAutoCreateUser: true, ResourceLimits: true, PasswordExpire: true, TLSRequirement: true}},
+		{"8.0.11", FlavorMySQL, Capabilities{Roles: true, DynamicPrivileges: true, SetPersist: true, DefaultAuthenticationPlugin: true, ResourceLimits: true, PasswordExpire: true, PasswordReuse: true, TLSRequirement: true}},
+		{"8.0.17-debug", FlavorMySQL, Capabilities{Roles: true, DynamicPrivileges: true, PartialRevokes: true, SetPersist: true, DefaultAuthenticationPlugin: true, ResourceLimits: true, PasswordExpire: true, PasswordReuse: true, PasswordRequireCurrent: true, TLSRequirement: true}},
+		{"8.0.18", FlavorMySQL, Capabilities{Roles: true, RandomPassword: true, DynamicPrivileges: true, PartialRevokes: true, SetPersist: true, DefaultAuthenticationPlugin: true, ResourceLimits: true, PasswordExpire: true, PasswordReuse: true, PasswordRequireCurrent: true, TLSRequirement: true}},
+		{"8.0.36-log", FlavorMySQL, Capabilities{Roles: true, RandomPassword: true, MultiFactorAuth: true, DynamicPrivileges: true, PartialRevokes: true, SetPersist: true, DefaultAuthenticationPlugin: true, ResourceLimits: true, PasswordExpire: true, PasswordReuse: true, PasswordRequireCurrent: true, FailedLoginTracking: true, TLSRequirement: true, UserAttributes: true}},
+		{"8.0.35-27", FlavorPercona, Capabilities{Roles: true, RandomPassword: true, MultiFactorAuth: true, DynamicPrivileges: true, PartialRevokes: true, SetPersist: true, DefaultAuthenticationPlugin: true, ResourceLimits: true, PasswordExpire: true, PasswordReuse: true, PasswordRequireCurrent: true, FailedLoginTracking: true, TLSRequirement: true, UserAttributes: true}},
+		{"8.4.0-debug", FlavorMySQL, Capabilities{Roles: true, RandomPassword: true, MultiFactorAuth: true, DynamicPrivileges: true, PartialRevokes: true, SetPersist: true, ResourceLimits: true, PasswordExpire: true, PasswordReuse: true, PasswordRequireCurrent: true, FailedLoginTracking: true, TLSRequirement: true, UserAttributes: true}},
+		{"10.0.4-MariaDB-log", FlavorMariaDB, Capabilities{ResourceLimits: true, TLSRequirement: true}},
+		{"10.11.6-MariaDB-log", FlavorMariaDB, Capabilities{Roles: true, ResourceLimits: true, PasswordExpire: true, TLSRequirement: true}},
+		{"8.0.11-TiDB-v7.5.0", FlavorTiDB, Capabilities{Roles: true, DynamicPrivileges: true, DefaultAuthenticationPlugin: true, PasswordExpire: true, PasswordReuse: true, FailedLoginTracking: true}},
+	}
+	for _, c := range cases {
+		t.Run(c.version, func(t *testing.T) {
+			v, err := parseServerVersion(c.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := newCapabilities(v, c.flavor); got != c.expected {
+				t.Errorf("unexpected capabilities:\n got: %+v\nwant: %+v", got, c.expected)
+			}
+		})
+	}
+}
+
+func TestCheckCapability(t *testing.T) {
+	conf := &MySQLConfiguration{
+		Config: &mysql.Config{
+			User:   "capabilities",
+			Net:    "tcp",
+			Addr:   "capabilities.invalid:3306",
+			Params: map[string]string{},
+		},
+	}
+	v := version.Must(version.NewVersion("5.7.44-log"))
+	connectionCacheMtx.Lock()
+	connectionCache[conf.cacheKey()] = &OneConnection{
+		Version:      v,
+		Flavor:       FlavorMySQL,
+		Capabilities: newCapabilities(v, FlavorMySQL),
+	}
+	connectionCacheMtx.Unlock()
+	t.Cleanup(func() {
+		connectionCacheMtx.Lock()
+		defer connectionCacheMtx.Unlock()
+		delete(connectionCache, conf.cacheKey())
+	})
+
+	diags := checkCapability(t.Context(), conf, path.Empty(), "Roles", func(c Capabilities) bool { return c.Roles })
+	if len(diags) != 1 {
+		t.Fatalf("expected an error, got %v", diags)
+	}
+	if detail := diags[0].Detail(); detail != "Roles is not supported on MySQL 5.7.44." {
+		t.Errorf("unexpected detail: %s", detail)
+	}
+
+	diags = checkCapability(t.Context(), conf, path.Root("privilege"), "Dynamic privileges", func(c Capabilities) bool { return c.NoAutoCreateUser })
+	if diags.HasError() {
+		t.Errorf("unexpected error: %v", diags)
+	}
+
+	conf.Unknown = true
+	diags = checkCapability(t.Context(), conf, path.Empty(), "Roles", func(c Capabilities) bool { return c.Roles })
+	if diags.HasError() {
+		t.Errorf("the check must be skipped while the configuration is unknown: %v", diags)
+	}
+}
+
+func TestGetPlanConnection_Unreachable(t *testing.T) {
+	// The server accepts connections but never sends the handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	var accepted atomic.Int32
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			conns = append(conns, conn)
+		}
+	}()
+
+	timeout := planConnectTimeout
+	planConnectTimeout = 500 * time.Millisecond
+	t.Cleanup(func() { planConnectTimeout = timeout })
+
+	conf := testMySQLConfig()
+	conf.Config.User = "unreachable"
+	conf.Config.Addr = listener.Addr().String()
+	t.Cleanup(func() {
+		planConnectionFailuresMtx.Lock()
+		defer planConnectionFailuresMtx.Unlock()
+		delete(planConnectionFailures, conf.cacheKey())
+	})
+
+	// Other connections are not blocked while dialing.
+	cachedConf := testMySQLConfig()
+	cachedConf.Config.User = "cached"
+	cached := testFakeConnection(t, cachedConf, func(query string) (*fakeRows, error) { return &fakeRows{}, nil })
+	done := make(chan *OneConnection)
+	go func() { done <- getPlanConnection(t.Context(), conf) }()
+	time.Sleep(100 * time.Millisecond)
+	started := time.Now()
+	if conn, err := getConnection(t.Context(), cachedConf); err != nil || conn != cached {
+		t.Errorf("unexpected connection: %v, %v", conn, err)
+	}
+	if elapsed := time.Since(started); elapsed > 100*time.Millisecond {
+		t.Errorf("the cached connection must not wait for the dial: %s", elapsed)
+	}
+
+	select {
+	case conn := <-done:
+		if conn != nil {
+			t.Fatal("the server must be unreachable")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the dial must time out")
+	}
+
+	// The failure is remembered, so the server is not dialed again.
+	started = time.Now()
+	if conn := getPlanConnection(t.Context(), conf); conn != nil {
+		t.Fatal("the server must be unreachable")
+	}
+	if elapsed := time.Since(started); elapsed > 100*time.Millisecond {
+		t.Errorf("the failure must be remembered: %s", elapsed)
+	}
+	if n := accepted.Load(); n != 1 {
+		t.Errorf("the server must be dialed once, got %d", n)
+	}
+}
+
+func TestIsDynamicPrivilege(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT":                  false,
+		"CREATE TEMPORARY TABLES": false,
+		"REPLICATION CLIENT":      false,
+		"SYSTEM_VARIABLES_ADMIN":  true,
+		"BACKUP_ADMIN":            true,
+	}
+	for privType, expected := range cases {
+		if got := isDynamicPrivilege(privType); got != expected {
+			t.Errorf("isDynamicPrivilege(%q) = %v", privType, got)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// connector wraps a driver.Connector to apply the provider settings to every
+// physical connection in the pool.
+type connector struct {
+	driver.Connector
+	statementTimeout time.Duration
+	// initStatements run on every new connection after the statements
+	// required by the provider.
+	initStatements []string
+
+	versionMtx sync.Mutex
+	version    *version.Version
+}
+
+var _ driver.Connector = &connector{}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.initSession(ctx, conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if c.statementTimeout <= 0 {
+		return conn, nil
+	}
+	return &timeoutConn{conn: conn, timeout: c.statementTimeout}, nil
+}
+
+// initSession runs the session statements on conn.
+func (c *connector) initSession(ctx context.Context, conn driver.Conn) error {
+	serverVersion, err := c.serverVersion(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed getting server version: %w", err)
+	}
+
+	var statements []string
+	capabilities := newCapabilities(serverVersion, detectFlavor(serverVersion.Original(), ""))
+	if capabilities.NoAutoCreateUser {
+		// Set up env so that we won't create users randomly.
+		// CONCAT and setting works even if there is no value.
+		statements = append(statements, `SET SESSION sql_mode=CONCAT(@@sql_mode, ',NO_AUTO_CREATE_USER')`)
+	}
+	statements = append(statements, c.initStatements...)
+	if len(statements) == 0 {
+		return nil
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return errors.New("driver does not support ExecContext")
+	}
+	for _, statement := range statements {
+		if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+			return fmt.Errorf("failed running %q: %w", statement, err)
+		}
+	}
+	return nil
+}
+
+// serverVersion returns the version of the server, querying it on the first
+// connection only.
+func (c *connector) serverVersion(ctx context.Context, conn driver.Conn) (*version.Version, error) {
+	c.versionMtx.Lock()
+	defer c.versionMtx.Unlock()
+
+	if c.version != nil {
+		return c.version, nil
+	}
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return nil, errors.New("driver does not support QueryContext")
+	}
+	rows, err := queryer.QueryContext(ctx, "SELECT @@GLOBAL.version", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	dest := make([]driver.Value, len(rows.Columns()))
+	if err := rows.Next(dest); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no rows returned")
+		}
+		return nil, err
+	}
+
+	var versionString string
+	switch value := dest[0].(type) {
+	case []byte:
+		versionString = string(value)
+	case string:
+		versionString = value
+	default:
+		return nil, fmt.Errorf("unexpected version value: %v", value)
+	}
+	c.version, err = parseServerVersion(versionString)
+	return c.version, err
+}
+
+// timeoutConn cancels every statement running longer than timeout.
+// Rows returned by QueryContext keep the statement context alive until they
+// are closed, so that the driver can read the result set.
+type timeoutConn struct {
+	conn    driver.Conn
+	timeout time.Duration
+}
+
+var (
+	_ driver.Conn               = &timeoutConn{}
+	_ driver.ConnBeginTx        = &timeoutConn{}
+	_ driver.ConnPrepareContext = &timeoutConn{}
+	_ driver.ExecerContext      = &timeoutConn{}
+	_ driver.QueryerContext     = &timeoutConn{}
+	_ driver.Pinger             = &timeoutConn{}
+	_ driver.SessionResetter    = &timeoutConn{}
+	_ driver.Validator          = &timeoutConn{}
+	_ driver.NamedValueChecker  = &timeoutConn{}
+)
+
+func (c *timeoutConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *timeoutConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *timeoutConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin() //nolint:staticcheck
+}
+
+func (c *timeoutConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if conn, ok := c.conn.(driver.ConnBeginTx); ok {
+		return conn.BeginTx(ctx, opts)
+	}
+	return nil, errors.New("driver does not support BeginTx")
+}
+
+func (c *timeoutConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if conn, ok := c.conn.(driver.ConnPrepareContext); ok {
+		ctx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		return conn.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *timeoutConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return conn.ExecContext(ctx, query, args)
+}
+
+func (c *timeoutConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	rows, err := conn.QueryContext(ctx, query, args)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+func (c *timeoutConn) Ping(ctx context.Context) error {
+	if conn, ok := c.conn.(driver.Pinger); ok {
+		return conn.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timeoutConn) ResetSession(ctx context.Context) error {
+	if conn, ok := c.conn.(driver.SessionResetter); ok {
+		return conn.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timeoutConn) IsValid() bool {
+	if conn, ok := c.conn.(driver.Validator); ok {
+		return conn.IsValid()
+	}
+	return true
+}
+
+func (c *timeoutConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if conn, ok := c.conn.(driver.NamedValueChecker); ok {
+		return conn.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// timeoutRows releases the statement context when the rows are closed.
+type timeoutRows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+func (r *timeoutRows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
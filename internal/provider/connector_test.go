@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConnector returns connections to a fake server of the given version.
+type testConnector struct {
+	version string
+	// slow makes statements block until the context is done.
+	slow bool
+
+	mu       sync.Mutex
+	executed []string
+	connects int
+}
+
+func (c *testConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connects++
+	return &testConn{connector: c}, nil
+}
+
+func (c *testConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (c *testConnector) Executed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.executed...)
+}
+
+type testConn struct {
+	connector *testConnector
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.connector.slow {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	c.connector.mu.Lock()
+	defer c.connector.mu.Unlock()
+	c.connector.executed = append(c.connector.executed, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *testConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	value := "value"
+	if query == "SELECT @@GLOBAL.version" {
+		value = c.connector.version
+	}
+	return &testRows{ctx: ctx, value: value}, nil
+}
+
+// testRows returns a single row unless the context is done.
+type testRows struct {
+	ctx   context.Context
+	value string
+	done  bool
+}
+
+func (r *testRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestConnector_StatementTimeout(t *testing.T) {
+	db := sql.OpenDB(&connector{Connector: &testConnector{version: "8.0.36", slow: true}, statementTimeout: 50 * time.Millisecond})
+	defer func() { _ = db.Close() }()
+
+	started := time.Now()
+	_, err := db.ExecContext(t.Context(), "DO SLEEP(10)")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("statement was not cancelled in time: %s", elapsed)
+	}
+
+	// The statement context must stay alive while reading rows.
+	var value string
+	if err := db.QueryRowContext(t.Context(), "SELECT 'value'").Scan(&value); err != nil {
+		t.Fatal(err)
+	}
+	if value != "value" {
+		t.Errorf("unexpected value: %s", value)
+	}
+}
+
+func TestConnector_NoStatementTimeout(t *testing.T) {
+	c := &connector{Connector: &testConnector{version: "8.0.36"}}
+	conn, err := c.Connect(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := conn.(*timeoutConn); ok {
+		t.Error("connections must not be wrapped without statement timeout")
+	}
+}
+
+func TestConnector_InitSession(t *testing.T) {
+	cases := []struct {
+		name     string
+		version  string
+		expected []string
+	}{
+		{
+			name:    "5.7",
+			version: "5.7.44-log",
+			expected: []string{
+				`SET SESSION sql_mode=CONCAT(@@sql_mode, ',NO_AUTO_CREATE_USER')`,
+				"SET SESSION sql_log_bin=0",
+				`SET SESSION sql_mode=CONCAT(@@sql_mode, ',NO_AUTO_CREATE_USER')`,
+				"SET SESSION sql_log_bin=0",
+			},
+		},
+		{
+			name:     "8.0",
+			version:  "8.0.36",
+			expected: []string{"SET SESSION sql_log_bin=0", "SET SESSION sql_log_bin=0"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			base := &testConnector{version: c.version}
+			db := sql.OpenDB(&connector{Connector: base, initStatements: []string{"SET SESSION sql_log_bin=0"}})
+			defer func() { _ = db.Close() }()
+
+			// Hold two connections at once so that the pool opens both.
+			conn1, err := db.Conn(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = conn1.Close() }()
+			conn2, err := db.Conn(t.Context())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = conn2.Close() }()
+
+			if base.connects != 2 {
+				t.Fatalf("unexpected number of connections: %d", base.connects)
+			}
+			if executed := base.Executed(); !reflect.DeepEqual(executed, c.expected) {
+				t.Errorf("statements must run on every connection: %v", executed)
+			}
+		})
+	}
+}
@@ -58,7 +58,10 @@ func (d *DatabaseDataSource) Schema(_ context.Context, req datasource.SchemaRequ
 }
 
 func (d *DatabaseDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
-	db, err := getDatabase(ctx, d.mysqlConfig)
+	if deferDataSourceRead(d.mysqlConfig, req, resp) {
+		return
+	}
+	conn, err := getConnection(ctx, d.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -86,7 +89,7 @@ WHERE
 	tflog.Info(ctx, fmt.Sprintf("\n%s\n", sql), map[string]any{"args": args})
 
 	var database, defaultCharacterSet, defaultCollation string
-	if err := db.QueryRowContext(ctx, sql, args...).Scan(&database, &defaultCharacterSet, &defaultCollation); err != nil {
+	if err := conn.queryRow(ctx, sqlStatement{query: sql, args: args}, &database, &defaultCharacterSet, &defaultCollation); err != nil {
 		resp.Diagnostics.AddError("Failed querying database", err.Error())
 		return
 	}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -87,7 +88,7 @@ func (r *databaseResource) Configure(ctx context.Context, req resource.Configure
 }
 
 func (r *databaseResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -99,7 +100,7 @@ func (r *databaseResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	database, _ := quoteIdentifier(ctx, db, data.Name.ValueString())
+	database := quoteIdentifier(data.Name.ValueString())
 	sql := fmt.Sprintf("CREATE DATABASE %s", database)
 	var args []interface{}
 	if !data.DefaultCharacterSet.IsNull() {
@@ -110,9 +111,7 @@ func (r *databaseResource) Create(ctx context.Context, req resource.CreateReques
 		sql += " COLLATE ?"
 		args = append(args, data.DefaultCollation.ValueString())
 	}
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err = db.ExecContext(ctx, sql, args...)
+	err = conn.exec(ctx, sqlStatement{query: sql, args: args})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed creating DB", err.Error())
 		return
@@ -124,7 +123,10 @@ func (r *databaseResource) Create(ctx context.Context, req resource.CreateReques
 }
 
 func (r *databaseResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -137,12 +139,15 @@ func (r *databaseResource) Read(ctx context.Context, req resource.ReadRequest, r
 
 	var characterSet, collation string
 	sql := "SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = ?"
-	err = db.QueryRowContext(ctx, sql, data.Id.ValueString()).Scan(&characterSet, &collation)
-	if err != nil {
-		tflog.Error(ctx, err.Error(), map[string]any{"sql": sql, "args": []interface{}{data.Id.ValueString()}})
+	err = conn.queryRow(ctx, sqlStatement{query: sql, args: []any{data.Id.ValueString()}}, &characterSet, &collation)
+	if errors.Is(err, errNotFound) {
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading database (%s)", data.Id.ValueString()), err.Error())
+		return
+	}
 
 	data.Name = data.Id
 	data.DefaultCharacterSet = types.StringValue(characterSet)
@@ -153,7 +158,7 @@ func (r *databaseResource) Read(ctx context.Context, req resource.ReadRequest, r
 }
 
 func (r *databaseResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -165,7 +170,7 @@ func (r *databaseResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	database, _ := quoteIdentifier(ctx, db, data.Name.ValueString())
+	database := quoteIdentifier(data.Name.ValueString())
 	sql := fmt.Sprintf("ALTER DATABASE %s", database)
 	var args []interface{}
 	if !data.DefaultCharacterSet.Equal(state.DefaultCharacterSet) {
@@ -176,9 +181,7 @@ func (r *databaseResource) Update(ctx context.Context, req resource.UpdateReques
 		sql += " COLLATE ?"
 		args = append(args, data.DefaultCollation.ValueString())
 	}
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err = db.ExecContext(ctx, sql, args...)
+	err = conn.exec(ctx, sqlStatement{query: sql, args: args})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed updating DB", err.Error())
 		return
@@ -191,7 +194,7 @@ func (r *databaseResource) Update(ctx context.Context, req resource.UpdateReques
 }
 
 func (r *databaseResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -202,11 +205,9 @@ func (r *databaseResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	database, _ := quoteIdentifier(ctx, db, data.Name.ValueString())
+	database := quoteIdentifier(data.Name.ValueString())
 	sql := fmt.Sprintf("DROP DATABASE %s", database)
-	tflog.Info(ctx, sql)
-
-	_, err = db.ExecContext(ctx, sql)
+	err = conn.exec(ctx, sqlStatement{query: sql})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed deleting DB", err.Error())
 		return
@@ -2,7 +2,6 @@ package provider
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strings"
 
@@ -12,8 +11,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
-	"github.com/hashicorp/terraform-plugin-log/tflog"
-
 	"github.com/okkez/terraform-provider-mysql/internal/utils"
 )
 
@@ -22,6 +19,7 @@ var (
 	_ resource.Resource                = &DefaultRolesResource{}
 	_ resource.ResourceWithConfigure   = &DefaultRolesResource{}
 	_ resource.ResourceWithImportState = &DefaultRolesResource{}
+	_ resource.ResourceWithModifyPlan  = &DefaultRolesResource{}
 )
 
 func NewDefaultRolesResource() resource.Resource {
@@ -47,7 +45,8 @@ func (r *DefaultRolesResource) Metadata(ctx context.Context, req resource.Metada
 
 func (r *DefaultRolesResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "The `mysql_default_role` resource manages default roles for the user.",
+		MarkdownDescription: "The `mysql_default_role` resource manages default roles for the user.\n\n" +
+			"~> **Note:** MariaDB supports a single default role per user.",
 
 		Attributes: map[string]schema.Attribute{
 			"id":   utils.IDAttribute(),
@@ -81,8 +80,16 @@ func (r *DefaultRolesResource) Configure(ctx context.Context, req resource.Confi
 	}
 }
 
+func (r *DefaultRolesResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	resp.Diagnostics.Append(checkCapability(ctx, r.mysqlConfig, path.Empty(), "Roles", func(c Capabilities) bool { return c.Roles })...)
+}
+
 func (r *DefaultRolesResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -96,7 +103,7 @@ func (r *DefaultRolesResource) Create(ctx context.Context, req resource.CreateRe
 
 	user := data.User.ValueString()
 	host := data.Host.ValueString()
-	err = alterDefaultRoles(ctx, db, data)
+	err = alterDefaultRoles(ctx, conn, data)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			fmt.Sprintf("Failed setting default role to user (%s@%s)", user, host),
@@ -109,11 +116,15 @@ func (r *DefaultRolesResource) Create(ctx context.Context, req resource.CreateRe
 }
 
 func (r *DefaultRolesResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
 	}
+	db := conn.Db
 
 	var data *DefaultRolesResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -123,32 +134,28 @@ func (r *DefaultRolesResource) Read(ctx context.Context, req resource.ReadReques
 
 	user := data.User.ValueString()
 	host := data.Host.ValueString()
-	if !utils.UserExists(ctx, db, user, host) {
+	exists, err := utils.UserExists(ctx, db, user, host)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed checking user (%s@%s)", user, host), err.Error())
+		return
+	}
+	if !exists {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
-	var args []interface{}
-	args = append(args, user)
-	args = append(args, host)
-	sql := `
-SELECT
-  DEFAULT_ROLE_USER
-, DEFAULT_ROLE_HOST
-FROM
-  mysql.default_roles
-WHERE
-  USER = ?
-  AND HOST = ?
-`
-
-	rows, err := db.QueryContext(ctx, sql, args...)
+	stmt := readDefaultRolesStatement(conn.Flavor, user, host)
+	rows, err := conn.query(ctx, stmt)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed querying default roles for user (%s@%s)", user, host), err.Error())
 		return
 	}
 	defer func() { _ = rows.Close() }()
 
+	var stateRoles []RoleModel
+	if !data.DefaultRoles.IsNull() {
+		resp.Diagnostics.Append(data.DefaultRoles.ElementsAs(ctx, &stateRoles, false)...)
+	}
 	defaultRoles := []attr.Value{}
 	for rows.Next() {
 		var roleName, roleHost string
@@ -156,6 +163,12 @@ WHERE
 			resp.Diagnostics.AddError("Failed scanning MySQL rows", err.Error())
 			return
 		}
+		if !conn.Flavor.rolesHaveHosts() {
+			roleHost = "%"
+			if role, ok := findRoleByName(stateRoles, roleName); ok {
+				roleHost = role.Host.ValueString()
+			}
+		}
 		roleValues := map[string]attr.Value{}
 		roleValues["name"] = types.StringValue(roleName)
 		roleValues["host"] = types.StringValue(roleHost)
@@ -167,7 +180,7 @@ WHERE
 }
 
 func (r *DefaultRolesResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -181,7 +194,7 @@ func (r *DefaultRolesResource) Update(ctx context.Context, req resource.UpdateRe
 
 	user := data.User.ValueString()
 	host := data.Host.ValueString()
-	err = alterDefaultRoles(ctx, db, data)
+	err = alterDefaultRoles(ctx, conn, data)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			fmt.Sprintf("Failed setting default role to user (%s@%s)", user, host),
@@ -193,7 +206,7 @@ func (r *DefaultRolesResource) Update(ctx context.Context, req resource.UpdateRe
 }
 
 func (r *DefaultRolesResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -207,13 +220,12 @@ func (r *DefaultRolesResource) Delete(ctx context.Context, req resource.DeleteRe
 
 	user := data.User.ValueString()
 	host := data.Host.ValueString()
-	var args []interface{}
-	args = append(args, user)
-	args = append(args, host)
-	sql := `ALTER USER ?@? DEFAULT ROLE NONE`
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err = db.ExecContext(ctx, sql, args...)
+	stmt, err := defaultRolesStatement(conn.Flavor, user, host, nil)
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed deleting default roles for user (%s@%s)", user, host), err.Error())
+		return
+	}
+	err = conn.exec(ctx, stmt)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed deleting default roles for user (%s@%s)", user, host), err.Error())
 		return
@@ -231,34 +243,80 @@ func (r *DefaultRolesResource) ImportState(ctx context.Context, req resource.Imp
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("host"), types.StringValue(userHost[1]))...)
 }
 
-func alterDefaultRoles(ctx context.Context, db *sql.DB, data *DefaultRolesResourceModel) error {
-	user := data.User.ValueString()
-	host := data.Host.ValueString()
+func alterDefaultRoles(ctx context.Context, conn *OneConnection, data *DefaultRolesResourceModel) error {
+	var defaultRoles []RoleModel
+	if !data.DefaultRoles.IsNull() {
+		data.DefaultRoles.ElementsAs(ctx, &defaultRoles, false)
+	}
+	stmt, err := defaultRolesStatement(conn.Flavor, data.User.ValueString(), data.Host.ValueString(), defaultRoles)
+	if err != nil {
+		return err
+	}
+
+	return conn.exec(ctx, stmt)
+}
 
-	var args []interface{}
-	args = append(args, user)
-	args = append(args, host)
-	sql := `ALTER USER ?@? DEFAULT ROLE`
+// defaultRolesStatement returns the statement setting roles as the default
+// roles of the user, or NONE without roles. MariaDB has a single default role.
+func defaultRolesStatement(flavor Flavor, user, host string, roles []RoleModel) (sqlStatement, error) {
+	account, args := flavor.account(user, host)
+	if flavor == FlavorMariaDB {
+		switch len(roles) {
+		case 0:
+			return sqlStatement{query: `SET DEFAULT ROLE NONE FOR ` + account, args: args}, nil
+		case 1:
+			role, _ := flavor.role(roles[0].Name.ValueString(), roles[0].Host.ValueString())
+			return sqlStatement{query: fmt.Sprintf(`SET DEFAULT ROLE %s FOR %s`, role, account), args: args}, nil
+		default:
+			return sqlStatement{}, fmt.Errorf("%s supports only one default role, got %d", flavor, len(roles))
+		}
+	}
 
-	if data.DefaultRoles.IsNull() {
+	sql := `ALTER USER ` + account + ` DEFAULT ROLE`
+	if len(roles) == 0 {
 		sql += ` NONE`
 	} else {
-		var defaultRoles []RoleModel
-		data.DefaultRoles.ElementsAs(ctx, &defaultRoles, false)
 		var placeholders []string
-		for _, role := range defaultRoles {
-			placeholders = append(placeholders, "?@?")
-			args = append(args, role.Name.ValueString())
-			args = append(args, role.Host.ValueString())
+		for _, role := range roles {
+			placeholder, roleArgs := flavor.account(role.Name.ValueString(), role.Host.ValueString())
+			placeholders = append(placeholders, placeholder)
+			args = append(args, roleArgs...)
 		}
 		sql += fmt.Sprintf(` %s`, strings.Join(placeholders, ","))
 	}
+	return sqlStatement{query: sql, args: args}, nil
+}
 
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-	_, err := db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return err
+// readDefaultRolesStatement returns the query selecting the names and the
+// hosts of the default roles of the user.
+func readDefaultRolesStatement(flavor Flavor, user, host string) sqlStatement {
+	if flavor == FlavorMariaDB {
+		return sqlStatement{
+			query: `
+SELECT
+  JSON_VALUE(Priv, '$.default_role')
+, ''
+FROM
+  mysql.global_priv
+WHERE
+  User = ?
+  AND Host = ?
+  AND JSON_VALUE(Priv, '$.default_role') <> ''
+`,
+			args: []any{user, host},
+		}
+	}
+	return sqlStatement{
+		query: `
+SELECT
+  DEFAULT_ROLE_USER
+, DEFAULT_ROLE_HOST
+FROM
+  mysql.default_roles
+WHERE
+  USER = ?
+  AND HOST = ?
+`,
+		args: []any{user, host},
 	}
-
-	return nil
 }
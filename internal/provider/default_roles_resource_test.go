@@ -169,3 +169,52 @@ func testAccTestAccDefaultRoleResource_CheckDestroy(user UserModel) resource.Tes
 		return nil
 	}
 }
+
+func TestDefaultRolesStatement(t *testing.T) {
+	roles := []RoleModel{NewRole("role0", "%"), NewRole("role1", "localhost")}
+	cases := []struct {
+		name     string
+		flavor   Flavor
+		roles    []RoleModel
+		expected sqlStatement
+	}{
+		{
+			name:     "MySQL",
+			flavor:   FlavorMySQL,
+			roles:    roles,
+			expected: sqlStatement{query: "ALTER USER ?@? DEFAULT ROLE ?@?,?@?", args: []any{"user", "%", "role0", "%", "role1", "localhost"}},
+		},
+		{
+			name:     "MySQL none",
+			flavor:   FlavorMySQL,
+			expected: sqlStatement{query: "ALTER USER ?@? DEFAULT ROLE NONE", args: []any{"user", "%"}},
+		},
+		{
+			name:     "MariaDB",
+			flavor:   FlavorMariaDB,
+			roles:    roles[:1],
+			expected: sqlStatement{query: "SET DEFAULT ROLE 'role0' FOR 'user'@'%'"},
+		},
+		{
+			name:     "MariaDB none",
+			flavor:   FlavorMariaDB,
+			expected: sqlStatement{query: "SET DEFAULT ROLE NONE FOR 'user'@'%'"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			stmt, err := defaultRolesStatement(c.flavor, "user", "%", c.roles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testStatement(t, stmt, c.expected)
+		})
+	}
+
+	if _, err := defaultRolesStatement(FlavorMariaDB, "user", "%", roles); err == nil {
+		t.Error("MariaDB must reject more than one default role")
+	}
+	if stmt := readDefaultRolesStatement(FlavorMariaDB, "user", "%"); !regexp.MustCompile(`mysql\.global_priv`).MatchString(stmt.query) {
+		t.Errorf("unexpected MariaDB query: %s", stmt.query)
+	}
+}
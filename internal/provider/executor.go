@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+)
+
+// The server errors which go away when the statement runs again.
+const (
+	// erLockWaitTimeout is also returned for metadata lock waits.
+	erLockWaitTimeout = 1205
+	erLockDeadlock    = 1213
+	// erOptionPreventsStatement is returned by a read only server, e.g.
+	// while a replica is promoted during a failover.
+	erOptionPreventsStatement = 1290
+	// erLockAborted is returned when a metadata lock wait is aborted for a
+	// pending exclusive lock.
+	erLockAborted = 1689
+)
+
+// redactedValue replaces the secrets of statements in the logs.
+const redactedValue = "<redacted>"
+
+// statementLogMtx serializes the writes to the statement logs, since
+// resources are applied concurrently.
+var statementLogMtx sync.Mutex
+
+// exec runs stmt, which changes the server. The statement is logged first,
+// and is not run in dry run mode.
+func (c *OneConnection) exec(ctx context.Context, stmt sqlStatement) error {
+	if err := c.logStatement(ctx, stmt); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+	return c.withRetry(ctx, func() error {
+		_, err := c.Db.ExecContext(ctx, c.sessionQuery(stmt.query), stmt.args...)
+		return err
+	})
+}
+
+// execRows runs stmt, which changes the server and returns rows such as the
+// generated passwords of CREATE USER. scan is called for every row. Neither
+// runs in dry run mode.
+func (c *OneConnection) execRows(ctx context.Context, stmt sqlStatement, scan func(rows *sql.Rows) error) error {
+	if err := c.logStatement(ctx, stmt); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+	rows, err := c.query(ctx, stmt)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// query runs stmt, which reads the server. The rows must be closed. The error
+// wraps errNotFound when the object does not exist.
+func (c *OneConnection) query(ctx context.Context, stmt sqlStatement) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := c.withRetry(ctx, func() error {
+		var err error
+		rows, err = c.Db.QueryContext(ctx, c.sessionQuery(stmt.query), stmt.args...)
+		return err
+	})
+	return rows, notFound(err)
+}
+
+// queryRow runs stmt, which reads a row of the server, and scans the row into
+// dest. The error wraps errNotFound when there is no row or the object does
+// not exist.
+func (c *OneConnection) queryRow(ctx context.Context, stmt sqlStatement, dest ...any) error {
+	return notFound(c.withRetry(ctx, func() error {
+		return c.Db.QueryRowContext(ctx, c.sessionQuery(stmt.query), stmt.args...).Scan(dest...)
+	}))
+}
+
+// sessionQuery returns query with the string literals written for the
+// sql_mode of the sessions of c.
+func (c *OneConnection) sessionQuery(query string) string {
+	if c.NoBackslashEscapes {
+		return withoutBackslashEscapes(query)
+	}
+	return query
+}
+
+// withRetry calls run until it succeeds, fails with an error which is not
+// transient or StatementRetryTimeout passes. The wait between the calls
+// grows exponentially.
+func (c *OneConnection) withRetry(ctx context.Context, run func() error) error {
+	if c.StatementRetryTimeout <= 0 {
+		return run()
+	}
+	return retry.RetryContext(ctx, c.StatementRetryTimeout, func() *retry.RetryError {
+		err := run()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() == nil && isTransientError(err) {
+			tflog.Warn(ctx, "Retrying the statement", map[string]any{"error": err.Error()})
+			return retry.RetryableError(err)
+		}
+		return retry.NonRetryableError(err)
+	})
+}
+
+// isTransientError reports whether err goes away when the statement runs
+// again, e.g. deadlocks and connections lost during a failover.
+func isTransientError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	switch mysqlErrorNumber(err) {
+	case erLockWaitTimeout, erLockDeadlock, erOptionPreventsStatement, erLockAborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// logStatement writes stmt with the arguments bound and the secrets redacted
+// to the provider log and appends it to the statement log.
+func (c *OneConnection) logStatement(ctx context.Context, stmt sqlStatement) error {
+	statement := c.sessionQuery(stmt.redacted())
+	tflog.Info(ctx, statement, map[string]any{"dry_run": c.DryRun})
+	if c.StatementLog == "" {
+		return nil
+	}
+
+	comment := time.Now().UTC().Format(time.RFC3339)
+	if c.DryRun {
+		comment += " (dry run)"
+	}
+
+	statementLogMtx.Lock()
+	defer statementLogMtx.Unlock()
+
+	f, err := os.OpenFile(c.StatementLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed opening the statement log: %w", err)
+	}
+	_, err = fmt.Fprintf(f, "-- %s\n%s;\n", comment, statement)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed writing the statement log: %w", err)
+	}
+	return nil
+}
+
+// redacted returns the statement with the arguments bound as literals and
+// the secrets replaced, so that it can be logged and replayed.
+func (s sqlStatement) redacted() string {
+	query := s.query
+	args := make([]any, len(s.args))
+	copy(args, s.args)
+	for _, secret := range s.secrets {
+		if secret == "" {
+			continue
+		}
+		query = strings.ReplaceAll(query, quoteString(secret), quoteString(redactedValue))
+		for i, arg := range args {
+			if arg == secret {
+				args[i] = redactedValue
+			}
+		}
+	}
+	return bindArgs(query, args)
+}
+
+// bindArgs replaces the placeholders of query with args as literals.
+// Question marks in quoted strings and identifiers are kept.
+func bindArgs(query string, args []any) string {
+	if len(args) == 0 {
+		return query
+	}
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range query {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			// A doubled quote closes and reopens the literal, which needs no
+			// special handling.
+			if r == '\\' && quote != '`' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?' && len(args) > 0:
+			b.WriteString(literal(args[0]))
+			args = args[1:]
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// literal returns arg as an SQL literal.
+func literal(arg any) string {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteString(v)
+	case []byte:
+		return quoteString(string(v))
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	default:
+		return quoteString(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestBindArgs(t *testing.T) {
+	cases := []struct {
+		query    string
+		args     []any
+		expected string
+	}{
+		{"CREATE ROLE ?@?", []any{"role", "%"}, "CREATE ROLE 'role'@'%'"},
+		{"GRANT SELECT ON `db?`.* TO ?@?", []any{"user", "%"}, "GRANT SELECT ON `db?`.* TO 'user'@'%'"},
+		{`SELECT 'it''s?', 'back\'?', ?`, []any{"x"}, `SELECT 'it''s?', 'back\'?', 'x'`},
+		{"SET GLOBAL max_connections = ?", []any{int64(100)}, "SET GLOBAL max_connections = 100"},
+		{"SET GLOBAL long_query_time = ?", []any{1.5}, "SET GLOBAL long_query_time = 1.5"},
+		{"SELECT ?", []any{nil}, "SELECT NULL"},
+		{"DROP USER 'user'@'%'", nil, "DROP USER 'user'@'%'"},
+	}
+	for _, c := range cases {
+		if got := bindArgs(c.query, c.args); got != c.expected {
+			t.Errorf("bindArgs(%q, %v) = %s, want %s", c.query, c.args, got, c.expected)
+		}
+	}
+}
+
+func TestSQLStatementRedacted(t *testing.T) {
+	authOption := &AuthOptionModel{
+		Plugin:         types.StringNull(),
+		AuthString:     types.StringValue("it's secret"),
+		RandomPassword: types.BoolNull(),
+	}
+	for flavor, expected := range map[Flavor]string{
+		FlavorMySQL:   `CREATE USER 'user'@'%' IDENTIFIED BY '<redacted>'`,
+		FlavorMariaDB: `CREATE USER 'user'@'%' IDENTIFIED BY '<redacted>'`,
+	} {
+		stmt := createUserStatement(flavor, "user", "%", authOption, nil, "", false, "")
+		if got := stmt.redacted(); got != expected {
+			t.Errorf("%s: unexpected statement: %s", flavor, got)
+		}
+	}
+}
+
+func TestOneConnectionExec_DryRun(t *testing.T) {
+	statementLog := filepath.Join(t.TempDir(), "statements.sql")
+	// Db is nil, so running a statement panics.
+	conn := &OneConnection{Flavor: FlavorMySQL, StatementLog: statementLog, DryRun: true}
+
+	if err := conn.exec(t.Context(), createRoleStatement(FlavorMySQL, "role", "%")); err != nil {
+		t.Fatal(err)
+	}
+	err := conn.execRows(t.Context(), sqlStatement{query: "ALTER USER ?@? IDENTIFIED BY RANDOM PASSWORD", args: []any{"user", "%"}}, func(*sql.Rows) error {
+		t.Error("rows must not be scanned in dry run mode")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(statementLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := regexp.MustCompile(`\A-- \S+ \(dry run\)\nCREATE ROLE 'role'@'%';\n-- \S+ \(dry run\)\nALTER USER 'user'@'%' IDENTIFIED BY RANDOM PASSWORD;\n\z`)
+	if !expected.Match(content) {
+		t.Errorf("unexpected statement log:\n%s", content)
+	}
+}
+
+func TestOneConnectionExec_NoBackslashEscapes(t *testing.T) {
+	conf := testMySQLConfig()
+	conf.Config.User = "no_backslash_escapes"
+	var queries []string
+	conn := testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		queries = append(queries, query)
+		return &fakeRows{}, nil
+	})
+	conn.NoBackslashEscapes = true
+
+	stmt := sqlStatement{query: "ALTER USER 'user'@'%' IDENTIFIED BY " + quoteString(`pass\'word`)}
+	if err := conn.exec(t.Context(), stmt); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || queries[0] != `ALTER USER 'user'@'%' IDENTIFIED BY 'pass\''word'` {
+		t.Errorf("unexpected queries: %q", queries)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, true},
+		{&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, true},
+		{&mysql.MySQLError{Number: 1689, Message: "Wait on a lock was aborted due to a pending exclusive lock"}, true},
+		{&mysql.MySQLError{Number: 1290, Message: "The MySQL server is running with the --read-only option"}, true},
+		{fmt.Errorf("failed creating user: %w", &mysql.MySQLError{Number: 1213}), true},
+		{driver.ErrBadConn, true},
+		{mysql.ErrInvalidConn, true},
+		{&mysql.MySQLError{Number: 1396, Message: "Operation CREATE USER failed"}, false},
+		{&mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}, false},
+		{&mysql.MySQLError{Number: 1045, Message: "Access denied"}, false},
+		{sql.ErrNoRows, false},
+		{errors.New("unknown"), false},
+	}
+	for _, c := range cases {
+		if got := isTransientError(c.err); got != c.expected {
+			t.Errorf("isTransientError(%v) = %v", c.err, got)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{sql.ErrNoRows, true},
+		{&mysql.MySQLError{Number: 1049, Message: "Unknown database 'db'"}, true},
+		{&mysql.MySQLError{Number: 1141, Message: "There is no such grant defined"}, true},
+		{&mysql.MySQLError{Number: 1193, Message: "Unknown system variable 'x'"}, true},
+		{fmt.Errorf("failed reading: %w", sql.ErrNoRows), true},
+		{&mysql.MySQLError{Number: 1142, Message: "SELECT command denied"}, false},
+		{driver.ErrBadConn, false},
+		{errors.New("unknown"), false},
+	}
+	for _, c := range cases {
+		err := notFound(c.err)
+		if got := errors.Is(err, errNotFound); got != c.expected {
+			t.Errorf("notFound(%v) is errNotFound: %v", c.err, got)
+		}
+		if !errors.Is(err, c.err) {
+			t.Errorf("notFound(%v) = %v, must wrap the error", c.err, err)
+		}
+	}
+	if err := notFound(nil); err != nil {
+		t.Errorf("notFound(nil) = %v", err)
+	}
+}
+
+func TestOneConnectionWithRetry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	duplicate := &mysql.MySQLError{Number: 1396, Message: "Operation CREATE USER failed"}
+	cases := []struct {
+		name    string
+		timeout time.Duration
+		errs    []error
+		calls   int
+		err     error
+	}{
+		{"transient errors are retried", time.Minute, []error{deadlock, driver.ErrBadConn, nil}, 3, nil},
+		{"permanent errors fail immediately", time.Minute, []error{duplicate, nil}, 1, duplicate},
+		{"permanent errors after transient ones", time.Minute, []error{deadlock, duplicate, nil}, 2, duplicate},
+		{"retries are disabled", 0, []error{deadlock, nil}, 1, deadlock},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conn := &OneConnection{StatementRetryTimeout: c.timeout}
+			calls := 0
+			err := conn.withRetry(t.Context(), func() error {
+				err := c.errs[calls]
+				calls++
+				return err
+			})
+			if !errors.Is(err, c.err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if calls != c.calls {
+				t.Errorf("run is called %d times, want %d", calls, c.calls)
+			}
+		})
+	}
+
+	conn := &OneConnection{StatementRetryTimeout: time.Second}
+	calls := 0
+	err := conn.withRetry(t.Context(), func() error {
+		calls++
+		return deadlock
+	})
+	if !errors.Is(err, deadlock) || calls < 2 {
+		t.Errorf("transient errors must be retried until the timeout: %d calls, %v", calls, err)
+	}
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+// fakeRows are the rows returned by fakeConn.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+var _ driver.Rows = &fakeRows{}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakeHandler answers a statement with rows or an error.
+type fakeHandler func(query string) (*fakeRows, error)
+
+// fakeConnector connects to a fake server whose statements are answered by
+// handle, so that tests can fabricate the errors of the server.
+type fakeConnector struct {
+	handle fakeHandler
+}
+
+var _ driver.Connector = &fakeConnector{}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{handle: c.handle}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver connects with fakeConnector only")
+}
+
+type fakeConn struct {
+	handle fakeHandler
+}
+
+var (
+	_ driver.ExecerContext  = &fakeConn{}
+	_ driver.QueryerContext = &fakeConn{}
+)
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not prepare statements")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake driver does not support transactions")
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.handle(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.handle(query)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = &fakeRows{columns: []string{"column"}}
+	}
+	return rows, nil
+}
+
+// testFakeConnection caches a connection to the fake server answering with
+// handle for conf, so that resources configured with conf use it.
+func testFakeConnection(t *testing.T, conf *MySQLConfiguration, handle fakeHandler) *OneConnection {
+	t.Helper()
+	v := version.Must(version.NewVersion("8.0.36"))
+	conn := &OneConnection{
+		Db:           sql.OpenDB(&fakeConnector{handle: handle}),
+		Version:      v,
+		Flavor:       FlavorMySQL,
+		Capabilities: newCapabilities(v, FlavorMySQL),
+	}
+	key := conf.cacheKey()
+	connectionCacheMtx.Lock()
+	connectionCache[key] = conn
+	connectionCacheMtx.Unlock()
+	t.Cleanup(func() {
+		connectionCacheMtx.Lock()
+		defer connectionCacheMtx.Unlock()
+		delete(connectionCache, key)
+		_ = conn.Db.Close()
+	})
+	return conn
+}
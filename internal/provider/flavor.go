@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"strings"
+)
+
+// Flavor is the distribution of the server. The resources build their SQL for
+// the flavor, since account management differs between distributions.
+type Flavor int
+
+const (
+	FlavorMySQL Flavor = iota
+	FlavorMariaDB
+	FlavorPercona
+	FlavorTiDB
+)
+
+func (f Flavor) String() string {
+	switch f {
+	case FlavorMariaDB:
+		return "MariaDB"
+	case FlavorPercona:
+		return "Percona Server"
+	case FlavorTiDB:
+		return "TiDB"
+	default:
+		return "MySQL"
+	}
+}
+
+// detectFlavor detects the flavor from @@GLOBAL.version and
+// @@GLOBAL.version_comment.
+func detectFlavor(versionString, versionComment string) Flavor {
+	switch {
+	case strings.Contains(versionString, "MariaDB"):
+		return FlavorMariaDB
+	case strings.Contains(versionString, "TiDB"):
+		return FlavorTiDB
+	case strings.Contains(strings.ToLower(versionComment), "percona"):
+		return FlavorPercona
+	default:
+		return FlavorMySQL
+	}
+}
+
+// rolesHaveHosts reports whether roles are accounts with a host part.
+// MariaDB roles have a name only.
+func (f Flavor) rolesHaveHosts() bool {
+	return f != FlavorMariaDB
+}
+
+// sqlStatement is a statement and its arguments.
+type sqlStatement struct {
+	query string
+	args  []any
+	// secrets are the values in the query or the arguments which must not
+	// be logged, e.g. passwords.
+	secrets []string
+}
+
+// account returns the account `name@host` to embed in a statement and its
+// arguments. MariaDB takes account names as literals, and an account without
+// a host is a role there.
+func (f Flavor) account(name, host string) (string, []any) {
+	if f == FlavorMariaDB {
+		if host == "" {
+			return quoteString(name), nil
+		}
+		return quoteString(name) + "@" + quoteString(host), nil
+	}
+	return "?@?", []any{name, host}
+}
+
+// role returns the role to embed in a statement and its arguments. The host
+// is omitted when empty, and always on MariaDB.
+func (f Flavor) role(name, host string) (string, []any) {
+	if f == FlavorMariaDB {
+		return quoteString(name), nil
+	}
+	if host == "" {
+		return "?", []any{name}
+	}
+	return "?@?", []any{name, host}
+}
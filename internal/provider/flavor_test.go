@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testStatement fails the test when got differs from expected.
+func testStatement(t *testing.T, got, expected sqlStatement) {
+	t.Helper()
+	if got.query != expected.query {
+		t.Errorf("unexpected query:\n got: %s\nwant: %s", got.query, expected.query)
+	}
+	if !reflect.DeepEqual(got.args, expected.args) {
+		t.Errorf("unexpected args: got %v, want %v", got.args, expected.args)
+	}
+}
+
+func TestDetectFlavor(t *testing.T) {
+	cases := []struct {
+		version        string
+		versionComment string
+		expected       Flavor
+		serverVersion  string
+	}{
+		{"8.0.36", "MySQL Community Server - GPL", FlavorMySQL, "8.0.36"},
+		{"8.4.0-log", "Source distribution", FlavorMySQL, "8.4.0-log"},
+		{"10.11.6-MariaDB-log", "MariaDB Server", FlavorMariaDB, "10.11.6-MariaDB-log"},
+		{"5.5.5-10.6.16-MariaDB-1:10.6.16+maria~ubu2004", "mariadb.org binary distribution", FlavorMariaDB, "10.6.16-MariaDB-1"},
+		{"8.0.35-27", "Percona Server (GPL), Release 27, Revision 2f8eeab2", FlavorPercona, "8.0.35-27"},
+		{"8.0.11-TiDB-v7.5.0", "TiDB Server (Apache License 2.0) Community Edition, MySQL 8.0 compatible", FlavorTiDB, "8.0.11-TiDB-v7.5.0"},
+	}
+	for _, c := range cases {
+		t.Run(c.version, func(t *testing.T) {
+			if flavor := detectFlavor(c.version, c.versionComment); flavor != c.expected {
+				t.Errorf("unexpected flavor: %s", flavor)
+			}
+			v, err := parseServerVersion(c.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Original() != c.serverVersion {
+				t.Errorf("unexpected version: %s", v.Original())
+			}
+		})
+	}
+}
+
+func TestFlavorAccount(t *testing.T) {
+	cases := []struct {
+		flavor  Flavor
+		name    string
+		host    string
+		account string
+		role    string
+		args    []any
+	}{
+		{FlavorMySQL, "user", "%", "?@?", "?@?", []any{"user", "%"}},
+		{FlavorTiDB, "user", "%", "?@?", "?@?", []any{"user", "%"}},
+		{FlavorMariaDB, "user", "%", "'user'@'%'", "'user'", nil},
+		{FlavorMariaDB, "role", "", "'role'", "'role'", nil},
+		{FlavorMariaDB, `it's\`, "%", `'it''s\\'@'%'`, `'it''s\\'`, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.flavor.String()+"/"+c.name, func(t *testing.T) {
+			account, args := c.flavor.account(c.name, c.host)
+			if account != c.account || !reflect.DeepEqual(args, c.args) {
+				t.Errorf("unexpected account: %s %v", account, args)
+			}
+			role, _ := c.flavor.role(c.name, c.host)
+			if role != c.role {
+				t.Errorf("unexpected role: %s", role)
+			}
+		})
+	}
+
+	if role, args := FlavorMySQL.role("role", ""); role != "?" || !reflect.DeepEqual(args, []any{"role"}) {
+		t.Errorf("roles without a host must omit it: %s %v", role, args)
+	}
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const gcpMetadataHost = "metadata.google.internal"
+
+// gcpCloudSQLIAMAuthModel describes the `gcp_cloudsql_iam_auth` block of the
+// provider configuration.
+type gcpCloudSQLIAMAuthModel struct {
+	AccessToken types.String `tfsdk:"access_token"`
+}
+
+// newGCPCloudSQLIAMTokenSource returns a token source issuing OAuth 2.0 access
+// tokens for Cloud SQL IAM database authentication. The given access token is
+// used as is, otherwise tokens are issued by the metadata server for the
+// service account attached to the instance.
+func newGCPCloudSQLIAMTokenSource(data gcpCloudSQLIAMAuthModel) tokenSource {
+	accessToken := data.AccessToken.ValueString()
+	if accessToken == "" {
+		accessToken = os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	}
+	if accessToken != "" {
+		return staticTokenSource(accessToken)
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = gcpMetadataHost
+	}
+	return &gcpMetadataTokenSource{
+		baseURL: "http://" + host,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+	}
+}
+
+// gcpMetadataTokenSource issues access tokens of the default service account
+// from the GCE metadata server.
+type gcpMetadataTokenSource struct {
+	baseURL string
+	client  *http.Client
+	now     func() time.Time
+}
+
+var _ tokenSource = &gcpMetadataTokenSource{}
+
+func (s *gcpMetadataTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return fetchOAuthToken(s.client, req, s.now())
+}
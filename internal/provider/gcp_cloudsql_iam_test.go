@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestGCPMetadataTokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/token" || r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := &gcpMetadataTokenSource{baseURL: server.URL, client: server.Client(), now: func() time.Time { return now }}
+	token, expiry, err := source.Token(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "ya29.token" || !expiry.Equal(now.Add(3599*time.Second)) {
+		t.Errorf("unexpected token: %s, %s", token, expiry)
+	}
+}
+
+func TestNewGCPCloudSQLIAMTokenSource(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "from-env")
+	t.Setenv("GCE_METADATA_HOST", "127.0.0.1:8080")
+
+	source := newGCPCloudSQLIAMTokenSource(gcpCloudSQLIAMAuthModel{AccessToken: types.StringValue("from-config")})
+	if source != staticTokenSource("from-config") {
+		t.Errorf("access_token must take precedence: %v", source)
+	}
+	source = newGCPCloudSQLIAMTokenSource(gcpCloudSQLIAMAuthModel{AccessToken: types.StringNull()})
+	if source != staticTokenSource("from-env") {
+		t.Errorf("access token must be sourced from the environment: %v", source)
+	}
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	source = newGCPCloudSQLIAMTokenSource(gcpCloudSQLIAMAuthModel{AccessToken: types.StringNull()})
+	metadata, ok := source.(*gcpMetadataTokenSource)
+	if !ok || metadata.baseURL != "http://127.0.0.1:8080" {
+		t.Errorf("unexpected token source: %#v", source)
+	}
+}
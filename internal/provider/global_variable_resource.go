@@ -2,7 +2,7 @@ package provider
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -84,7 +84,7 @@ func (r *GlobalVariableResource) Configure(ctx context.Context, req resource.Con
 }
 
 func (r *GlobalVariableResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -98,7 +98,7 @@ func (r *GlobalVariableResource) Create(ctx context.Context, req resource.Create
 
 	name := data.Name.ValueString()
 	value := data.Value.ValueString()
-	err = setGlobalVariable(ctx, db, name, value)
+	err = setGlobalVariable(ctx, conn, name, value)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed setting global variable (%s)", name), err.Error())
 		return
@@ -109,7 +109,10 @@ func (r *GlobalVariableResource) Create(ctx context.Context, req resource.Create
 }
 
 func (r *GlobalVariableResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -126,12 +129,16 @@ func (r *GlobalVariableResource) Read(ctx context.Context, req resource.ReadRequ
 	tflog.Info(ctx, sql)
 
 	var value string
-	err = db.QueryRowContext(ctx, sql).Scan(&value)
-	if err != nil {
-		resp.Diagnostics.AddWarning("Failed scanning MySQL rows", err.Error())
+	err = conn.queryRow(ctx, sqlStatement{query: sql}, &value)
+	if errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddWarning(fmt.Sprintf("Unknown global variable (%s)", name), err.Error())
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading global variable (%s)", name), err.Error())
+		return
+	}
 	data.ID = types.StringValue(name)
 	data.Name = types.StringValue(name)
 	data.Value = types.StringValue(value)
@@ -140,7 +147,7 @@ func (r *GlobalVariableResource) Read(ctx context.Context, req resource.ReadRequ
 }
 
 func (r *GlobalVariableResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -154,7 +161,7 @@ func (r *GlobalVariableResource) Update(ctx context.Context, req resource.Update
 
 	name := data.Name.ValueString()
 	value := data.Value.ValueString()
-	err = setGlobalVariable(ctx, db, name, value)
+	err = setGlobalVariable(ctx, conn, name, value)
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed setting global variable (%s)", name), err.Error())
 		return
@@ -164,7 +171,7 @@ func (r *GlobalVariableResource) Update(ctx context.Context, req resource.Update
 }
 
 func (r *GlobalVariableResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -178,8 +185,7 @@ func (r *GlobalVariableResource) Delete(ctx context.Context, req resource.Delete
 
 	name := data.Name.ValueString()
 	sql := fmt.Sprintf(`SET GLOBAL %s = DEFAULT`, name)
-	tflog.Info(ctx, sql)
-	_, err = db.ExecContext(ctx, sql)
+	err = conn.exec(ctx, sqlStatement{query: sql})
 	if err != nil {
 		resp.Diagnostics.AddError(fmt.Sprintf("Failed resetting global variable (%s)", name), err.Error())
 		return
@@ -191,7 +197,7 @@ func (r *GlobalVariableResource) ImportState(ctx context.Context, req resource.I
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
 }
 
-func setGlobalVariable(ctx context.Context, db *sql.DB, name, value string) error {
+func setGlobalVariable(ctx context.Context, conn *OneConnection, name, value string) error {
 	var args []interface{}
 	sql := fmt.Sprintf(`SET GLOBAL %s = ?`, name)
 	if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
@@ -202,12 +208,5 @@ func setGlobalVariable(ctx context.Context, db *sql.DB, name, value string) erro
 		args = append(args, value)
 	}
 
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err := db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return conn.exec(ctx, sqlStatement{query: sql, args: args})
 }
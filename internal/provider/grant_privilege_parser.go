@@ -5,10 +5,13 @@ import (
 
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/ast"
+	tidbmysql "github.com/pingcap/tidb/parser/mysql"
 	_ "github.com/pingcap/tidb/parser/test_driver"
 )
 
 type GrantPrivilege struct {
+	// ObjectType is TABLE, FUNCTION or PROCEDURE.
+	ObjectType  string
 	DBName      string
 	TableName   string
 	Username    string
@@ -22,6 +25,14 @@ func (v *GrantPrivilege) Enter(in ast.Node) (ast.Node, bool) {
 		us := g.Users[0]
 		v.Username = us.User.Username
 		v.Hostname = us.User.Hostname
+		switch g.ObjectType {
+		case ast.ObjectTypeFunction:
+			v.ObjectType = objectTypeFunction
+		case ast.ObjectTypeProcedure:
+			v.ObjectType = objectTypeProcedure
+		default:
+			v.ObjectType = objectTypeTable
+		}
 		if len(g.Level.DBName) == 0 {
 			v.DBName = "*"
 		} else {
@@ -65,9 +76,16 @@ func (v *GrantPrivilege) PrivString() string {
 	return strings.Join(privs, ",")
 }
 
-func (v *GrantPrivilege) Match(dbName, tableName, username, hostname string) bool {
-	return v.DBName == dbName &&
-		v.TableName == tableName &&
+// Match reports whether the grant is on the object and to the user. Routine
+// names are case-insensitive.
+func (v *GrantPrivilege) Match(objectType, dbName, tableName, username, hostname string) bool {
+	sameTable := v.TableName == tableName
+	if objectType != objectTypeTable {
+		sameTable = strings.EqualFold(v.TableName, tableName)
+	}
+	return v.ObjectType == objectType &&
+		v.DBName == dbName &&
+		sameTable &&
 		v.Username == username &&
 		v.Hostname == hostname
 }
@@ -78,8 +96,11 @@ func extract(rootNode *ast.StmtNode) *GrantPrivilege {
 	return v
 }
 
-func parse(sql string) (*ast.StmtNode, error) {
+func parse(sql string, ansiQuotes bool) (*ast.StmtNode, error) {
 	p := parser.New()
+	if ansiQuotes {
+		p.SetSQLMode(tidbmysql.ModeANSIQuotes)
+	}
 
 	stmtNodes, _, err := p.Parse(sql, "", "")
 	if err != nil {
@@ -89,11 +110,36 @@ func parse(sql string) (*ast.StmtNode, error) {
 	return &stmtNodes[0], nil
 }
 
-func ParseGrantPrivilegeStatement(sql string) (*GrantPrivilege, error) {
-	astNode, err := parse(sql)
+// ParseGrantPrivilegeStatement parses a row of SHOW GRANTS. ansiQuotes must be
+// true when the session quotes identifiers with double quotes.
+func ParseGrantPrivilegeStatement(sql string, ansiQuotes bool) (*GrantPrivilege, error) {
+	astNode, err := parse(sql, ansiQuotes)
 	if err != nil {
 		return nil, err
 	}
 
 	return extract(astNode), nil
 }
+
+// ParseShowGrantsStatement parses a row of SHOW GRANTS like
+// ParseGrantPrivilegeStatement, but returns nil for the rows other than
+// privilege grants, e.g. role grants, proxy grants, partial revokes and the
+// SET DEFAULT ROLE rows of MariaDB.
+func ParseShowGrantsStatement(sql string, ansiQuotes bool) (*GrantPrivilege, error) {
+	sql = strings.TrimSpace(sql)
+	// The parser does not know some of the other rows, e.g. SET DEFAULT ROLE
+	// ... FOR, so they are skipped before parsing.
+	if len(sql) < len("GRANT ") || !strings.EqualFold(sql[:len("GRANT ")], "GRANT ") {
+		return nil, nil
+	}
+	// The parser does not know WITH ADMIN OPTION of role grants.
+	sql = strings.TrimSuffix(sql, " WITH ADMIN OPTION")
+	astNode, err := parse(sql, ansiQuotes)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := (*astNode).(*ast.GrantStmt); !ok {
+		return nil, nil
+	}
+	return extract(astNode), nil
+}
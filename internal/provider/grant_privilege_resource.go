@@ -2,7 +2,7 @@ package provider
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -10,6 +10,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/okkez/terraform-provider-mysql/internal/utils"
 
+	"github.com/pingcap/tidb/parser/ast"
 	"github.com/r3labs/diff/v3"
 )
 
@@ -31,6 +33,9 @@ import (
 var (
 	_ resource.Resource                = &GrantPrivilegeResource{}
 	_ resource.ResourceWithImportState = &GrantPrivilegeResource{}
+	_ resource.ResourceWithModifyPlan  = &GrantPrivilegeResource{}
+
+	_ resource.ResourceWithValidateConfig = &GrantPrivilegeResource{}
 )
 
 func NewGrantPrivilegeResource() resource.Resource {
@@ -62,8 +67,29 @@ var PrivlilegeTypeModelTypes = map[string]attr.Type{
 }
 
 type PrivilegeLevelModel struct {
-	Database types.String `tfsdk:"database"`
-	Table    types.String `tfsdk:"table"`
+	ObjectType types.String `tfsdk:"object_type"`
+	Database   types.String `tfsdk:"database"`
+	Table      types.String `tfsdk:"table"`
+}
+
+// The object types of privilege levels. The object type of a null
+// object_type is TABLE.
+const (
+	objectTypeTable     = "TABLE"
+	objectTypeFunction  = "FUNCTION"
+	objectTypeProcedure = "PROCEDURE"
+)
+
+// objectType returns the object type of the privilege level.
+func (m PrivilegeLevelModel) objectType() string {
+	return objectTypeOf(m.ObjectType)
+}
+
+func objectTypeOf(objectType types.String) string {
+	if objectType.IsNull() || objectType.IsUnknown() {
+		return objectTypeTable
+	}
+	return objectType.ValueString()
 }
 
 type PrivilegeTypeRaw struct {
@@ -91,28 +117,27 @@ func (r *GrantPrivilegeResource) Schema(ctx context.Context, req resource.Schema
 			},
 		},
 		Blocks: map[string]schema.Block{
-			"privilege": schema.SetNestedBlock{
-				MarkdownDescription: "Set privilege name and columns.",
-				NestedObject: schema.NestedBlockObject{
-					Attributes: map[string]schema.Attribute{
-						"priv_type": schema.StringAttribute{
-							MarkdownDescription: "The privilege name.",
-							Required:            true,
-							Validators: []validator.String{
-								stringvalidator.RegexMatches(regexp.MustCompile(`\A[A-Z_ ]+\z`), "priv_type must be upper cases"),
-							},
-						},
-						"columns": schema.SetAttribute{
-							MarkdownDescription: "Column names.",
-							ElementType:         types.StringType,
-							Optional:            true,
-						},
-					},
-				},
-			},
+			"privilege": privilegeBlock(),
 			"on": schema.SingleNestedBlock{
 				MarkdownDescription: "Set the target to grant privileges.",
 				Attributes: map[string]schema.Attribute{
+					"object_type": schema.StringAttribute{
+						MarkdownDescription: "The type of the object, `TABLE`, `FUNCTION` or `PROCEDURE`. " +
+							"Set `FUNCTION` or `PROCEDURE` to grant privileges such as `EXECUTE` on the stored routine named `table`. Defaults to `TABLE`.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf(objectTypeTable, objectTypeFunction, objectTypeProcedure),
+						},
+						PlanModifiers: []planmodifier.String{
+							stringplanmodifier.RequiresReplaceIf(
+								func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+									resp.RequiresReplace = objectTypeOf(req.StateValue) != objectTypeOf(req.PlanValue)
+								},
+								"Changing the object type requires replacement. A null object type is the same as `TABLE`.",
+								"Changing the object type requires replacement. A null object type is the same as `TABLE`.",
+							),
+						},
+					},
 					"database": schema.StringAttribute{
 						MarkdownDescription: "The database name to grant privileges.",
 						Required:            true,
@@ -121,7 +146,7 @@ func (r *GrantPrivilegeResource) Schema(ctx context.Context, req resource.Schema
 						},
 					},
 					"table": schema.StringAttribute{
-						MarkdownDescription: "The table name to grant privileges.",
+						MarkdownDescription: "The table name to grant privileges, or the routine name when `object_type` is `FUNCTION` or `PROCEDURE`.",
 						Required:            true,
 						PlanModifiers: []planmodifier.String{
 							stringplanmodifier.RequiresReplace(),
@@ -153,8 +178,49 @@ func (r *GrantPrivilegeResource) Configure(ctx context.Context, req resource.Con
 	}
 }
 
+func (r *GrantPrivilegeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var privileges types.Set
+	var on types.Object
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("privilege"), &privileges)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("on"), &on)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	level, diags := grantLevelOf(ctx, on)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(validatePrivileges(ctx, path.Root("privilege"), privileges, level)...)
+}
+
+func (r *GrantPrivilegeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var privilegeSet types.Set
+	var on types.Object
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("privilege"), &privilegeSet)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on"), &on)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// The level may be unknown while validating, e.g. when the database is
+	// the name of mysql_database.
+	level, diags := grantLevelOf(ctx, on)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(validatePrivileges(ctx, path.Root("privilege"), privilegeSet, level)...)
+	if privilegeSet.IsUnknown() {
+		return
+	}
+	var privileges []PrivilegeTypeModel
+	resp.Diagnostics.Append(privilegeSet.ElementsAs(ctx, &privileges, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(checkServerPrivileges(ctx, r.mysqlConfig, path.Root("privilege"), privileges)...)
+}
+
 func (r *GrantPrivilegeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -177,7 +243,7 @@ func (r *GrantPrivilegeResource) Create(ctx context.Context, req resource.Create
 		return
 	}
 
-	err = grantPrivileges(ctx, db, privileges, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
+	err = grantPrivileges(ctx, conn, privileges, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			fmt.Sprintf("Failed executing GRANT statement (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
@@ -185,20 +251,17 @@ func (r *GrantPrivilegeResource) Create(ctx context.Context, req resource.Create
 		return
 	}
 
-	data.ID = types.StringValue(
-		fmt.Sprintf(
-			"%s@%s@%s@%s",
-			privilegeLevel.Database.ValueString(),
-			privilegeLevel.Table.ValueString(),
-			userOrRole.Name.ValueString(),
-			userOrRole.Host.ValueString()))
+	data.ID = types.StringValue(grantPrivilegeID(privilegeLevel, userOrRole))
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *GrantPrivilegeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -210,29 +273,29 @@ func (r *GrantPrivilegeResource) Read(ctx context.Context, req resource.ReadRequ
 		return
 	}
 
-	var args []interface{}
-	sql := `SHOW GRANTS FOR ?@?`
-
 	var privilegeLevel PrivilegeLevelModel
 	resp.Diagnostics.Append(data.On.As(ctx, &privilegeLevel, basetypes.ObjectAsOptions{})...)
 	var userOrRole UserModel
 	resp.Diagnostics.Append(data.To.As(ctx, &userOrRole, basetypes.ObjectAsOptions{})...)
 
-	args = append(args, userOrRole.Name.ValueString())
-	args = append(args, userOrRole.Host.ValueString())
-
-	tflog.Info(ctx, sql, map[string]any{"args": args})
+	stmt := showGrantsStatement(conn.Flavor, userOrRole)
+	tflog.Info(ctx, stmt.query, map[string]any{"args": stmt.args})
 
-	rows, err := db.QueryContext(ctx, sql, args...)
+	rows, err := conn.query(ctx, stmt)
+	if errors.Is(err, errNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			fmt.Sprintf("Failed showing grants (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
 			err.Error())
-		resp.State.RemoveResource(ctx)
 		return
 	}
 	defer func() { _ = rows.Close() }()
 
+	spellings := privilegeSpellings(ctx, data.Privileges)
+
 	privileges := []attr.Value{}
 	for rows.Next() {
 		var grantStatement string
@@ -240,13 +303,13 @@ func (r *GrantPrivilegeResource) Read(ctx context.Context, req resource.ReadRequ
 			resp.Diagnostics.AddError("Failed scanning MySQL rows", err.Error())
 			return
 		}
-		tflog.Info(ctx, fmt.Sprintf("\nGrant Statement: %s", grantStatement))
-		grantPrivilege, err := ParseGrantPrivilegeStatement(grantStatement)
+		tflog.Debug(ctx, "Grant statement", map[string]any{"statement": grantStatement})
+		grantPrivilege, err := ParseShowGrantsStatement(grantStatement, conn.ANSIQuotes)
 		if err != nil {
 			resp.Diagnostics.AddError("Failed parsing grant statement", fmt.Sprintf("Statement: %s, Error: %s", grantStatement, err.Error()))
 			return
 		}
-		if !grantPrivilege.Match(privilegeLevel.Database.ValueString(), privilegeLevel.Table.ValueString(), userOrRole.Name.ValueString(), userOrRole.Host.ValueString()) {
+		if grantPrivilege == nil || !grantPrivilege.Match(privilegeLevel.objectType(), privilegeLevel.Database.ValueString(), privilegeLevel.Table.ValueString(), userOrRole.Name.ValueString(), userOrRole.Host.ValueString()) {
 			continue
 		}
 
@@ -256,23 +319,7 @@ func (r *GrantPrivilegeResource) Read(ctx context.Context, req resource.ReadRequ
 			if len(priv.Priv.String()) == 0 && len(priv.Name) == 0 {
 				continue
 			}
-			privilegeTypeModelValue := map[string]attr.Value{}
-			if len(priv.Priv.String()) > 0 {
-				privilegeTypeModelValue["priv_type"] = types.StringValue(strings.ToUpper(priv.Priv.String()))
-			}
-			if len(priv.Name) > 0 {
-				privilegeTypeModelValue["priv_type"] = types.StringValue(strings.ToUpper(priv.Name))
-			}
-			if len(priv.Cols) == 0 {
-				privilegeTypeModelValue["columns"] = types.SetNull(types.StringType)
-			} else {
-				columns := []attr.Value{}
-				for _, col := range priv.Cols {
-					columns = append(columns, types.StringValue(col.Name.O))
-				}
-				privilegeTypeModelValue["columns"] = types.SetValueMust(types.StringType, columns)
-			}
-			privileges = append(privileges, types.ObjectValueMust(PrivlilegeTypeModelTypes, privilegeTypeModelValue))
+			privileges = append(privileges, privilegeValue(priv, spellings))
 		}
 	}
 
@@ -285,7 +332,7 @@ func (r *GrantPrivilegeResource) Read(ctx context.Context, req resource.ReadRequ
 }
 
 func (r *GrantPrivilegeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -402,7 +449,7 @@ func (r *GrantPrivilegeResource) Update(ctx context.Context, req resource.Update
 	if len(privilegesToRevoke) > 0 {
 		revokeGrantOption := state.GrantOption.ValueBool() && !data.GrantOption.ValueBool()
 		tflog.Info(ctx, fmt.Sprintf("\nrevokeGrantOption=%t\n", revokeGrantOption))
-		err = revokePrivileges(ctx, db, privilegesToRevoke, privilegeLevel, userOrRole, revokeGrantOption)
+		err = revokePrivileges(ctx, conn, privilegesToRevoke, privilegeLevel, userOrRole, revokeGrantOption)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				fmt.Sprintf("Failed executing REVOKE statement (%s)", data.ID.ValueString()),
@@ -411,7 +458,7 @@ func (r *GrantPrivilegeResource) Update(ctx context.Context, req resource.Update
 		}
 	}
 	if len(privilegesToGrant) > 0 {
-		err = grantPrivileges(ctx, db, privilegesToGrant, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
+		err = grantPrivileges(ctx, conn, privilegesToGrant, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
 		if err != nil {
 			resp.Diagnostics.AddError(
 				fmt.Sprintf("Failed executing GRANT statement (%s)", data.ID.ValueString()),
@@ -421,7 +468,7 @@ func (r *GrantPrivilegeResource) Update(ctx context.Context, req resource.Update
 	}
 
 	if !state.GrantOption.ValueBool() && data.GrantOption.ValueBool() {
-		err := grantPrivileges(ctx, db, dataPrivileges, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
+		err := grantPrivileges(ctx, conn, dataPrivileges, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
 		if err != nil {
 			resp.Diagnostics.AddError(
 				fmt.Sprintf("Failed executing GRANT statement (%s)", data.ID.ValueString()),
@@ -430,7 +477,7 @@ func (r *GrantPrivilegeResource) Update(ctx context.Context, req resource.Update
 		}
 	}
 	if state.GrantOption.ValueBool() && !data.GrantOption.ValueBool() {
-		err := revokePrivileges(ctx, db, []PrivilegeTypeModel{}, privilegeLevel, userOrRole, true)
+		err := revokePrivileges(ctx, conn, []PrivilegeTypeModel{}, privilegeLevel, userOrRole, true)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				fmt.Sprintf("Failed executing REVOKE statement (%s)", data.ID.ValueString()),
@@ -444,7 +491,7 @@ func (r *GrantPrivilegeResource) Update(ctx context.Context, req resource.Update
 }
 
 func (r *GrantPrivilegeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -467,7 +514,7 @@ func (r *GrantPrivilegeResource) Delete(ctx context.Context, req resource.Delete
 		return
 	}
 
-	err = revokePrivileges(ctx, db, privileges, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
+	err = revokePrivileges(ctx, conn, privileges, privilegeLevel, userOrRole, data.GrantOption.ValueBool())
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -478,109 +525,150 @@ func (r *GrantPrivilegeResource) Delete(ctx context.Context, req resource.Delete
 }
 
 func (r *GrantPrivilegeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	idParts := strings.SplitN(req.ID, "@", 4)
-	if len(idParts) != 4 {
-		resp.Diagnostics.AddAttributeError(path.Root("id"), fmt.Sprintf("Invalid ID format. %s", req.ID), "The valid ID format is `database@table@name@host`")
+	objectType, idParts, err := parseGrantPrivilegeID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("id"), fmt.Sprintf("Invalid ID format. %s", req.ID), fmt.Sprintf("The valid ID format is `[object_type@]database@table@name@host`: %s", err))
 		return
 	}
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on").AtName("object_type"), objectType)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on").AtName("database"), types.StringValue(idParts[0]))...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on").AtName("table"), types.StringValue(idParts[1]))...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("to").AtName("name"), types.StringValue(idParts[2]))...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("to").AtName("host"), types.StringValue(idParts[3]))...)
 }
 
-func buildPrivilege(ctx context.Context, db *sql.DB, privilege PrivilegeTypeModel) (string, error) {
+// parseGrantPrivilegeID splits the import ID `[object_type@]database@table@name@host`
+// into the object type, which is null when the ID has no prefix, and the
+// database, table, name and host. The ID is prefixed only when the rest has
+// all four parts, so a database may be named like an object type. The host
+// may contain `@`.
+func parseGrantPrivilegeID(id string) (types.String, []string, error) {
+	if objectType, rest, ok := strings.Cut(id, "@"); ok {
+		objectType = strings.ToUpper(objectType)
+		switch objectType {
+		case objectTypeTable, objectTypeFunction, objectTypeProcedure:
+			if idParts := strings.SplitN(rest, "@", 4); len(idParts) == 4 {
+				return types.StringValue(objectType), idParts, nil
+			}
+		}
+	}
+	idParts := strings.SplitN(id, "@", 4)
+	if len(idParts) != 4 {
+		return types.StringNull(), nil, fmt.Errorf("the ID has %d parts separated by @", len(idParts))
+	}
+	return types.StringNull(), idParts, nil
+}
+
+// grantPrivilegeID returns the ID `database@table@name@host`, which is
+// prefixed with `FUNCTION@` or `PROCEDURE@` for routines.
+func grantPrivilegeID(privilegeLevel PrivilegeLevelModel, userOrRole UserModel) string {
+	id := fmt.Sprintf(
+		"%s@%s@%s@%s",
+		privilegeLevel.Database.ValueString(),
+		privilegeLevel.Table.ValueString(),
+		userOrRole.Name.ValueString(),
+		userOrRole.Host.ValueString())
+	if objectType := privilegeLevel.objectType(); objectType != objectTypeTable {
+		id = objectType + "@" + id
+	}
+	return id
+}
+
+func buildPrivilege(ctx context.Context, privilege PrivilegeTypeModel) string {
 	normalizedPrivType := strings.ToUpper(privilege.PrivType.ValueString())
 	if privilege.Columns.IsNull() || len(privilege.Columns.Elements()) == 0 {
-		return normalizedPrivType, nil
+		return normalizedPrivType
 	}
 
-	var columns, quotedColumns []string
+	var columns []string
 	privilege.Columns.ElementsAs(ctx, &columns, false)
-	quotedColumns, err := quoteIdentifiers(ctx, db, columns...)
-	if err != nil {
-		return "", err
-	}
 
-	return fmt.Sprintf("%s (%s)", normalizedPrivType, strings.Join(quotedColumns, ",")), nil
+	return fmt.Sprintf("%s (%s)", normalizedPrivType, strings.Join(quoteIdentifiers(columns...), ","))
+}
+
+// buildPrivilegeLevel returns the `db.table` part of GRANT and REVOKE, which
+// is prefixed with FUNCTION or PROCEDURE for routines.
+func buildPrivilegeLevel(privilegeLevel PrivilegeLevelModel) string {
+	prefix := ""
+	if objectType := privilegeLevel.objectType(); objectType != objectTypeTable {
+		prefix = objectType + " "
+	}
+	database := privilegeLevel.Database.ValueString()
+	if database != "*" {
+		database = quoteIdentifier(database)
+	}
+	table := privilegeLevel.Table.ValueString()
+	if table != "*" {
+		table = quoteIdentifier(table)
+	}
+	return fmt.Sprintf("%s%s.%s", prefix, database, table)
 }
 
-func grantPrivileges(ctx context.Context, db *sql.DB, privileges []PrivilegeTypeModel, privilegeLevel PrivilegeLevelModel, userOrRole UserModel, grantOption bool) error {
-	var args []interface{}
+func grantPrivilegesStatement(ctx context.Context, flavor Flavor, privileges []PrivilegeTypeModel, privilegeLevel PrivilegeLevelModel, userOrRole UserModel, grantOption bool) sqlStatement {
 	sql := `GRANT `
 
 	var privilegesWithColumns []string
 
 	for _, privilege := range privileges {
-		priv, err := buildPrivilege(ctx, db, privilege)
-		if err != nil {
-			return fmt.Errorf("failed building privilege: %w", err)
-		}
-		privilegesWithColumns = append(privilegesWithColumns, priv)
+		privilegesWithColumns = append(privilegesWithColumns, buildPrivilege(ctx, privilege))
 	}
 
 	sql += strings.Join(privilegesWithColumns, ",")
 
-	sql += ` ON`
-	var database, table string
-	if privilegeLevel.Database.ValueString() == "*" {
-		database = privilegeLevel.Database.ValueString()
-	} else {
-		database, _ = quoteIdentifier(ctx, db, privilegeLevel.Database.ValueString())
-	}
-	if privilegeLevel.Table.ValueString() == "*" {
-		table = privilegeLevel.Table.ValueString()
-	} else {
-		table, _ = quoteIdentifier(ctx, db, privilegeLevel.Table.ValueString())
-	}
-	sql += fmt.Sprintf(" %s.%s", database, table)
-	sql += ` TO ?@?`
-	args = append(args, userOrRole.Name.ValueString())
-	args = append(args, userOrRole.Host.ValueString())
+	sql += ` ON ` + buildPrivilegeLevel(privilegeLevel)
+	account, args := flavor.account(userOrRole.Name.ValueString(), userOrRole.Host.ValueString())
+	sql += ` TO ` + account
 
 	if grantOption {
 		sql += ` WITH GRANT OPTION`
 	}
 
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err := db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return err
-	}
+	return sqlStatement{query: sql, args: args}
+}
 
-	return nil
+func grantPrivileges(ctx context.Context, conn *OneConnection, privileges []PrivilegeTypeModel, privilegeLevel PrivilegeLevelModel, userOrRole UserModel, grantOption bool) error {
+	stmt := grantPrivilegesStatement(ctx, conn.Flavor, privileges, privilegeLevel, userOrRole, grantOption)
+	return conn.exec(ctx, stmt)
 }
 
-func revokePrivileges(ctx context.Context, db *sql.DB, privileges []PrivilegeTypeModel, privilegeLevel PrivilegeLevelModel, userOrRole UserModel, revokeGrantOption bool) error {
-	var args []interface{}
+// revokePrivilegesStatement returns the REVOKE statement. GRANT OPTION is
+// revoked when both revokeGrantOption and hasGrantOption are true.
+func revokePrivilegesStatement(ctx context.Context, flavor Flavor, privileges []PrivilegeTypeModel, privilegeLevel PrivilegeLevelModel, userOrRole UserModel, revokeGrantOption, hasGrantOption bool) sqlStatement {
 	sql := `REVOKE `
 
 	var privilegesWithColumns []string
 	for _, privilege := range privileges {
-		priv, err := buildPrivilege(ctx, db, privilege)
-		if err != nil {
-			return fmt.Errorf("failed to building privileges: %w", err)
-		}
-		privilegesWithColumns = append(privilegesWithColumns, priv)
+		privilegesWithColumns = append(privilegesWithColumns, buildPrivilege(ctx, privilege))
 	}
 
 	sql += strings.Join(privilegesWithColumns, ",")
 
+	if revokeGrantOption && hasGrantOption {
+		if len(privileges) > 0 {
+			sql += ` ,GRANT OPTION`
+		} else {
+			sql += ` GRANT OPTION`
+		}
+	}
+
+	sql += ` ON ` + buildPrivilegeLevel(privilegeLevel)
+	account, args := flavor.account(userOrRole.Name.ValueString(), userOrRole.Host.ValueString())
+	sql += ` FROM ` + account
+
+	return sqlStatement{query: sql, args: args}
+}
+
+func revokePrivileges(ctx context.Context, conn *OneConnection, privileges []PrivilegeTypeModel, privilegeLevel PrivilegeLevelModel, userOrRole UserModel, revokeGrantOption bool) error {
+	var hasGrantOption bool
 	if revokeGrantOption {
 		// MySQL 8.4 compatibility: Check if user actually has GRANT OPTION before trying to revoke it
-		hasGrantOption, err := checkGrantOption(ctx, db, privilegeLevel, userOrRole)
+		var err error
+		hasGrantOption, err = checkGrantOption(ctx, conn, privilegeLevel, userOrRole)
 		if err != nil {
 			return fmt.Errorf("failed to check GRANT OPTION status: %w", err)
 		}
-		if hasGrantOption {
-			if len(privileges) > 0 {
-				sql += ` ,GRANT OPTION`
-			} else {
-				sql += ` GRANT OPTION`
-			}
-		} else {
+		if !hasGrantOption {
 			tflog.Info(ctx, "User does not have GRANT OPTION, skipping REVOKE GRANT OPTION")
 			// Only execute REVOKE if there are privileges to revoke
 			if len(privileges) == 0 {
@@ -589,44 +677,27 @@ func revokePrivileges(ctx context.Context, db *sql.DB, privileges []PrivilegeTyp
 		}
 	}
 
-	sql += ` ON`
-	var database, table string
-	if privilegeLevel.Database.ValueString() == "*" {
-		database = privilegeLevel.Database.ValueString()
-	} else {
-		database, _ = quoteIdentifier(ctx, db, privilegeLevel.Database.ValueString())
-	}
-	if privilegeLevel.Table.ValueString() == "*" {
-		table = privilegeLevel.Table.ValueString()
-	} else {
-		table, _ = quoteIdentifier(ctx, db, privilegeLevel.Table.ValueString())
-	}
-	sql += fmt.Sprintf(" %s.%s", database, table)
-	sql += ` FROM ?@?`
-	args = append(args, userOrRole.Name.ValueString())
-	args = append(args, userOrRole.Host.ValueString())
-
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err := db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return err
-	}
+	stmt := revokePrivilegesStatement(ctx, conn.Flavor, privileges, privilegeLevel, userOrRole, revokeGrantOption, hasGrantOption)
+	return conn.exec(ctx, stmt)
+}
 
-	return nil
+// showGrantsStatement returns SHOW GRANTS for the user or role.
+func showGrantsStatement(flavor Flavor, userOrRole UserModel) sqlStatement {
+	account, args := flavor.account(userOrRole.Name.ValueString(), userOrRole.Host.ValueString())
+	return sqlStatement{query: `SHOW GRANTS FOR ` + account, args: args}
 }
 
-func checkGrantOption(ctx context.Context, db *sql.DB, privilegeLevel PrivilegeLevelModel, userOrRole UserModel) (bool, error) {
-	sql := "SHOW GRANTS FOR ?@?"
-	args := []interface{}{userOrRole.Name.ValueString(), userOrRole.Host.ValueString()}
+func checkGrantOption(ctx context.Context, conn *OneConnection, privilegeLevel PrivilegeLevelModel, userOrRole UserModel) (bool, error) {
+	stmt := showGrantsStatement(conn.Flavor, userOrRole)
 
-	rows, err := db.QueryContext(ctx, sql, args...)
+	rows, err := conn.query(ctx, stmt)
 	if err != nil {
 		tflog.Error(ctx, "Failed to check GRANT OPTION status", map[string]any{"user": userOrRole.Name.ValueString(), "host": userOrRole.Host.ValueString(), "error": err.Error()})
 		return false, err
 	}
 	defer func() { _ = rows.Close() }()
 
+	objectType := privilegeLevel.objectType()
 	database := privilegeLevel.Database.ValueString()
 	table := privilegeLevel.Table.ValueString()
 	userName := userOrRole.Name.ValueString()
@@ -640,7 +711,7 @@ func checkGrantOption(ctx context.Context, db *sql.DB, privilegeLevel PrivilegeL
 		}
 
 		// Parse the grant statement using the existing parser
-		grantPrivilege, err := ParseGrantPrivilegeStatement(grantStatement)
+		grantPrivilege, err := ParseGrantPrivilegeStatement(grantStatement, conn.ANSIQuotes)
 		if err != nil {
 			// Log parsing errors for debugging, but continue with other statements
 			tflog.Warn(ctx, "Failed to parse grant statement", map[string]any{"statement": grantStatement, "error": err.Error()})
@@ -648,7 +719,7 @@ func checkGrantOption(ctx context.Context, db *sql.DB, privilegeLevel PrivilegeL
 		}
 
 		// Check if this grant statement matches our database/table/user and has GRANT OPTION
-		if grantPrivilege.Match(database, table, userName, hostName) && grantPrivilege.GrantOption {
+		if grantPrivilege.Match(objectType, database, table, userName, hostName) && grantPrivilege.GrantOption {
 			return true, nil
 		}
 	}
@@ -660,7 +731,8 @@ func convertPrivilegesToRaws(ctx context.Context, privileges []PrivilegeTypeMode
 	var result []PrivilegeTypeRaw
 	for _, p := range privileges {
 		var raw PrivilegeTypeRaw
-		raw.PrivType = p.PrivType.ValueString()
+		// Aliases are not changes.
+		raw.PrivType = normalizePrivilege(p.PrivType.ValueString())
 		var columns []string
 		p.Columns.ElementsAs(ctx, &columns, false)
 		raw.Columns = columns
@@ -684,3 +756,208 @@ func convertRawsToPrivileges(raws []PrivilegeTypeRaw) []PrivilegeTypeModel {
 	}
 	return result
 }
+
+// privilegeBlock returns the schema of the privilege blocks, which are
+// shared with mysql_grants.
+func privilegeBlock() schema.SetNestedBlock {
+	return schema.SetNestedBlock{
+		MarkdownDescription: "Set privilege name and columns.",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"priv_type": schema.StringAttribute{
+					MarkdownDescription: "The privilege name. Aliases such as `ALL` and `ALL PRIVILEGES` are the same privilege. " +
+						"Static privileges are checked against the levels they are valid at, and dynamic privileges against the server version. " +
+						"Dynamic privileges unknown to the provider, e.g. the ones of third party plugins, are warned about unless `verify_privileges` of the provider is set.",
+					Required: true,
+					Validators: []validator.String{
+						stringvalidator.RegexMatches(regexp.MustCompile(`\A[A-Z_ ]+\z`), "priv_type must be upper cases"),
+					},
+				},
+				"columns": schema.SetAttribute{
+					MarkdownDescription: "Column names.",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+			},
+		},
+	}
+}
+
+// grantLevel returns the level which the privileges are granted at. It is
+// zero while the level is unknown.
+func (m PrivilegeLevelModel) grantLevel() grantLevel {
+	if m.ObjectType.IsUnknown() || m.Database.IsUnknown() || m.Table.IsUnknown() {
+		return 0
+	}
+	switch {
+	case m.objectType() != objectTypeTable:
+		return grantLevelRoutine
+	case m.Database.ValueString() == "*":
+		return grantLevelGlobal
+	case m.Table.ValueString() == "*":
+		return grantLevelDatabase
+	default:
+		return grantLevelTable
+	}
+}
+
+// checkServerPrivileges checks the privileges against the catalog and the
+// version of the server, or against the privileges registered on the server
+// when verify_privileges is set. The errors are reported on attribute. The
+// checks are skipped when the server
+// cannot be reached while planning.
+func checkServerPrivileges(ctx context.Context, conf *MySQLConfiguration, attribute path.Path, privileges []PrivilegeTypeModel) diag.Diagnostics {
+	conn := getPlanConnection(ctx, conf)
+	if conn == nil {
+		return nil
+	}
+	var diags diag.Diagnostics
+
+	// The privileges of the server are read once when verify_privileges is
+	// set.
+	var registered map[string]bool
+	for _, privilege := range privileges {
+		if privilege.PrivType.IsUnknown() {
+			continue
+		}
+		privType := normalizePrivilege(privilege.PrivType.ValueString())
+		if isDynamicPrivilege(privType) {
+			if !conn.Capabilities.DynamicPrivileges {
+				diags.AddAttributeError(attribute, "Unsupported feature", conn.notSupported(fmt.Sprintf("Dynamic privilege %s", privType)))
+				continue
+			}
+			if conf.VerifyPrivileges {
+				if registered == nil {
+					var err error
+					registered, err = queryPrivileges(ctx, conn)
+					if err != nil {
+						diags.AddError("Failed reading privileges", err.Error())
+						return diags
+					}
+				}
+				if !registered[privType] {
+					diags.AddAttributeError(attribute, "Unknown privilege",
+						fmt.Sprintf("%s is not registered on %s. Install the plugin or the component which provides it.", privType, conn.serverName()))
+				}
+				continue
+			}
+		}
+		info, ok := lookupPrivilege(privType)
+		if !ok {
+			// ValidateConfig rejects unknown static privileges.
+			diags.AddAttributeWarning(attribute, "Unknown privilege",
+				fmt.Sprintf("%s is not a known dynamic privilege of %s. It may be provided by a plugin or a component. "+
+					"Set verify_privileges of the provider to check it on the server.", privType, conn.serverName()))
+			continue
+		}
+		if err := info.supportedBy(privType, conn.Flavor, conn.Version); err != nil {
+			diags.AddAttributeError(attribute, "Unsupported privilege", err.Error()+".")
+		}
+	}
+	return diags
+}
+
+// privilegeSpellings maps the canonical names of the privileges to their
+// spellings, so that Read keeps e.g. `ALL` for `ALL PRIVILEGES`.
+func privilegeSpellings(ctx context.Context, privileges types.Set) map[string]string {
+	var privilegeModels []PrivilegeTypeModel
+	privileges.ElementsAs(ctx, &privilegeModels, false)
+	spellings := map[string]string{}
+	for _, privilege := range privilegeModels {
+		spellings[normalizePrivilege(privilege.PrivType.ValueString())] = privilege.PrivType.ValueString()
+	}
+	return spellings
+}
+
+// privilegeValue returns the privilege block of a privilege of SHOW GRANTS.
+// The privilege is spelled as in spellings when they have the same
+// canonical name.
+func privilegeValue(priv *ast.PrivElem, spellings map[string]string) attr.Value {
+	privType := priv.Priv.String()
+	if len(priv.Name) > 0 {
+		privType = priv.Name
+	}
+	privType = strings.ToUpper(privType)
+	if spelling, ok := spellings[normalizePrivilege(privType)]; ok {
+		privType = spelling
+	}
+	columns := types.SetNull(types.StringType)
+	if len(priv.Cols) > 0 {
+		values := []attr.Value{}
+		for _, col := range priv.Cols {
+			values = append(values, types.StringValue(col.Name.O))
+		}
+		columns = types.SetValueMust(types.StringType, values)
+	}
+	return types.ObjectValueMust(PrivlilegeTypeModelTypes, map[string]attr.Value{
+		"priv_type": types.StringValue(privType),
+		"columns":   columns,
+	})
+}
+
+// grantLevelOf returns the level of the privileges granted on, or zero when
+// it is unknown.
+func grantLevelOf(ctx context.Context, on types.Object) (grantLevel, diag.Diagnostics) {
+	if on.IsNull() || on.IsUnknown() {
+		return 0, nil
+	}
+	var privilegeLevel PrivilegeLevelModel
+	diags := on.As(ctx, &privilegeLevel, basetypes.ObjectAsOptions{})
+	return privilegeLevel.grantLevel(), diags
+}
+
+// validatePrivileges checks the privilege blocks against the catalog at
+// level. The errors are reported on attribute. Unknown values are not
+// checked.
+func validatePrivileges(ctx context.Context, attribute path.Path, privileges types.Set, level grantLevel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if privileges.IsNull() || privileges.IsUnknown() {
+		return diags
+	}
+	var privilegeModels []PrivilegeTypeModel
+	diags.Append(privileges.ElementsAs(ctx, &privilegeModels, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	for _, privilege := range privilegeModels {
+		if privilege.PrivType.IsUnknown() || privilege.PrivType.IsNull() || privilege.Columns.IsUnknown() {
+			continue
+		}
+		hasColumns := len(privilege.Columns.Elements()) > 0
+		if err := checkPrivilege(privilege.PrivType.ValueString(), hasColumns, level); err != nil {
+			diags.AddAttributeError(attribute, "Invalid privilege", err.Error())
+		}
+	}
+	return diags
+}
+
+// checkPrivilege returns an error when privType is not a privilege of the
+// catalog or cannot be granted at level, or on columns when hasColumns is
+// true. The level is not checked when it is zero. Dynamic privileges unknown
+// to the catalog are global.
+func checkPrivilege(privType string, hasColumns bool, level grantLevel) error {
+	name := normalizePrivilege(privType)
+	info, ok := lookupPrivilege(name)
+	if !ok && !isDynamicPrivilege(name) {
+		return fmt.Errorf("%s is not a privilege", privType)
+	}
+	if hasColumns {
+		if info.levels&grantLevelColumn == 0 {
+			return fmt.Errorf("%s cannot be granted on columns", privType)
+		}
+		if level != 0 && level != grantLevelTable {
+			return fmt.Errorf("columns of %s can be granted only on a table, not at the %s level", privType, level)
+		}
+		return nil
+	}
+	if level != 0 && info.levels&level == 0 {
+		return fmt.Errorf("%s cannot be granted at the %s level", privType, level)
+	}
+	return nil
+}
+
+// isDynamicPrivilege reports whether privType is a dynamic privilege such as
+// SYSTEM_VARIABLES_ADMIN. Static privileges are words separated by spaces.
+func isDynamicPrivilege(privType string) bool {
+	return strings.Contains(privType, "_") && !strings.Contains(privType, " ")
+}
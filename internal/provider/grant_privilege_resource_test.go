@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"math/rand"
 	"regexp"
+	"slices"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/okkez/terraform-provider-mysql/internal/utils"
 )
@@ -74,6 +77,53 @@ func TestAccGrantPrivilegeResource_LowerCase(t *testing.T) {
 	})
 }
 
+func TestAccGrantPrivilegeResource_InvalidPrivilege(t *testing.T) {
+	database := fmt.Sprintf("test_database_%04d", rand.Intn(1000))
+	user := NewRandomUser("test-user", "%")
+	t.Logf("database: %s user: %s", database, user.GetID())
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccGrantPrivilegeResource_Config(t, database, user.GetName(), []string{"SELEC"}, []string{}),
+				ExpectError: regexp.MustCompile(`SELEC is not a privilege`),
+			},
+			{
+				Config:      testAccGrantPrivilegeResource_Config(t, database, user.GetName(), []string{"PROCESS"}, []string{}),
+				ExpectError: regexp.MustCompile(`PROCESS cannot be granted at the database level`),
+			},
+		},
+	})
+}
+
+func TestAccGrantPrivilegeResource_Alias(t *testing.T) {
+	database := fmt.Sprintf("test_database_%04d", rand.Intn(1000))
+	user := NewRandomUser("test-user", "%")
+	t.Logf("database: %s user: %s", database, user.GetID())
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// SHOW GRANTS returns ALL PRIVILEGES, which must not be a change.
+			{
+				Config: testAccGrantPrivilegeResource_Config(t, database, user.GetName(), []string{"ALL"}, []string{}),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "privilege.#", "1"),
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "privilege.0.priv_type", "ALL"),
+				),
+			},
+			{
+				Config: testAccGrantPrivilegeResource_Config(t, database, user.GetName(), []string{"ALL PRIVILEGES"}, []string{}),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "privilege.#", "1"),
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "privilege.0.priv_type", "ALL PRIVILEGES"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccGrantPrivilegeResource_Table(t *testing.T) {
 	database := fmt.Sprintf("test_database_%04d", rand.Intn(1000))
 	table := fmt.Sprintf("test_table_%04d", rand.Intn(1000))
@@ -139,6 +189,58 @@ func TestAccGrantPrivilegeResource_Table(t *testing.T) {
 	})
 }
 
+func TestAccGrantPrivilegeResource_Procedure(t *testing.T) {
+	database := fmt.Sprintf("test_database_%04d", rand.Intn(1000))
+	db := testDatabase()
+	if _, err := db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", database)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(testAccGrantPrivilegeResource_Cleanup(t, database))
+	if _, err := db.Exec(fmt.Sprintf("CREATE PROCEDURE %s.test_proc() SELECT 1", database)); err != nil {
+		t.Fatal(err)
+	}
+	user := NewRandomUser("test-user", "%")
+	config := fmt.Sprintf(`
+resource "mysql_user" "test" {
+  name = "%s"
+}
+resource "mysql_grant_privilege" "test" {
+  privilege {
+    priv_type = "EXECUTE"
+  }
+  on {
+    object_type = "PROCEDURE"
+    database    = "%s"
+    table       = "test_proc"
+  }
+  to {
+    name = mysql_user.test.name
+    host = mysql_user.test.host
+  }
+}
+`, user.GetName(), database)
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "id", fmt.Sprintf("PROCEDURE@%s@test_proc@%s", database, user.GetID())),
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "privilege.#", "1"),
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "privilege.0.priv_type", "EXECUTE"),
+					resource.TestCheckResourceAttr("mysql_grant_privilege.test", "on.object_type", "PROCEDURE"),
+				),
+			},
+			{
+				ResourceName:      "mysql_grant_privilege.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func TestAccGrantPrivilegeResource_GrantOption(t *testing.T) {
 	database := fmt.Sprintf("test_database_%04d", rand.Intn(1000))
 	table := fmt.Sprintf("test_table_%04d", rand.Intn(1000))
@@ -569,7 +671,7 @@ func TestAccGrantPrivilegeResource_ImportNonExistentRemoteObject(t *testing.T) {
 				ImportState:       true,
 				ImportStateId:     fmt.Sprintf("%s@*@non-existent-user@%%", database),
 				ImportStateVerify: false,
-				ExpectError:       regexp.MustCompile("Failed showing grants"),
+				ExpectError:       regexp.MustCompile("Cannot import non-existent remote object"),
 			},
 		},
 	})
@@ -740,3 +842,161 @@ func testAccGrantPrivilegeResource_Cleanup(t *testing.T, database string) func()
 		}
 	}
 }
+
+func TestGrantPrivilegesStatement(t *testing.T) {
+	ctx := t.Context()
+	privileges := []PrivilegeTypeModel{
+		{PrivType: types.StringValue("select"), Columns: types.SetNull(types.StringType)},
+		{PrivType: types.StringValue("update"), Columns: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("c`1")})},
+	}
+	level := PrivilegeLevelModel{Database: types.StringValue("db"), Table: types.StringValue("*")}
+	cases := []struct {
+		flavor Flavor
+		to     UserModel
+		grant  sqlStatement
+		revoke sqlStatement
+	}{
+		{
+			flavor: FlavorMariaDB,
+			to:     NewUser("user", "%"),
+			grant:  sqlStatement{query: "GRANT SELECT,UPDATE (`c``1`) ON `db`.* TO 'user'@'%' WITH GRANT OPTION"},
+			revoke: sqlStatement{query: "REVOKE SELECT,UPDATE (`c``1`) ,GRANT OPTION ON `db`.* FROM 'user'@'%'"},
+		},
+		{
+			flavor: FlavorMariaDB,
+			to:     NewUser("role", ""),
+			grant:  sqlStatement{query: "GRANT SELECT,UPDATE (`c``1`) ON `db`.* TO 'role' WITH GRANT OPTION"},
+			revoke: sqlStatement{query: "REVOKE SELECT,UPDATE (`c``1`) ,GRANT OPTION ON `db`.* FROM 'role'"},
+		},
+		{
+			flavor: FlavorMySQL,
+			to:     NewUser("user", "%"),
+			grant:  sqlStatement{query: "GRANT SELECT,UPDATE (`c``1`) ON `db`.* TO ?@? WITH GRANT OPTION", args: []any{"user", "%"}},
+			revoke: sqlStatement{query: "REVOKE SELECT,UPDATE (`c``1`) ,GRANT OPTION ON `db`.* FROM ?@?", args: []any{"user", "%"}},
+		},
+		{
+			flavor: FlavorTiDB,
+			to:     NewUser("user", "%"),
+			grant:  sqlStatement{query: "GRANT SELECT,UPDATE (`c``1`) ON `db`.* TO ?@? WITH GRANT OPTION", args: []any{"user", "%"}},
+			revoke: sqlStatement{query: "REVOKE SELECT,UPDATE (`c``1`) ,GRANT OPTION ON `db`.* FROM ?@?", args: []any{"user", "%"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.flavor.String()+"/"+c.to.GetID(), func(t *testing.T) {
+			testStatement(t, grantPrivilegesStatement(ctx, c.flavor, privileges, level, c.to, true), c.grant)
+			testStatement(t, revokePrivilegesStatement(ctx, c.flavor, privileges, level, c.to, true, true), c.revoke)
+		})
+	}
+
+	procedure := PrivilegeLevelModel{ObjectType: types.StringValue("PROCEDURE"), Database: types.StringValue("db"), Table: types.StringValue("proc")}
+	execute := []PrivilegeTypeModel{{PrivType: types.StringValue("EXECUTE"), Columns: types.SetNull(types.StringType)}}
+	testStatement(t, grantPrivilegesStatement(ctx, FlavorMySQL, execute, procedure, NewUser("user", "%"), false),
+		sqlStatement{query: "GRANT EXECUTE ON PROCEDURE `db`.`proc` TO ?@?", args: []any{"user", "%"}})
+	testStatement(t, revokePrivilegesStatement(ctx, FlavorMySQL, execute, procedure, NewUser("user", "%"), false, false),
+		sqlStatement{query: "REVOKE EXECUTE ON PROCEDURE `db`.`proc` FROM ?@?", args: []any{"user", "%"}})
+	if id := grantPrivilegeID(procedure, NewUser("user", "%")); id != "PROCEDURE@db@proc@user@%" {
+		t.Errorf("unexpected ID: %s", id)
+	}
+	if id := grantPrivilegeID(level, NewUser("user", "%")); id != "db@*@user@%" {
+		t.Errorf("unexpected ID: %s", id)
+	}
+
+	grant := grantPrivilegesStatement(ctx, FlavorMySQL, privileges[:1], PrivilegeLevelModel{Database: types.StringValue("*"), Table: types.StringValue("*")}, NewUser("user", "%"), false)
+	testStatement(t, grant, sqlStatement{query: "GRANT SELECT ON *.* TO ?@?", args: []any{"user", "%"}})
+	testStatement(t, showGrantsStatement(FlavorMariaDB, NewUser("role", "")), sqlStatement{query: "SHOW GRANTS FOR 'role'"})
+}
+
+func TestParseGrantPrivilegeStatement_ObjectType(t *testing.T) {
+	cases := []struct {
+		sql        string
+		objectType string
+		table      string
+	}{
+		{"GRANT SELECT ON `db`.`t` TO `user`@`%`", objectTypeTable, "t"},
+		{"GRANT SELECT ON TABLE `db`.`t` TO `user`@`%`", objectTypeTable, "t"},
+		{"GRANT EXECUTE ON FUNCTION `db`.`fn` TO `user`@`%`", objectTypeFunction, "FN"},
+		{"GRANT EXECUTE, ALTER ROUTINE ON PROCEDURE `db`.`proc` TO `user`@`%`", objectTypeProcedure, "proc"},
+	}
+	for _, c := range cases {
+		grant, err := ParseGrantPrivilegeStatement(c.sql, false)
+		if err != nil {
+			t.Fatalf("%s: %v", c.sql, err)
+		}
+		if !grant.Match(c.objectType, "db", c.table, "user", "%") {
+			t.Errorf("%s must match %s %s: %+v", c.sql, c.objectType, c.table, grant)
+		}
+		for _, other := range []string{objectTypeTable, objectTypeFunction, objectTypeProcedure} {
+			if other != c.objectType && grant.Match(other, "db", c.table, "user", "%") {
+				t.Errorf("%s must not match %s", c.sql, other)
+			}
+		}
+	}
+}
+
+func TestParseShowGrantsStatement_MariaDB(t *testing.T) {
+	// SHOW GRANTS of MariaDB 10.11 for a user with a default role.
+	rows := []string{
+		"GRANT `app_read` TO `app`@`%`",
+		"GRANT USAGE ON *.* TO `app`@`%` IDENTIFIED BY PASSWORD '*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19'",
+		"GRANT SELECT, INSERT ON `app`.* TO `app`@`%`",
+		"GRANT ALL PRIVILEGES ON `app_log`.* TO `app`@`%` WITH GRANT OPTION",
+		"GRANT EXECUTE ON PROCEDURE `app`.`refresh_stats` TO `app`@`%`",
+		"GRANT PROXY ON ''@'%' TO 'app'@'%' WITH GRANT OPTION",
+		"SET DEFAULT ROLE `app_read` FOR `app`@`%`",
+	}
+	var grants []*GrantPrivilege
+	for _, row := range rows {
+		grant, err := ParseShowGrantsStatement(row, false)
+		if err != nil {
+			t.Fatalf("%s: %v", row, err)
+		}
+		if grant != nil {
+			grants = append(grants, grant)
+		}
+	}
+	if len(grants) != 4 {
+		t.Fatalf("unexpected grants: %d", len(grants))
+	}
+	if !grants[1].Match(objectTypeTable, "app", "*", "app", "%") || len(grants[1].Privileges) != 2 {
+		t.Errorf("unexpected grant: %+v", grants[1])
+	}
+	if !grants[2].Match(objectTypeTable, "app_log", "*", "app", "%") || !grants[2].GrantOption {
+		t.Errorf("unexpected grant: %+v", grants[2])
+	}
+	if !grants[3].Match(objectTypeProcedure, "app", "refresh_stats", "app", "%") {
+		t.Errorf("unexpected grant: %+v", grants[3])
+	}
+}
+
+func TestParseGrantPrivilegeID(t *testing.T) {
+	cases := []struct {
+		id         string
+		objectType types.String
+		parts      []string
+	}{
+		{"db@t@user@%", types.StringNull(), []string{"db", "t", "user", "%"}},
+		{"TABLE@t1@user@%", types.StringNull(), []string{"TABLE", "t1", "user", "%"}},
+		{"TABLE@db@t@user@%", types.StringValue(objectTypeTable), []string{"db", "t", "user", "%"}},
+		{"FUNCTION@db@fn@user@%", types.StringValue(objectTypeFunction), []string{"db", "fn", "user", "%"}},
+		{"procedure@db@proc@role@", types.StringValue(objectTypeProcedure), []string{"db", "proc", "role", ""}},
+		{"db@t@user@host@example", types.StringNull(), []string{"db", "t", "user", "host@example"}},
+		{"FUNCTION@db@fn@user@host@example", types.StringValue(objectTypeFunction), []string{"db", "fn", "user", "host@example"}},
+		{"VIEW@db@t@user@%", types.StringNull(), []string{"VIEW", "db", "t", "user@%"}},
+	}
+	for _, c := range cases {
+		objectType, parts, err := parseGrantPrivilegeID(c.id)
+		if err != nil {
+			t.Errorf("%s: %v", c.id, err)
+			continue
+		}
+		if !objectType.Equal(c.objectType) || !slices.Equal(parts, c.parts) {
+			t.Errorf("%s: unexpected result: %s %q", c.id, objectType, parts)
+		}
+	}
+
+	for _, id := range []string{"db@t@user", "TABLE@t@user", ""} {
+		if _, _, err := parseGrantPrivilegeID(id); err == nil {
+			t.Errorf("%s must be rejected", id)
+		}
+	}
+}
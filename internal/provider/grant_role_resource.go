@@ -2,7 +2,6 @@ package provider
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strings"
 
@@ -24,6 +23,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &GrantRoleResource{}
 var _ resource.ResourceWithImportState = &GrantRoleResource{}
+var _ resource.ResourceWithModifyPlan = &GrantRoleResource{}
 
 func NewGrantRoleResource() resource.Resource {
 	return &GrantRoleResource{}
@@ -94,8 +94,16 @@ func (r *GrantRoleResource) Configure(ctx context.Context, req resource.Configur
 	}
 }
 
+func (r *GrantRoleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	resp.Diagnostics.Append(checkCapability(ctx, r.mysqlConfig, path.Empty(), "Roles", func(c Capabilities) bool { return c.Roles })...)
+}
+
 func (r *GrantRoleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -116,7 +124,7 @@ func (r *GrantRoleResource) Create(ctx context.Context, req resource.CreateReque
 		return
 	}
 
-	err = grantRoles(ctx, db, userOrRole, roles, data.AdminOption.ValueBool())
+	err = grantRoles(ctx, conn, userOrRole, roles, data.AdminOption.ValueBool())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			fmt.Sprintf("Failed executing GRANT statement (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
@@ -131,11 +139,15 @@ func (r *GrantRoleResource) Create(ctx context.Context, req resource.CreateReque
 }
 
 func (r *GrantRoleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
 	}
+	db := conn.Db
 
 	var data *GrantRoleResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -147,29 +159,22 @@ func (r *GrantRoleResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
-	var args []interface{}
-	args = append(args, userOrRole.Name.ValueString())
-	args = append(args, userOrRole.Host.ValueString())
-
-	if !utils.UserExists(ctx, db, userOrRole.Name.ValueString(), userOrRole.Host.ValueString()) {
+	exists, err := utils.UserExists(ctx, db, userOrRole.Name.ValueString(), userOrRole.Host.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			fmt.Sprintf("Failed checking user or role (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
+			err.Error())
+		return
+	}
+	if !exists {
 		resp.State.RemoveResource(ctx)
 		return
 	}
 
-	sql := `
-SELECT
-  FROM_USER
-, FROM_HOST
-, WITH_ADMIN_OPTION
-FROM
-  mysql.role_edges
-WHERE
-  TO_USER = ? 
-  AND TO_HOST = ?
-`
-	tflog.Info(ctx, sql, map[string]any{"args": args})
+	stmt := readGrantedRolesStatement(conn.Flavor, userOrRole.Name.ValueString(), userOrRole.Host.ValueString())
+	tflog.Info(ctx, stmt.query, map[string]any{"args": stmt.args})
 
-	rows, err := db.QueryContext(ctx, sql, args...)
+	rows, err := conn.query(ctx, stmt)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			fmt.Sprintf("Failed querying roles (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
@@ -186,6 +191,11 @@ WHERE
 			return
 		}
 		role := findRole(roles, fromUser, fromHost)
+		if !conn.Flavor.rolesHaveHosts() {
+			// MariaDB roles have no host. Keep the configured one.
+			role, _ = findRoleByName(roles, fromUser)
+			fromHost = role.Host.ValueString()
+		}
 		attributes := map[string]attr.Value{}
 		attributes["name"] = types.StringValue(fromUser)
 		attributes["host"] = types.StringNull()
@@ -202,7 +212,7 @@ WHERE
 }
 
 func (r *GrantRoleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -309,7 +319,7 @@ func (r *GrantRoleResource) Update(ctx context.Context, req resource.UpdateReque
 			rolesToRevoke = append(rolesToRevoke, NewRole(role.Name, role.Host))
 		}
 		tflog.Info(ctx, fmt.Sprintf("\nrevoke=%+v\n", rolesToRevoke))
-		err := revokeRoles(ctx, db, userOrRole, rolesToRevoke)
+		err := revokeRoles(ctx, conn, userOrRole, rolesToRevoke)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				fmt.Sprintf("[Update] Failed executing REVOKE statement (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
@@ -324,7 +334,7 @@ func (r *GrantRoleResource) Update(ctx context.Context, req resource.UpdateReque
 			rolesToGrant = append(rolesToGrant, NewRole(role.Name, role.Host))
 		}
 		tflog.Info(ctx, fmt.Sprintf("\ngrant=%+v\n", rolesToGrant))
-		err := grantRoles(ctx, db, userOrRole, rolesToGrant, data.AdminOption.ValueBool())
+		err := grantRoles(ctx, conn, userOrRole, rolesToGrant, data.AdminOption.ValueBool())
 		if err != nil {
 			resp.Diagnostics.AddError(
 				fmt.Sprintf("[Update] Failed executing GRANT statement (%s@%s)", userOrRole.Name.ValueString(), userOrRole.Host.ValueString()),
@@ -338,7 +348,7 @@ func (r *GrantRoleResource) Update(ctx context.Context, req resource.UpdateReque
 }
 
 func (r *GrantRoleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -358,7 +368,7 @@ func (r *GrantRoleResource) Delete(ctx context.Context, req resource.DeleteReque
 		return
 	}
 
-	err = revokeRoles(ctx, db, userOrRole, roles)
+	err = revokeRoles(ctx, conn, userOrRole, roles)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -368,70 +378,106 @@ func (r *GrantRoleResource) Delete(ctx context.Context, req resource.DeleteReque
 	}
 }
 
-func grantRoles(ctx context.Context, db *sql.DB, to UserModel, roles []RoleModel, adminOption bool) error {
-	var args []interface{}
-	sql := `GRANT`
-
-	placeholders := []string{}
-	for _, role := range roles {
-		if role.Host.IsNull() || len(role.Host.ValueString()) == 0 {
-			placeholders = append(placeholders, "?")
-			args = append(args, role.Name.ValueString())
-		} else {
-			placeholders = append(placeholders, "?@?")
-			args = append(args, role.Name.ValueString())
-			args = append(args, role.Host.ValueString())
+func grantRoles(ctx context.Context, conn *OneConnection, to UserModel, roles []RoleModel, adminOption bool) error {
+	for _, stmt := range grantRolesStatements(conn.Flavor, to, roles, adminOption) {
+		err := conn.exec(ctx, stmt)
+		if err != nil {
+			return err
 		}
 	}
-	sql += fmt.Sprintf(` %s`, strings.Join(placeholders, ","))
 
-	sql += ` TO ?@?`
-	args = append(args, to.Name.ValueString())
-	args = append(args, to.Host.ValueString())
+	return nil
+}
 
-	if adminOption {
-		sql += ` WITH ADMIN OPTION`
+func revokeRoles(ctx context.Context, conn *OneConnection, to UserModel, roles []RoleModel) error {
+	for _, stmt := range revokeRolesStatements(conn.Flavor, to, roles) {
+		err := conn.exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
 	}
 
-	tflog.Info(ctx, sql, map[string]any{"args": args})
+	return nil
+}
 
-	_, err := db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return err
+// grantRolesStatements returns the statements granting roles. MariaDB grants
+// a single role per statement.
+func grantRolesStatements(flavor Flavor, to UserModel, roles []RoleModel, adminOption bool) []sqlStatement {
+	suffix := ""
+	if adminOption {
+		suffix = ` WITH ADMIN OPTION`
 	}
-
-	return nil
+	return buildRolesStatements(flavor, `GRANT`, `TO`, to, roles, suffix)
 }
 
-func revokeRoles(ctx context.Context, db *sql.DB, to UserModel, roles []RoleModel) error {
-	var args []interface{}
-	sql := `REVOKE`
+// revokeRolesStatements returns the statements revoking roles. MariaDB
+// revokes a single role per statement.
+func revokeRolesStatements(flavor Flavor, to UserModel, roles []RoleModel) []sqlStatement {
+	return buildRolesStatements(flavor, `REVOKE`, `FROM`, to, roles, "")
+}
 
-	placeholders := []string{}
-	for _, role := range roles {
-		if role.Host.IsNull() || len(role.Host.ValueString()) == 0 {
-			placeholders = append(placeholders, "?")
-			args = append(args, role.Name.ValueString())
-		} else {
-			placeholders = append(placeholders, "?@?")
-			args = append(args, role.Name.ValueString())
-			args = append(args, role.Host.ValueString())
+func buildRolesStatements(flavor Flavor, verb, preposition string, to UserModel, roles []RoleModel, suffix string) []sqlStatement {
+	var groups [][]RoleModel
+	if flavor == FlavorMariaDB {
+		for _, role := range roles {
+			groups = append(groups, []RoleModel{role})
 		}
+	} else {
+		groups = [][]RoleModel{roles}
 	}
-	sql += fmt.Sprintf(` %s`, strings.Join(placeholders, ","))
 
-	sql += ` FROM ?@?`
-	args = append(args, to.Name.ValueString())
-	args = append(args, to.Host.ValueString())
-
-	tflog.Info(ctx, sql, map[string]any{"args": args})
+	var statements []sqlStatement
+	for _, group := range groups {
+		var args []any
+		placeholders := []string{}
+		for _, role := range group {
+			placeholder, roleArgs := flavor.role(role.Name.ValueString(), role.Host.ValueString())
+			placeholders = append(placeholders, placeholder)
+			args = append(args, roleArgs...)
+		}
+		account, accountArgs := flavor.account(to.Name.ValueString(), to.Host.ValueString())
+		args = append(args, accountArgs...)
+		statements = append(statements, sqlStatement{
+			query: fmt.Sprintf(`%s %s %s %s%s`, verb, strings.Join(placeholders, ","), preposition, account, suffix),
+			args:  args,
+		})
+	}
+	return statements
+}
 
-	_, err := db.ExecContext(ctx, sql, args...)
-	if err != nil {
-		return err
+// readGrantedRolesStatement returns the query selecting the name, the host and
+// the admin option of the roles granted to the user or role.
+func readGrantedRolesStatement(flavor Flavor, name, host string) sqlStatement {
+	if flavor == FlavorMariaDB {
+		return sqlStatement{
+			query: `
+SELECT
+  Role
+, ''
+, Admin_option
+FROM
+  mysql.roles_mapping
+WHERE
+  User = ?
+  AND Host = ?
+`,
+			args: []any{name, host},
+		}
+	}
+	return sqlStatement{
+		query: `
+SELECT
+  FROM_USER
+, FROM_HOST
+, WITH_ADMIN_OPTION
+FROM
+  mysql.role_edges
+WHERE
+  TO_USER = ?
+  AND TO_HOST = ?
+`,
+		args: []any{name, host},
 	}
-
-	return nil
 }
 
 func (r *GrantRoleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
@@ -186,3 +186,42 @@ resource "mysql_grant_role" "test" {
 	}
 	return config
 }
+
+func TestGrantRolesStatements(t *testing.T) {
+	to := NewUser("user", "%")
+	roles := []RoleModel{NewRole("role0", "%"), {Name: NewRole("role1", "").Name}}
+
+	t.Run("MySQL", func(t *testing.T) {
+		grants := grantRolesStatements(FlavorMySQL, to, roles, true)
+		if len(grants) != 1 {
+			t.Fatalf("unexpected statements: %v", grants)
+		}
+		testStatement(t, grants[0], sqlStatement{query: "GRANT ?@?,? TO ?@? WITH ADMIN OPTION", args: []any{"role0", "%", "role1", "user", "%"}})
+		revokes := revokeRolesStatements(FlavorMySQL, to, roles)
+		if len(revokes) != 1 {
+			t.Fatalf("unexpected statements: %v", revokes)
+		}
+		testStatement(t, revokes[0], sqlStatement{query: "REVOKE ?@?,? FROM ?@?", args: []any{"role0", "%", "role1", "user", "%"}})
+	})
+
+	t.Run("MariaDB", func(t *testing.T) {
+		grants := grantRolesStatements(FlavorMariaDB, to, roles, false)
+		if len(grants) != 2 {
+			t.Fatalf("MariaDB must grant a role per statement: %v", grants)
+		}
+		testStatement(t, grants[0], sqlStatement{query: "GRANT 'role0' TO 'user'@'%'"})
+		testStatement(t, grants[1], sqlStatement{query: "GRANT 'role1' TO 'user'@'%'"})
+		revokes := revokeRolesStatements(FlavorMariaDB, NewUser("role2", ""), roles[:1])
+		if len(revokes) != 1 {
+			t.Fatalf("unexpected statements: %v", revokes)
+		}
+		testStatement(t, revokes[0], sqlStatement{query: "REVOKE 'role0' FROM 'role2'"})
+	})
+
+	if stmt := readGrantedRolesStatement(FlavorMariaDB, "user", "%"); !regexp.MustCompile(`mysql\.roles_mapping`).MatchString(stmt.query) {
+		t.Errorf("unexpected MariaDB query: %s", stmt.query)
+	}
+	if stmt := readGrantedRolesStatement(FlavorMySQL, "user", "%"); !regexp.MustCompile(`mysql\.role_edges`).MatchString(stmt.query) {
+		t.Errorf("unexpected MySQL query: %s", stmt.query)
+	}
+}
@@ -0,0 +1,779 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/okkez/terraform-provider-mysql/internal/utils"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var (
+	_ resource.Resource                   = &GrantsResource{}
+	_ resource.ResourceWithConfigure      = &GrantsResource{}
+	_ resource.ResourceWithImportState    = &GrantsResource{}
+	_ resource.ResourceWithModifyPlan     = &GrantsResource{}
+	_ resource.ResourceWithValidateConfig = &GrantsResource{}
+)
+
+func NewGrantsResource() resource.Resource {
+	return &GrantsResource{}
+}
+
+// GrantsResource defines the resource implementation.
+type GrantsResource struct {
+	mysqlConfig *MySQLConfiguration
+}
+
+// GrantsResourceModel describes the resource data model.
+type GrantsResourceModel struct {
+	ID     types.String `tfsdk:"id"`
+	User   types.String `tfsdk:"user"`
+	Host   types.String `tfsdk:"host"`
+	Grants types.Set    `tfsdk:"grant"`
+	Roles  types.Set    `tfsdk:"role"`
+}
+
+// AccountGrantModel is a grant block, i.e. the privileges on an object.
+type AccountGrantModel struct {
+	ObjectType  types.String `tfsdk:"object_type"`
+	Database    types.String `tfsdk:"database"`
+	Table       types.String `tfsdk:"table"`
+	Privileges  types.Set    `tfsdk:"privilege"`
+	GrantOption types.Bool   `tfsdk:"grant_option"`
+}
+
+var AccountGrantModelTypes = map[string]attr.Type{
+	"object_type":  types.StringType,
+	"database":     types.StringType,
+	"table":        types.StringType,
+	"privilege":    types.SetType{ElemType: types.ObjectType{AttrTypes: PrivlilegeTypeModelTypes}},
+	"grant_option": types.BoolType,
+}
+
+// privilegeLevel returns the object of the grant.
+func (m AccountGrantModel) privilegeLevel() PrivilegeLevelModel {
+	return PrivilegeLevelModel{ObjectType: m.ObjectType, Database: m.Database, Table: m.Table}
+}
+
+// key identifies the object of the grant. Routine names are
+// case-insensitive.
+func (m AccountGrantModel) key() string {
+	objectType := objectTypeOf(m.ObjectType)
+	table := m.Table.ValueString()
+	if objectType != objectTypeTable {
+		table = strings.ToLower(table)
+	}
+	return fmt.Sprintf("%s %s.%s", objectType, m.Database.ValueString(), table)
+}
+
+// GrantedRoleModel is a role block.
+type GrantedRoleModel struct {
+	Name        types.String `tfsdk:"name"`
+	Host        types.String `tfsdk:"host"`
+	AdminOption types.Bool   `tfsdk:"admin_option"`
+}
+
+var GrantedRoleModelTypes = map[string]attr.Type{
+	"name":         types.StringType,
+	"host":         types.StringType,
+	"admin_option": types.BoolType,
+}
+
+// key identifies the role. MariaDB roles have no host.
+func (m GrantedRoleModel) key(flavor Flavor) string {
+	if !flavor.rolesHaveHosts() {
+		return m.Name.ValueString()
+	}
+	return m.Name.ValueString() + "@" + m.Host.ValueString()
+}
+
+func (r *GrantsResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_grants"
+}
+
+func (r *GrantsResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "The `mysql_grants` resource manages all privileges and roles granted to a user or a role. " +
+			"Grants which are not in the configuration, e.g. the ones granted by hand, are shown as changes and revoked on apply, " +
+			"including the ones granted before the resource is created.\n\n" +
+			"~> **Note:** Do not use this resource with [`mysql_grant_privilege`](./grant_privilege) or [`mysql_grant_role`](./grant_role) for the same account. " +
+			"Proxy grants and the partial revokes of MySQL are not managed. " +
+			"MySQL lists the privileges of `ALL PRIVILEGES` on `*.*` one by one, so list them in the configuration too.",
+
+		Attributes: map[string]schema.Attribute{
+			"id":   utils.IDAttribute(),
+			"user": utils.NameAttribute("user or role", true),
+			"host": utils.HostAttribute("user or role", true),
+		},
+		Blocks: map[string]schema.Block{
+			"grant": schema.SetNestedBlock{
+				MarkdownDescription: "Set the privileges on an object. Each object may have one `grant` block.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"object_type": schema.StringAttribute{
+							MarkdownDescription: "The type of the object, `TABLE`, `FUNCTION` or `PROCEDURE`. Defaults to `TABLE`.",
+							Optional:            true,
+							Validators: []validator.String{
+								stringvalidator.OneOf(objectTypeTable, objectTypeFunction, objectTypeProcedure),
+							},
+						},
+						"database": schema.StringAttribute{
+							MarkdownDescription: "The database name, or `*` for global privileges.",
+							Required:            true,
+						},
+						"table": schema.StringAttribute{
+							MarkdownDescription: "The table name or `*`, or the routine name when `object_type` is `FUNCTION` or `PROCEDURE`.",
+							Required:            true,
+						},
+						"grant_option": schema.BoolAttribute{
+							MarkdownDescription: "If `true`, add `WITH GRANT OPTION`. Defaults to `false`.",
+							Optional:            true,
+							Computed:            true,
+							Default:             booldefault.StaticBool(false),
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"privilege": grantsPrivilegeBlock(),
+					},
+				},
+			},
+			"role": schema.SetNestedBlock{
+				MarkdownDescription: "Set a role to be granted.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": utils.NameAttribute("role", false),
+						"host": utils.HostAttribute("role", false),
+						"admin_option": schema.BoolAttribute{
+							MarkdownDescription: "If `true`, add `WITH ADMIN OPTION`. Defaults to `false`.",
+							Optional:            true,
+							Computed:            true,
+							Default:             booldefault.StaticBool(false),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// grantsPrivilegeBlock returns the privilege blocks of a grant block, which
+// must have at least one.
+func grantsPrivilegeBlock() schema.SetNestedBlock {
+	block := privilegeBlock()
+	block.Validators = []validator.Set{setvalidator.IsRequired()}
+	return block
+}
+
+func (r *GrantsResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	if mysqlConfig, ok := req.ProviderData.(*MySQLConfiguration); ok {
+		r.mysqlConfig = mysqlConfig
+	} else {
+		resp.Diagnostics.AddError("Failed type assertion", "")
+	}
+}
+
+func (r *GrantsResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var grants types.Set
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("grant"), &grants)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateAccountGrants(ctx, grants)...)
+}
+
+func (r *GrantsResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var data *GrantsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// The objects may be unknown while validating, e.g. when the database
+	// is the name of mysql_database.
+	resp.Diagnostics.Append(validateAccountGrants(ctx, data.Grants)...)
+	if !data.Roles.IsUnknown() && len(data.Roles.Elements()) > 0 {
+		resp.Diagnostics.Append(checkCapability(ctx, r.mysqlConfig, path.Root("role"), "Roles", func(c Capabilities) bool { return c.Roles })...)
+	}
+	if data.Grants.IsUnknown() {
+		return
+	}
+	var grants []AccountGrantModel
+	resp.Diagnostics.Append(data.Grants.ElementsAs(ctx, &grants, false)...)
+	var privileges []PrivilegeTypeModel
+	for _, grant := range grants {
+		var grantPrivileges []PrivilegeTypeModel
+		resp.Diagnostics.Append(grant.Privileges.ElementsAs(ctx, &grantPrivileges, false)...)
+		privileges = append(privileges, grantPrivileges...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(checkServerPrivileges(ctx, r.mysqlConfig, path.Root("grant"), privileges)...)
+}
+
+func (r *GrantsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	conn, err := getConnection(ctx, r.mysqlConfig)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
+		return
+	}
+
+	var data *GrantsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	account := UserModel{Name: data.User, Host: data.Host}
+
+	// The account owns the grants made before the resource is created too.
+	current, currentRoles, err := queryAccountGrants(ctx, conn, account, nil, nil)
+	if errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Account not found (%s)", account.GetID()), "Create the user or the role before granting privileges.")
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading grants (%s)", account.GetID()), err.Error())
+		return
+	}
+	revokes := r.apply(ctx, conn, account, current, currentRoles, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if revokes > 0 {
+		resp.Diagnostics.AddWarning("Revoked unmanaged grants",
+			fmt.Sprintf("%d REVOKE statements were run for the grants of %s which are not in the configuration.", revokes, account.GetID()))
+	}
+
+	data.ID = types.StringValue(account.GetID())
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GrantsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
+		return
+	}
+
+	var data *GrantsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	account := UserModel{Name: data.User, Host: data.Host}
+
+	var priorGrants []AccountGrantModel
+	var priorRoles []GrantedRoleModel
+	data.Grants.ElementsAs(ctx, &priorGrants, false)
+	data.Roles.ElementsAs(ctx, &priorRoles, false)
+	grants, roles, err := queryAccountGrants(ctx, conn, account, priorGrants, priorRoles)
+	if errors.Is(err, errNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading grants (%s)", account.GetID()), err.Error())
+		return
+	}
+
+	var diags diag.Diagnostics
+	data.Grants, diags = types.SetValueFrom(ctx, types.ObjectType{AttrTypes: AccountGrantModelTypes}, grants)
+	resp.Diagnostics.Append(diags...)
+	data.Roles, diags = types.SetValueFrom(ctx, types.ObjectType{AttrTypes: GrantedRoleModelTypes}, roles)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GrantsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	conn, err := getConnection(ctx, r.mysqlConfig)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
+		return
+	}
+
+	var data, state *GrantsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	account := UserModel{Name: data.User, Host: data.Host}
+
+	// The state is not refreshed with -refresh=false, so the grants are read
+	// again. The state only spells them as configured.
+	var priorGrants []AccountGrantModel
+	var priorRoles []GrantedRoleModel
+	resp.Diagnostics.Append(state.Grants.ElementsAs(ctx, &priorGrants, false)...)
+	resp.Diagnostics.Append(state.Roles.ElementsAs(ctx, &priorRoles, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	current, currentRoles, err := queryAccountGrants(ctx, conn, account, priorGrants, priorRoles)
+	if errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError(fmt.Sprintf("Account not found (%s)", account.GetID()), "Create the user or the role before granting privileges.")
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading grants (%s)", account.GetID()), err.Error())
+		return
+	}
+	r.apply(ctx, conn, account, current, currentRoles, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GrantsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	conn, err := getConnection(ctx, r.mysqlConfig)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
+		return
+	}
+
+	var data *GrantsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	account := UserModel{Name: data.User, Host: data.Host}
+
+	var current []AccountGrantModel
+	var currentRoles []GrantedRoleModel
+	resp.Diagnostics.Append(data.Grants.ElementsAs(ctx, &current, false)...)
+	resp.Diagnostics.Append(data.Roles.ElementsAs(ctx, &currentRoles, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	revokes, _ := grantsStatements(ctx, conn.Flavor, account, current, currentRoles, nil, nil)
+	for _, stmt := range revokes {
+		if err := conn.exec(ctx, stmt); err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed executing REVOKE statement (%s)", account.GetID()), err.Error())
+			return
+		}
+	}
+}
+
+func (r *GrantsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	nameHost := strings.SplitN(req.ID, "@", 2)
+	if len(nameHost) != 2 {
+		resp.Diagnostics.AddAttributeError(path.Root("id"), fmt.Sprintf("Invalid ID format. %s", req.ID), "The valid ID format is `name@host`")
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), types.StringValue(req.ID))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("user"), types.StringValue(nameHost[0]))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("host"), types.StringValue(nameHost[1]))...)
+}
+
+// apply runs the statements changing the grants of account from current to
+// the grants of data, and returns the number of REVOKE statements.
+func (r *GrantsResource) apply(ctx context.Context, conn *OneConnection, account UserModel, current []AccountGrantModel, currentRoles []GrantedRoleModel, data *GrantsResourceModel, diags *diag.Diagnostics) int {
+	var desired []AccountGrantModel
+	var desiredRoles []GrantedRoleModel
+	diags.Append(data.Grants.ElementsAs(ctx, &desired, false)...)
+	diags.Append(data.Roles.ElementsAs(ctx, &desiredRoles, false)...)
+	if diags.HasError() {
+		return 0
+	}
+
+	revokes, grants := grantsStatements(ctx, conn.Flavor, account, current, currentRoles, desired, desiredRoles)
+	for _, stmt := range revokes {
+		if err := conn.exec(ctx, stmt); err != nil {
+			diags.AddError(fmt.Sprintf("Failed executing REVOKE statement (%s)", account.GetID()), err.Error())
+			return 0
+		}
+	}
+	for _, stmt := range grants {
+		if err := conn.exec(ctx, stmt); err != nil {
+			diags.AddError(fmt.Sprintf("Failed executing GRANT statement (%s)", account.GetID()), err.Error())
+			return 0
+		}
+	}
+	return len(revokes)
+}
+
+// validateAccountGrants checks the privileges of the grant blocks against the
+// catalog, and rejects the objects and the privileges which are set twice,
+// since the server merges them. Unknown values are not checked.
+func validateAccountGrants(ctx context.Context, grants types.Set) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if grants.IsNull() || grants.IsUnknown() {
+		return diags
+	}
+	var grantModels []AccountGrantModel
+	diags.Append(grants.ElementsAs(ctx, &grantModels, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	objects := map[string]bool{}
+	for _, grant := range grantModels {
+		level := grant.privilegeLevel()
+		diags.Append(validatePrivileges(ctx, path.Root("grant"), grant.Privileges, level.grantLevel())...)
+		if level.grantLevel() != 0 {
+			if objects[grant.key()] {
+				diags.AddAttributeError(path.Root("grant"), "Duplicate grant",
+					fmt.Sprintf("%s has more than one grant block. Merge them into one.", buildPrivilegeLevel(level)))
+			}
+			objects[grant.key()] = true
+		}
+
+		if grant.Privileges.IsNull() || grant.Privileges.IsUnknown() {
+			continue
+		}
+		var privileges []PrivilegeTypeModel
+		diags.Append(grant.Privileges.ElementsAs(ctx, &privileges, false)...)
+		seen := map[string]bool{}
+		for _, privilege := range privileges {
+			if privilege.PrivType.IsUnknown() || privilege.Columns.IsUnknown() {
+				continue
+			}
+			privType := normalizePrivilege(privilege.PrivType.ValueString())
+			if privType == "USAGE" {
+				diags.AddAttributeError(path.Root("grant"), "Invalid privilege", "USAGE grants nothing. Remove the privilege block.")
+			}
+			key := fmt.Sprintf("%s columns=%t", privType, len(privilege.Columns.Elements()) > 0)
+			if seen[key] {
+				diags.AddAttributeError(path.Root("grant"), "Duplicate privilege",
+					fmt.Sprintf("%s is set more than once on %s. Merge the privilege blocks into one.", privType, buildPrivilegeLevel(level)))
+			}
+			seen[key] = true
+		}
+	}
+	return diags
+}
+
+// queryAccountGrants reads the privileges of SHOW GRANTS and the roles
+// granted to account. The objects, the privileges and the MariaDB roles are
+// spelled as in prior and priorRoles. The error wraps errNotFound when the
+// account does not exist.
+func queryAccountGrants(ctx context.Context, conn *OneConnection, account UserModel, prior []AccountGrantModel, priorRoles []GrantedRoleModel) ([]AccountGrantModel, []GrantedRoleModel, error) {
+	rows, err := conn.query(ctx, showGrantsStatement(conn.Flavor, account))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var statements []*GrantPrivilege
+	for rows.Next() {
+		var grantStatement string
+		if err := rows.Scan(&grantStatement); err != nil {
+			return nil, nil, err
+		}
+		grantPrivilege, err := ParseShowGrantsStatement(grantStatement, conn.ANSIQuotes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed parsing grant statement %s: %w", grantStatement, err)
+		}
+		if grantPrivilege != nil {
+			statements = append(statements, grantPrivilege)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	grants, err := accountGrantsValue(ctx, statements, prior)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !conn.Capabilities.Roles {
+		return grants, nil, nil
+	}
+	stmt := readGrantedRolesStatement(conn.Flavor, account.Name.ValueString(), account.Host.ValueString())
+	roleRows, err := conn.query(ctx, stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = roleRows.Close() }()
+	var roles []GrantedRoleModel
+	for roleRows.Next() {
+		var fromUser, fromHost, adminOption string
+		if err := roleRows.Scan(&fromUser, &fromHost, &adminOption); err != nil {
+			return nil, nil, err
+		}
+		role := GrantedRoleModel{Name: types.StringValue(fromUser), Host: types.StringValue(fromHost), AdminOption: types.BoolValue(adminOption == "Y")}
+		if !conn.Flavor.rolesHaveHosts() {
+			// MariaDB roles have no host. Keep the configured one.
+			role.Host = types.StringValue("%")
+			for _, priorRole := range priorRoles {
+				if priorRole.Name.ValueString() == fromUser {
+					role.Host = priorRole.Host
+				}
+			}
+		}
+		roles = append(roles, role)
+	}
+	return grants, roles, roleRows.Err()
+}
+
+// accountGrantsValue returns the grant blocks of the rows of SHOW GRANTS. The
+// rows on the same object are merged, e.g. the static and the dynamic
+// privileges of MySQL, and USAGE is dropped.
+func accountGrantsValue(ctx context.Context, statements []*GrantPrivilege, prior []AccountGrantModel) ([]AccountGrantModel, error) {
+	priorByKey := map[string]AccountGrantModel{}
+	for _, grant := range prior {
+		priorByKey[grant.key()] = grant
+	}
+
+	type object struct {
+		grant      AccountGrantModel
+		privileges []attr.Value
+		seen       map[string]bool
+	}
+	var keys []string
+	objects := map[string]*object{}
+	for _, statement := range statements {
+		grant := AccountGrantModel{
+			ObjectType:  types.StringNull(),
+			Database:    types.StringValue(statement.DBName),
+			Table:       types.StringValue(statement.TableName),
+			GrantOption: types.BoolValue(statement.GrantOption),
+		}
+		if statement.ObjectType != objectTypeTable {
+			grant.ObjectType = types.StringValue(statement.ObjectType)
+		}
+		key := grant.key()
+		o, ok := objects[key]
+		if !ok {
+			if p, ok := priorByKey[key]; ok {
+				grant.ObjectType, grant.Database, grant.Table = p.ObjectType, p.Database, p.Table
+			}
+			o = &object{grant: grant, seen: map[string]bool{}}
+			objects[key] = o
+			keys = append(keys, key)
+		}
+		o.grant.GrantOption = types.BoolValue(o.grant.GrantOption.ValueBool() || statement.GrantOption)
+
+		var spellings map[string]string
+		if p, ok := priorByKey[key]; ok {
+			spellings = privilegeSpellings(ctx, p.Privileges)
+		}
+		for _, priv := range statement.Privileges {
+			if len(priv.Priv.String()) == 0 && len(priv.Name) == 0 {
+				continue
+			}
+			if len(priv.Name) == 0 && normalizePrivilege(priv.Priv.String()) == "USAGE" {
+				continue
+			}
+			value := privilegeValue(priv, spellings)
+			if o.seen[value.String()] {
+				continue
+			}
+			o.seen[value.String()] = true
+			o.privileges = append(o.privileges, value)
+		}
+	}
+
+	var grants []AccountGrantModel
+	for _, key := range keys {
+		o := objects[key]
+		if len(o.privileges) == 0 && !o.grant.GrantOption.ValueBool() {
+			continue
+		}
+		o.grant.Privileges = types.SetValueMust(types.ObjectType{AttrTypes: PrivlilegeTypeModelTypes}, o.privileges)
+		grants = append(grants, o.grant)
+	}
+	return grants, nil
+}
+
+// privilegeUnit is a privilege on an object or on one of its columns, which
+// is granted and revoked independently.
+type privilegeUnit struct {
+	privType string
+	column   string
+}
+
+// privilegeUnits splits privileges into units. The privileges are normalized,
+// so aliases are the same unit.
+func privilegeUnits(ctx context.Context, privileges types.Set) map[privilegeUnit]bool {
+	var privilegeModels []PrivilegeTypeModel
+	privileges.ElementsAs(ctx, &privilegeModels, false)
+	units := map[privilegeUnit]bool{}
+	for _, privilege := range privilegeModels {
+		privType := normalizePrivilege(privilege.PrivType.ValueString())
+		var columns []string
+		privilege.Columns.ElementsAs(ctx, &columns, false)
+		if len(columns) == 0 {
+			units[privilegeUnit{privType: privType}] = true
+		}
+		for _, column := range columns {
+			units[privilegeUnit{privType: privType, column: column}] = true
+		}
+	}
+	return units
+}
+
+// privilegesOfUnits merges the units into privileges with columns, sorted by
+// the names.
+func privilegesOfUnits(units map[privilegeUnit]bool) []PrivilegeTypeModel {
+	type name struct {
+		privType   string
+		hasColumns bool
+	}
+	columnsByName := map[name][]string{}
+	for unit := range units {
+		n := name{privType: unit.privType, hasColumns: unit.column != ""}
+		columnsByName[n] = append(columnsByName[n], unit.column)
+	}
+	names := make([]name, 0, len(columnsByName))
+	for n := range columnsByName {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].privType != names[j].privType {
+			return names[i].privType < names[j].privType
+		}
+		return !names[i].hasColumns && names[j].hasColumns
+	})
+
+	var privileges []PrivilegeTypeModel
+	for _, n := range names {
+		privilege := PrivilegeTypeModel{PrivType: types.StringValue(n.privType), Columns: types.SetNull(types.StringType)}
+		if n.hasColumns {
+			columns := columnsByName[n]
+			sort.Strings(columns)
+			values := make([]attr.Value, 0, len(columns))
+			for _, column := range columns {
+				values = append(values, types.StringValue(column))
+			}
+			privilege.Columns = types.SetValueMust(types.StringType, values)
+		}
+		privileges = append(privileges, privilege)
+	}
+	return privileges
+}
+
+// grantsStatements returns the REVOKE and the GRANT statements changing the
+// grants of account from current to desired. The REVOKE statements run
+// first.
+func grantsStatements(ctx context.Context, flavor Flavor, account UserModel, current []AccountGrantModel, currentRoles []GrantedRoleModel, desired []AccountGrantModel, desiredRoles []GrantedRoleModel) (revokes, grants []sqlStatement) {
+	currentByKey := map[string]AccountGrantModel{}
+	desiredByKey := map[string]AccountGrantModel{}
+	var keys []string
+	for _, grant := range current {
+		currentByKey[grant.key()] = grant
+		keys = append(keys, grant.key())
+	}
+	for _, grant := range desired {
+		if _, ok := currentByKey[grant.key()]; !ok {
+			keys = append(keys, grant.key())
+		}
+		desiredByKey[grant.key()] = grant
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		cur, hasCurrent := currentByKey[key]
+		des, hasDesired := desiredByKey[key]
+		curUnits, desUnits := map[privilegeUnit]bool{}, map[privilegeUnit]bool{}
+		if hasCurrent {
+			curUnits = privilegeUnits(ctx, cur.Privileges)
+		}
+		if hasDesired {
+			desUnits = privilegeUnits(ctx, des.Privileges)
+		}
+		curGrantOption := hasCurrent && cur.GrantOption.ValueBool()
+		desGrantOption := hasDesired && des.GrantOption.ValueBool()
+
+		toRevoke := map[privilegeUnit]bool{}
+		for unit := range curUnits {
+			if !desUnits[unit] {
+				toRevoke[unit] = true
+			}
+		}
+		revokeGrantOption := curGrantOption && !desGrantOption
+		if len(toRevoke) > 0 || revokeGrantOption {
+			revokes = append(revokes, revokePrivilegesStatement(ctx, flavor, privilegesOfUnits(toRevoke), cur.privilegeLevel(), account, revokeGrantOption, true))
+		}
+
+		toGrant := map[privilegeUnit]bool{}
+		for unit := range desUnits {
+			// The grant option of dynamic privileges is per privilege, so
+			// every privilege is granted again.
+			if !curUnits[unit] || (desGrantOption && !curGrantOption) {
+				toGrant[unit] = true
+			}
+		}
+		if len(toGrant) > 0 {
+			grants = append(grants, grantPrivilegesStatement(ctx, flavor, privilegesOfUnits(toGrant), des.privilegeLevel(), account, desGrantOption))
+		}
+	}
+
+	currentRolesByKey := map[string]GrantedRoleModel{}
+	for _, role := range currentRoles {
+		currentRolesByKey[role.key(flavor)] = role
+	}
+	desiredRolesByKey := map[string]GrantedRoleModel{}
+	for _, role := range desiredRoles {
+		desiredRolesByKey[role.key(flavor)] = role
+	}
+	var rolesToRevoke, rolesToGrant, rolesToGrantWithAdmin []RoleModel
+	for _, role := range sortedRoles(flavor, currentRoles) {
+		des, ok := desiredRolesByKey[role.key(flavor)]
+		// MySQL has no REVOKE ADMIN OPTION FOR, so the role is granted
+		// again without the admin option.
+		if !ok || (role.AdminOption.ValueBool() && !des.AdminOption.ValueBool()) {
+			rolesToRevoke = append(rolesToRevoke, RoleModel{Name: role.Name, Host: role.Host})
+		}
+	}
+	for _, role := range sortedRoles(flavor, desiredRoles) {
+		cur, ok := currentRolesByKey[role.key(flavor)]
+		switch {
+		case role.AdminOption.ValueBool() && (!ok || !cur.AdminOption.ValueBool()):
+			rolesToGrantWithAdmin = append(rolesToGrantWithAdmin, RoleModel{Name: role.Name, Host: role.Host})
+		case !role.AdminOption.ValueBool() && (!ok || cur.AdminOption.ValueBool()):
+			rolesToGrant = append(rolesToGrant, RoleModel{Name: role.Name, Host: role.Host})
+		}
+	}
+	if len(rolesToRevoke) > 0 {
+		revokes = append(revokes, revokeRolesStatements(flavor, account, rolesToRevoke)...)
+	}
+	if len(rolesToGrant) > 0 {
+		grants = append(grants, grantRolesStatements(flavor, account, rolesToGrant, false)...)
+	}
+	if len(rolesToGrantWithAdmin) > 0 {
+		grants = append(grants, grantRolesStatements(flavor, account, rolesToGrantWithAdmin, true)...)
+	}
+	return revokes, grants
+}
+
+// sortedRoles returns roles sorted by the keys.
+func sortedRoles(flavor Flavor, roles []GrantedRoleModel) []GrantedRoleModel {
+	sorted := append([]GrantedRoleModel{}, roles...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].key(flavor) < sorted[j].key(flavor) })
+	return sorted
+}
@@ -0,0 +1,355 @@
+package provider
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccGrantsResource(t *testing.T) {
+	user := NewRandomUser("test-user", "%")
+	role := NewRandomRole("test-role", "%")
+	database := fmt.Sprintf("test_grants_%s", user.GetName()[len("test-user-"):])
+	t.Logf("user: %s, role: %s, database: %s", user.GetName(), role.GetName(), database)
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccGrantsResource_Config(user, role, database, `["SELECT", "INSERT"]`, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_grants.test", "id", user.GetID()),
+					resource.TestCheckResourceAttr("mysql_grants.test", "grant.#", "2"),
+					resource.TestCheckResourceAttr("mysql_grants.test", "role.#", "1"),
+					resource.TestCheckResourceAttr("mysql_grants.test", "role.0.admin_option", "false"),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      "mysql_grants.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Unmanaged grants are revoked
+			{
+				PreConfig: func() {
+					if _, err := testDatabase().Exec(fmt.Sprintf("GRANT DELETE ON `%s`.* TO '%s'@'%%'", database, user.GetName())); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccGrantsResource_Config(user, role, database, `["SELECT", "INSERT"]`, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_grants.test", "grant.#", "2"),
+				),
+			},
+			// Update and Read testing
+			{
+				Config: testAccGrantsResource_Config(user, role, database, `["SELECT"]`, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_grants.test", "grant.#", "2"),
+					resource.TestCheckResourceAttr("mysql_grants.test", "role.0.admin_option", "true"),
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func testAccGrantsResource_Config(user UserModel, role RoleModel, database, privileges string, adminOption bool) string {
+	return fmt.Sprintf(`
+resource "mysql_user" "test" {
+  name = %[1]q
+}
+
+resource "mysql_role" "test" {
+  name = %[2]q
+}
+
+resource "mysql_database" "test" {
+  name = %[3]q
+}
+
+resource "mysql_grants" "test" {
+  user = mysql_user.test.name
+  host = mysql_user.test.host
+
+  grant {
+    database = "*"
+    table    = "*"
+    privilege {
+      priv_type = "PROCESS"
+    }
+  }
+
+  grant {
+    database = mysql_database.test.name
+    table    = "*"
+    dynamic "privilege" {
+      for_each = toset(%[4]s)
+      content {
+        priv_type = privilege.value
+      }
+    }
+  }
+
+  role {
+    name         = mysql_role.test.name
+    admin_option = %[5]t
+  }
+}
+`, user.GetName(), role.GetName(), database, privileges, adminOption)
+}
+
+func testAccountGrant(database, table string, grantOption bool, privileges ...PrivilegeTypeModel) AccountGrantModel {
+	values := make([]attr.Value, 0, len(privileges))
+	for _, privilege := range privileges {
+		values = append(values, types.ObjectValueMust(PrivlilegeTypeModelTypes, map[string]attr.Value{
+			"priv_type": privilege.PrivType,
+			"columns":   privilege.Columns,
+		}))
+	}
+	return AccountGrantModel{
+		ObjectType:  types.StringNull(),
+		Database:    types.StringValue(database),
+		Table:       types.StringValue(table),
+		Privileges:  types.SetValueMust(types.ObjectType{AttrTypes: PrivlilegeTypeModelTypes}, values),
+		GrantOption: types.BoolValue(grantOption),
+	}
+}
+
+func testPrivilege(privType string, columns ...string) PrivilegeTypeModel {
+	privilege := PrivilegeTypeModel{PrivType: types.StringValue(privType), Columns: types.SetNull(types.StringType)}
+	if len(columns) > 0 {
+		values := make([]attr.Value, 0, len(columns))
+		for _, column := range columns {
+			values = append(values, types.StringValue(column))
+		}
+		privilege.Columns = types.SetValueMust(types.StringType, values)
+	}
+	return privilege
+}
+
+func testGrantedRole(name string, adminOption bool) GrantedRoleModel {
+	return GrantedRoleModel{Name: types.StringValue(name), Host: types.StringValue("%"), AdminOption: types.BoolValue(adminOption)}
+}
+
+func TestGrantsStatements(t *testing.T) {
+	ctx := t.Context()
+	account := NewUser("user", "%")
+	cases := []struct {
+		name         string
+		current      []AccountGrantModel
+		currentRoles []GrantedRoleModel
+		desired      []AccountGrantModel
+		desiredRoles []GrantedRoleModel
+		revokes      []string
+		grants       []string
+	}{
+		{
+			name: "no changes",
+			current: []AccountGrantModel{
+				testAccountGrant("db", "*", false, testPrivilege("ALL PRIVILEGES")),
+			},
+			desired: []AccountGrantModel{
+				testAccountGrant("db", "*", false, testPrivilege("all")),
+			},
+		},
+		{
+			name: "unmanaged object",
+			current: []AccountGrantModel{
+				testAccountGrant("*", "*", false, testPrivilege("PROCESS")),
+				testAccountGrant("db", "*", false, testPrivilege("SELECT")),
+			},
+			desired: []AccountGrantModel{
+				testAccountGrant("db", "*", false, testPrivilege("SELECT")),
+			},
+			revokes: []string{"REVOKE PROCESS ON *.* FROM 'user'@'%'"},
+		},
+		{
+			name: "privileges and columns",
+			current: []AccountGrantModel{
+				testAccountGrant("db", "t", false, testPrivilege("SELECT"), testPrivilege("UPDATE", "c1", "c2")),
+			},
+			desired: []AccountGrantModel{
+				testAccountGrant("db", "t", false, testPrivilege("INSERT"), testPrivilege("UPDATE", "c2", "c3")),
+			},
+			revokes: []string{"REVOKE SELECT,UPDATE (`c1`) ON `db`.`t` FROM 'user'@'%'"},
+			grants:  []string{"GRANT INSERT,UPDATE (`c3`) ON `db`.`t` TO 'user'@'%'"},
+		},
+		{
+			name: "add grant option",
+			current: []AccountGrantModel{
+				testAccountGrant("db", "*", false, testPrivilege("SELECT")),
+			},
+			desired: []AccountGrantModel{
+				testAccountGrant("db", "*", true, testPrivilege("SELECT"), testPrivilege("INSERT")),
+			},
+			grants: []string{"GRANT INSERT,SELECT ON `db`.* TO 'user'@'%' WITH GRANT OPTION"},
+		},
+		{
+			name: "remove grant option",
+			current: []AccountGrantModel{
+				testAccountGrant("db", "*", true, testPrivilege("SELECT"), testPrivilege("INSERT")),
+			},
+			desired: []AccountGrantModel{
+				testAccountGrant("db", "*", false, testPrivilege("SELECT")),
+			},
+			revokes: []string{"REVOKE INSERT ,GRANT OPTION ON `db`.* FROM 'user'@'%'"},
+		},
+		{
+			name:         "roles",
+			currentRoles: []GrantedRoleModel{testGrantedRole("role0", false), testGrantedRole("role1", true), testGrantedRole("role2", false)},
+			desiredRoles: []GrantedRoleModel{testGrantedRole("role1", false), testGrantedRole("role2", true), testGrantedRole("role3", false)},
+			revokes:      []string{"REVOKE 'role0' FROM 'user'@'%'", "REVOKE 'role1' FROM 'user'@'%'"},
+			grants: []string{
+				"GRANT 'role1' TO 'user'@'%'",
+				"GRANT 'role3' TO 'user'@'%'",
+				"GRANT 'role2' TO 'user'@'%' WITH ADMIN OPTION",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			revokes, grants := grantsStatements(ctx, FlavorMariaDB, account, c.current, c.currentRoles, c.desired, c.desiredRoles)
+			if len(revokes) != len(c.revokes) {
+				t.Fatalf("unexpected revokes: %v", revokes)
+			}
+			for i, revoke := range revokes {
+				testStatement(t, revoke, sqlStatement{query: c.revokes[i]})
+			}
+			if len(grants) != len(c.grants) {
+				t.Fatalf("unexpected grants: %v", grants)
+			}
+			for i, grant := range grants {
+				testStatement(t, grant, sqlStatement{query: c.grants[i]})
+			}
+		})
+	}
+}
+
+func TestAccountGrantsValue(t *testing.T) {
+	ctx := t.Context()
+	rows := []string{
+		"GRANT USAGE ON *.* TO `user`@`%`",
+		"GRANT PROCESS ON *.* TO `user`@`%`",
+		"GRANT BACKUP_ADMIN ON *.* TO `user`@`%` WITH GRANT OPTION",
+		"GRANT SELECT, UPDATE (`c1`) ON `db`.`t` TO `user`@`%`",
+		"GRANT `role0`@`%` TO `user`@`%` WITH ADMIN OPTION",
+	}
+	var statements []*GrantPrivilege
+	for _, row := range rows {
+		statement, err := ParseShowGrantsStatement(row, false)
+		if err != nil {
+			t.Fatalf("%s: %v", row, err)
+		}
+		if statement != nil {
+			statements = append(statements, statement)
+		}
+	}
+	if len(statements) != 4 {
+		t.Fatalf("unexpected statements: %d", len(statements))
+	}
+
+	prior := []AccountGrantModel{testAccountGrant("db", "t", false, testPrivilege("select"))}
+	grants, err := accountGrantsValue(ctx, statements, prior)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 2 {
+		t.Fatalf("unexpected grants: %v", grants)
+	}
+
+	global := grants[0]
+	if global.key() != "TABLE *.*" || !global.GrantOption.ValueBool() {
+		t.Errorf("unexpected global grant: %v", global)
+	}
+	units := privilegeUnits(ctx, global.Privileges)
+	if len(units) != 2 || !units[privilegeUnit{privType: "PROCESS"}] || !units[privilegeUnit{privType: "BACKUP_ADMIN"}] {
+		t.Errorf("unexpected global privileges: %v", units)
+	}
+
+	table := grants[1]
+	if table.key() != "TABLE db.t" || table.GrantOption.ValueBool() {
+		t.Errorf("unexpected table grant: %v", table)
+	}
+	var privileges []PrivilegeTypeModel
+	table.Privileges.ElementsAs(ctx, &privileges, false)
+	spellings := map[string]bool{}
+	for _, privilege := range privileges {
+		spellings[privilege.PrivType.ValueString()] = true
+	}
+	if len(privileges) != 2 || !spellings["select"] || !spellings["UPDATE"] {
+		t.Errorf("unexpected table privileges: %v", privileges)
+	}
+}
+
+func TestGrantsResourceUpdate_StaleState(t *testing.T) {
+	ctx := t.Context()
+	conf := testMySQLConfig()
+	conf.Config.User = "update_stale_grants"
+	var queries []string
+	testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		switch {
+		case strings.HasPrefix(query, "SHOW GRANTS"):
+			// INSERT was granted after the state was refreshed.
+			return &fakeRows{
+				columns: []string{"grants"},
+				values: [][]driver.Value{
+					{"GRANT USAGE ON *.* TO `user`@`%`"},
+					{"GRANT SELECT, INSERT ON `db`.* TO `user`@`%`"},
+				},
+			}, nil
+		case strings.Contains(query, "FROM_USER"):
+			return &fakeRows{columns: []string{"FROM_USER", "FROM_HOST", "WITH_ADMIN_OPTION"}}, nil
+		}
+		queries = append(queries, query)
+		return nil, nil
+	})
+
+	r := &GrantsResource{mysqlConfig: conf}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	value := func(grants ...AccountGrantModel) tftypes.Value {
+		grantsValue, diags := types.SetValueFrom(ctx, types.ObjectType{AttrTypes: AccountGrantModelTypes}, grants)
+		if diags.HasError() {
+			t.Fatalf("%v", diags)
+		}
+		state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+		diags = state.Set(ctx, &GrantsResourceModel{
+			ID:     types.StringValue("user@%"),
+			User:   types.StringValue("user"),
+			Host:   types.StringValue("%"),
+			Grants: grantsValue,
+			Roles:  types.SetValueMust(types.ObjectType{AttrTypes: GrantedRoleModelTypes}, nil),
+		})
+		if diags.HasError() {
+			t.Fatalf("%v", diags)
+		}
+		return state.Raw
+	}
+
+	// The plan adds UPDATE to the state, which has SELECT only.
+	req := fwresource.UpdateRequest{
+		Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: value(testAccountGrant("db", "*", false, testPrivilege("SELECT"), testPrivilege("UPDATE")))},
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: value(testAccountGrant("db", "*", false, testPrivilege("SELECT")))},
+	}
+	resp := fwresource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: req.Plan.Raw}}
+	r.Update(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	expected := []string{"REVOKE INSERT ON `db`.* FROM ?@?", "GRANT UPDATE ON `db`.* TO ?@?"}
+	if !slices.Equal(queries, expected) {
+		t.Errorf("unexpected statements: %q", queries)
+	}
+}
@@ -69,6 +69,17 @@ func (r *RoleModel) GetID() string {
 	return fmt.Sprintf("%s@%s", r.GetName(), r.GetHost())
 }
 
+// findRoleByName returns the role named name in roles. Roles read from
+// MariaDB have no host, so the host is taken from the configured role.
+func findRoleByName(roles []RoleModel, name string) (RoleModel, bool) {
+	for _, role := range roles {
+		if role.Name.ValueString() == name {
+			return role, true
+		}
+	}
+	return RoleModel{}, false
+}
+
 type RoleModelRaw struct {
 	Name string `diff:"name"`
 	Host string `diff:"host"`
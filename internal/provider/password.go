@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// readPasswordFile returns the content of the file at path without a trailing
+// newline.
+func readPasswordFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(content)), nil
+}
+
+// runPasswordCommand runs command without a shell and returns its standard
+// output without a trailing newline.
+func runPasswordCommand(ctx context.Context, command []string) (string, error) {
+	if len(command) == 0 || len(command[0]) == 0 {
+		return "", fmt.Errorf("command must not be empty")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return trimNewline(stdout.String()), nil
+}
+
+func trimNewline(s string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+}
+
+// The characters of generated passwords. The special characters exclude
+// quotes, backslashes and spaces, which are often mishandled by the tools
+// passing the passwords on.
+const (
+	passwordLowerChars   = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpperChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordNumberChars  = "0123456789"
+	passwordSpecialChars = "!#%*+,-.:=?^_~"
+)
+
+// passwordPolicy is the policy of the validate_password component (or the
+// plugin of MySQL 5.7) or of the simple_password_check plugin of MariaDB.
+type passwordPolicy struct {
+	Length int
+	// MixedCaseCount is the number of both lowercase and uppercase
+	// characters.
+	MixedCaseCount   int
+	NumberCount      int
+	SpecialCharCount int
+}
+
+// queryPasswordPolicy reads the password policy of the server. The policy is
+// empty when no password validation is installed. The patterns match more
+// variables than needed, which parsePasswordPolicy ignores.
+func queryPasswordPolicy(ctx context.Context, conn *OneConnection) (passwordPolicy, error) {
+	stmt := sqlStatement{query: `SHOW GLOBAL VARIABLES WHERE Variable_name LIKE 'validate_password%' OR Variable_name LIKE 'simple_password_check%'`}
+	rows, err := conn.query(ctx, stmt)
+	if err != nil {
+		return passwordPolicy{}, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	variables := map[string]string{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return passwordPolicy{}, err
+		}
+		variables[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return passwordPolicy{}, err
+	}
+	return parsePasswordPolicy(variables)
+}
+
+// parsePasswordPolicy builds the policy from the system variables of the
+// password validation. The LOW policy of validate_password checks only the
+// length.
+func parsePasswordPolicy(variables map[string]string) (passwordPolicy, error) {
+	var policy passwordPolicy
+	low := false
+	for name, value := range variables {
+		var field *int
+		switch strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(name, "validate_password."), "validate_password_"), "simple_password_check_") {
+		case "policy":
+			low = strings.EqualFold(value, "LOW") || value == "0"
+			continue
+		case "length", "minimal_length":
+			field = &policy.Length
+		case "mixed_case_count", "letters_same_case":
+			field = &policy.MixedCaseCount
+		case "number_count", "digits":
+			field = &policy.NumberCount
+		case "special_char_count", "other_characters":
+			field = &policy.SpecialCharCount
+		default:
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return passwordPolicy{}, fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+		*field = max(*field, n)
+	}
+	if low {
+		policy.MixedCaseCount, policy.NumberCount, policy.SpecialCharCount = 0, 0, 0
+	}
+	return policy, nil
+}
+
+// generatePassword returns a random password of at least length characters
+// which satisfies policy. Special characters are used only when special is
+// true.
+func generatePassword(policy passwordPolicy, length int, special bool) (string, error) {
+	if !special && policy.SpecialCharCount > 0 {
+		return "", fmt.Errorf("the password policy requires %d special characters", policy.SpecialCharCount)
+	}
+	length = max(length, policy.Length, 2*policy.MixedCaseCount+policy.NumberCount+policy.SpecialCharCount)
+
+	chars := passwordLowerChars + passwordUpperChars + passwordNumberChars
+	if special {
+		chars += passwordSpecialChars
+	}
+	password := make([]byte, 0, length)
+	for _, class := range []struct {
+		chars string
+		count int
+	}{
+		{passwordLowerChars, policy.MixedCaseCount},
+		{passwordUpperChars, policy.MixedCaseCount},
+		{passwordNumberChars, policy.NumberCount},
+		{passwordSpecialChars, policy.SpecialCharCount},
+		{chars, length - 2*policy.MixedCaseCount - policy.NumberCount - policy.SpecialCharCount},
+	} {
+		for range class.count {
+			c, err := randomIndex(len(class.chars))
+			if err != nil {
+				return "", err
+			}
+			password = append(password, class.chars[c])
+		}
+	}
+	// Shuffle the required characters into the others.
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// randomIndex returns a uniformly random integer in [0, n).
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadPasswordFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("p@ss word\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	password, err := readPasswordFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "p@ss word" {
+		t.Errorf("unexpected password: %q", password)
+	}
+
+	if _, err := readPasswordFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestRunPasswordCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	ctx := t.Context()
+
+	password, err := runPasswordCommand(ctx, []string{"sh", "-c", `printf '%s\n' "$1"`, "sh", "secret; not a shell"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "secret; not a shell" {
+		t.Errorf("unexpected password: %q", password)
+	}
+
+	_, err = runPasswordCommand(ctx, []string{"sh", "-c", "echo denied >&2; exit 1"})
+	if err == nil || err.Error() != "exit status 1: denied" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := runPasswordCommand(ctx, nil); err == nil {
+		t.Error("expected error for empty command")
+	}
+}
+
+func TestParsePasswordPolicy(t *testing.T) {
+	cases := []struct {
+		name      string
+		variables map[string]string
+		expected  passwordPolicy
+	}{
+		{"not installed", map[string]string{}, passwordPolicy{}},
+		{
+			"MySQL component",
+			map[string]string{
+				"validate_password.length":             "12",
+				"validate_password.mixed_case_count":   "2",
+				"validate_password.number_count":       "3",
+				"validate_password.special_char_count": "1",
+				"validate_password.policy":             "MEDIUM",
+				"validate_password.check_user_name":    "ON",
+			},
+			passwordPolicy{Length: 12, MixedCaseCount: 2, NumberCount: 3, SpecialCharCount: 1},
+		},
+		{
+			"MySQL 5.7 plugin with LOW policy",
+			map[string]string{
+				"validate_password_length":             "8",
+				"validate_password_mixed_case_count":   "1",
+				"validate_password_number_count":       "1",
+				"validate_password_special_char_count": "1",
+				"validate_password_policy":             "LOW",
+			},
+			passwordPolicy{Length: 8},
+		},
+		{
+			"MariaDB",
+			map[string]string{
+				"simple_password_check_minimal_length":    "10",
+				"simple_password_check_letters_same_case": "1",
+				"simple_password_check_digits":            "2",
+				"simple_password_check_other_characters":  "1",
+			},
+			passwordPolicy{Length: 10, MixedCaseCount: 1, NumberCount: 2, SpecialCharCount: 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			policy, err := parsePasswordPolicy(c.variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if policy != c.expected {
+				t.Errorf("unexpected policy: %+v", policy)
+			}
+		})
+	}
+
+	if _, err := parsePasswordPolicy(map[string]string{"validate_password.length": "long"}); err == nil {
+		t.Error("expected error for invalid length")
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	count := func(password, chars string) int {
+		n := 0
+		for _, c := range password {
+			if strings.ContainsRune(chars, c) {
+				n++
+			}
+		}
+		return n
+	}
+	policy := passwordPolicy{Length: 16, MixedCaseCount: 3, NumberCount: 4, SpecialCharCount: 2}
+	for range 100 {
+		password, err := generatePassword(policy, 8, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 16 {
+			t.Fatalf("unexpected length: %q", password)
+		}
+		if count(password, passwordLowerChars) < 3 || count(password, passwordUpperChars) < 3 ||
+			count(password, passwordNumberChars) < 4 || count(password, passwordSpecialChars) < 2 {
+			t.Fatalf("password does not satisfy the policy: %q", password)
+		}
+	}
+
+	password, err := generatePassword(passwordPolicy{NumberCount: 30}, 20, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) != 30 || count(password, passwordSpecialChars) > 0 {
+		t.Errorf("unexpected password: %q", password)
+	}
+
+	if _, err := generatePassword(passwordPolicy{SpecialCharCount: 1}, 20, false); err == nil {
+		t.Error("expected error when the policy requires special characters")
+	}
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// readPGPKey reads the public keys of key, which is ASCII armored or the
+// base64 encoding of the binary key, e.g. `gpg --export <id> | base64`.
+func readPGPKey(key string) (openpgp.EntityList, error) {
+	key = strings.TrimSpace(key)
+	if strings.HasPrefix(key, "-----BEGIN") {
+		return openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("PGP key is neither ASCII armored nor base64-encoded: %w", err)
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(raw))
+}
+
+// encryptWithPGPKey encrypts plaintext for the public keys of key and returns
+// the base64 encoding of the binary message, which is decrypted with
+// `base64 -d | gpg -d`.
+func encryptWithPGPKey(key, plaintext string) (string, error) {
+	entities, err := readPGPKey(key)
+	if err != nil {
+		return "", err
+	}
+	var ciphertext bytes.Buffer
+	w, err := openpgp.Encrypt(&ciphertext, entities, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write([]byte(plaintext)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext.Bytes()), nil
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// testPGPKey generates a PGP key and returns it with its public key, ASCII
+// armored and base64-encoded.
+func testPGPKey(t *testing.T) (*openpgp.Entity, string, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var public bytes.Buffer
+	if err := entity.Serialize(&public); err != nil {
+		t.Fatal(err)
+	}
+	var armored bytes.Buffer
+	w, err := armor.Encode(&armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(public.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return entity, armored.String(), base64.StdEncoding.EncodeToString(public.Bytes())
+}
+
+// testDecryptWithPGPKey decrypts the output of encryptWithPGPKey.
+func testDecryptWithPGPKey(t *testing.T, entity *openpgp.Entity, encrypted string) string {
+	t.Helper()
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := openpgp.ReadMessage(bytes.NewReader(ciphertext), openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := io.ReadAll(message.UnverifiedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(plaintext)
+}
+
+func TestEncryptWithPGPKey(t *testing.T) {
+	entity, armored, encoded := testPGPKey(t)
+	for name, key := range map[string]string{
+		"armored": armored,
+		"base64":  encoded + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := encryptWithPGPKey(key, "p@ssw0rd")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(encrypted, "p@ssw0rd") {
+				t.Fatal("the password is not encrypted")
+			}
+			if plaintext := testDecryptWithPGPKey(t, entity, encrypted); plaintext != "p@ssw0rd" {
+				t.Errorf("unexpected plaintext: %q", plaintext)
+			}
+		})
+	}
+
+	for _, key := range []string{"not a key", base64.StdEncoding.EncodeToString([]byte("not a key"))} {
+		if _, err := encryptWithPGPKey(key, "p@ssw0rd"); err == nil {
+			t.Errorf("expected error for %q", key)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// grantLevel is a set of the levels which privileges are granted at.
+type grantLevel uint8
+
+const (
+	// grantLevelGlobal is `*.*`.
+	grantLevelGlobal grantLevel = 1 << iota
+	// grantLevelDatabase is `db.*`.
+	grantLevelDatabase
+	// grantLevelTable is `db.table`.
+	grantLevelTable
+	// grantLevelColumn is `db.table` with columns.
+	grantLevelColumn
+	// grantLevelRoutine is `FUNCTION db.name` or `PROCEDURE db.name`.
+	grantLevelRoutine
+)
+
+// String returns the name of a single level.
+func (l grantLevel) String() string {
+	switch l {
+	case grantLevelGlobal:
+		return "global"
+	case grantLevelDatabase:
+		return "database"
+	case grantLevelTable:
+		return "table"
+	case grantLevelColumn:
+		return "column"
+	case grantLevelRoutine:
+		return "routine"
+	}
+	return fmt.Sprintf("grantLevel(%d)", uint8(l))
+}
+
+// privilegeInfo describes a privilege of the catalog.
+type privilegeInfo struct {
+	// levels are the levels which the privilege is valid at.
+	levels grantLevel
+	// since maps the flavors having the privilege to the first version
+	// which has it. An empty version is any version. A nil map is every
+	// flavor. FlavorPercona is checked as FlavorMySQL.
+	since map[Flavor]string
+}
+
+// Short hands of the catalog.
+const (
+	levelsGlobal        = grantLevelGlobal
+	levelsDatabase      = grantLevelGlobal | grantLevelDatabase
+	levelsTable         = levelsDatabase | grantLevelTable
+	levelsColumn        = levelsTable | grantLevelColumn
+	levelsRoutine       = levelsDatabase | grantLevelRoutine
+	levelsTableRoutines = levelsTable | grantLevelRoutine
+)
+
+func mysqlSince(v string) map[Flavor]string   { return map[Flavor]string{FlavorMySQL: v} }
+func mariadbSince(v string) map[Flavor]string { return map[Flavor]string{FlavorMariaDB: v} }
+
+// staticPrivileges are the privileges built in the servers, keyed by the
+// canonical names of normalizePrivilege.
+var staticPrivileges = map[string]privilegeInfo{
+	"ALL PRIVILEGES":          {levels: levelsTableRoutines},
+	"ALTER":                   {levels: levelsTable},
+	"ALTER ROUTINE":           {levels: levelsRoutine},
+	"CREATE":                  {levels: levelsTable},
+	"CREATE ROLE":             {levels: levelsGlobal, since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"CREATE ROUTINE":          {levels: levelsDatabase},
+	"CREATE TABLESPACE":       {levels: levelsGlobal, since: map[Flavor]string{FlavorMySQL: "", FlavorTiDB: ""}},
+	"CREATE TEMPORARY TABLES": {levels: levelsDatabase},
+	"CREATE USER":             {levels: levelsGlobal},
+	"CREATE VIEW":             {levels: levelsTable},
+	"DELETE":                  {levels: levelsTable},
+	"DROP":                    {levels: levelsTable},
+	"DROP ROLE":               {levels: levelsGlobal, since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"EVENT":                   {levels: levelsDatabase},
+	"EXECUTE":                 {levels: levelsRoutine},
+	"FILE":                    {levels: levelsGlobal},
+	"GRANT OPTION":            {levels: levelsTableRoutines},
+	"INDEX":                   {levels: levelsTable},
+	"INSERT":                  {levels: levelsColumn},
+	"LOCK TABLES":             {levels: levelsDatabase},
+	"PROCESS":                 {levels: levelsGlobal},
+	"REFERENCES":              {levels: levelsColumn},
+	"RELOAD":                  {levels: levelsGlobal},
+	"REPLICATION CLIENT":      {levels: levelsGlobal},
+	"REPLICATION SLAVE":       {levels: levelsGlobal},
+	"SELECT":                  {levels: levelsColumn},
+	"SHOW DATABASES":          {levels: levelsGlobal},
+	"SHOW VIEW":               {levels: levelsTable},
+	"SHUTDOWN":                {levels: levelsGlobal},
+	"SUPER":                   {levels: levelsGlobal},
+	"TRIGGER":                 {levels: levelsTable},
+	"UPDATE":                  {levels: levelsColumn},
+	"USAGE":                   {levels: levelsTableRoutines},
+
+	// MariaDB splits SUPER into the following privileges since 10.5.
+	"BINLOG ADMIN":             {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"BINLOG REPLAY":            {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"CONNECTION ADMIN":         {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"DELETE HISTORY":           {levels: levelsTable, since: mariadbSince("10.3.4")},
+	"FEDERATED ADMIN":          {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"READ_ONLY ADMIN":          {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"REPLICATION MASTER ADMIN": {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"REPLICATION SLAVE ADMIN":  {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"SET USER":                 {levels: levelsGlobal, since: mariadbSince("10.5.2")},
+	"SHOW CREATE ROUTINE":      {levels: levelsRoutine, since: mariadbSince("11.3.1")},
+	"SLAVE MONITOR":            {levels: levelsGlobal, since: mariadbSince("10.5.9")},
+
+	"CONFIG": {levels: levelsGlobal, since: map[Flavor]string{FlavorTiDB: ""}},
+}
+
+// dynamicPrivileges are the dynamic privileges known to be registered by the
+// servers or by the plugins and the components shipped with them. Dynamic
+// privileges are global.
+var dynamicPrivileges = map[string]privilegeInfo{
+	"ALLOW_NONEXISTENT_DEFINER":    {since: mysqlSince("8.2.0")},
+	"APPLICATION_PASSWORD_ADMIN":   {since: mysqlSince("8.0.14")},
+	"AUDIT_ABORT_EXEMPT":           {since: mysqlSince("8.0.28")},
+	"AUDIT_ADMIN":                  {since: mysqlSince("8.0.0")},
+	"AUTHENTICATION_POLICY_ADMIN":  {since: mysqlSince("8.0.27")},
+	"BACKUP_ADMIN":                 {since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"BINLOG_ADMIN":                 {since: mysqlSince("8.0.0")},
+	"BINLOG_ENCRYPTION_ADMIN":      {since: mysqlSince("8.0.14")},
+	"CLONE_ADMIN":                  {since: mysqlSince("8.0.17")},
+	"CONNECTION_ADMIN":             {since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"DASHBOARD_CLIENT":             {since: map[Flavor]string{FlavorTiDB: ""}},
+	"ENCRYPTION_KEY_ADMIN":         {since: mysqlSince("8.0.0")},
+	"FIREWALL_ADMIN":               {since: mysqlSince("8.0.0")},
+	"FIREWALL_EXEMPT":              {since: mysqlSince("8.0.23")},
+	"FIREWALL_USER":                {since: mysqlSince("8.0.0")},
+	"FLUSH_OPTIMIZER_COSTS":        {since: mysqlSince("8.0.23")},
+	"FLUSH_PRIVILEGES":             {since: mysqlSince("8.4.0")},
+	"FLUSH_STATUS":                 {since: mysqlSince("8.0.23")},
+	"FLUSH_TABLES":                 {since: mysqlSince("8.0.23")},
+	"FLUSH_USER_RESOURCES":         {since: mysqlSince("8.0.23")},
+	"GROUP_REPLICATION_ADMIN":      {since: mysqlSince("8.0.0")},
+	"GROUP_REPLICATION_STREAM":     {since: mysqlSince("8.0.26")},
+	"INNODB_REDO_LOG_ARCHIVE":      {since: mysqlSince("8.0.17")},
+	"INNODB_REDO_LOG_ENABLE":       {since: mysqlSince("8.0.21")},
+	"NDB_STORED_USER":              {since: mysqlSince("8.0.18")},
+	"OPTIMIZE_LOCAL_TABLE":         {since: mysqlSince("8.2.0")},
+	"PASSWORDLESS_USER_ADMIN":      {since: mysqlSince("8.0.27")},
+	"PERSIST_RO_VARIABLES_ADMIN":   {since: mysqlSince("8.0.0")},
+	"PLACEMENT_ADMIN":              {since: map[Flavor]string{FlavorTiDB: ""}},
+	"REPLICATION_APPLIER":          {since: mysqlSince("8.0.18")},
+	"REPLICATION_SLAVE_ADMIN":      {since: mysqlSince("8.0.0")},
+	"RESOURCE_GROUP_ADMIN":         {since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"RESOURCE_GROUP_USER":          {since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"RESTORE_ADMIN":                {since: map[Flavor]string{FlavorTiDB: ""}},
+	"RESTRICTED_CONNECTION_ADMIN":  {since: map[Flavor]string{FlavorTiDB: ""}},
+	"RESTRICTED_STATUS_ADMIN":      {since: map[Flavor]string{FlavorTiDB: ""}},
+	"RESTRICTED_TABLES_ADMIN":      {since: map[Flavor]string{FlavorTiDB: ""}},
+	"RESTRICTED_USER_ADMIN":        {since: map[Flavor]string{FlavorTiDB: ""}},
+	"RESTRICTED_VARIABLES_ADMIN":   {since: map[Flavor]string{FlavorTiDB: ""}},
+	"ROLE_ADMIN":                   {since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"SENSITIVE_VARIABLES_OBSERVER": {since: mysqlSince("8.0.29")},
+	"SERVICE_CONNECTION_ADMIN":     {since: mysqlSince("8.0.14")},
+	"SESSION_VARIABLES_ADMIN":      {since: mysqlSince("8.0.14")},
+	"SET_ANY_DEFINER":              {since: mysqlSince("8.2.0")},
+	"SET_USER_ID":                  {since: mysqlSince("8.0.0")},
+	"SHOW_ROUTINE":                 {since: mysqlSince("8.0.20")},
+	"SKIP_QUERY_REWRITE":           {since: mysqlSince("8.0.31")},
+	"SYSTEM_USER":                  {since: map[Flavor]string{FlavorMySQL: "8.0.16", FlavorTiDB: ""}},
+	"SYSTEM_VARIABLES_ADMIN":       {since: map[Flavor]string{FlavorMySQL: "8.0.0", FlavorTiDB: ""}},
+	"TABLE_ENCRYPTION_ADMIN":       {since: mysqlSince("8.0.16")},
+	"TELEMETRY_LOG_ADMIN":          {since: mysqlSince("8.0.30")},
+	"TP_CONNECTION_ADMIN":          {since: mysqlSince("8.0.31")},
+	"TRANSACTION_GTID_TAG":         {since: mysqlSince("8.3.0")},
+	"VERSION_TOKEN_ADMIN":          {since: mysqlSince("8.0.0")},
+	"XA_RECOVER_ADMIN":             {since: mysqlSince("8.0.19")},
+}
+
+// privilegeAliases maps the other names of privileges to the canonical
+// names, which every flavor having the privilege accepts.
+var privilegeAliases = map[string]string{
+	"ALL":                 "ALL PRIVILEGES",
+	"BINLOG MONITOR":      "REPLICATION CLIENT",
+	"REPLICA MONITOR":     "SLAVE MONITOR",
+	"REPLICATION REPLICA": "REPLICATION SLAVE",
+}
+
+// normalizePrivilege returns the canonical name of privType, e.g. `ALL
+// PRIVILEGES` for `all` and `PROCESS` for `Process`.
+func normalizePrivilege(privType string) string {
+	name := strings.ToUpper(strings.Join(strings.Fields(privType), " "))
+	if alias, ok := privilegeAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// lookupPrivilege returns the catalog entry of privType. ok is false for
+// privileges unknown to the catalog, e.g. typos or dynamic privileges of
+// third party plugins.
+func lookupPrivilege(privType string) (info privilegeInfo, ok bool) {
+	name := normalizePrivilege(privType)
+	if isDynamicPrivilege(name) {
+		info, ok = dynamicPrivileges[name]
+		info.levels = grantLevelGlobal
+		return info, ok
+	}
+	info, ok = staticPrivileges[name]
+	return info, ok
+}
+
+// supportedBy returns an error unless the server of flavor and v has the
+// privilege.
+func (p privilegeInfo) supportedBy(privType string, flavor Flavor, v *version.Version) error {
+	if p.since == nil {
+		return nil
+	}
+	if flavor == FlavorPercona {
+		flavor = FlavorMySQL
+	}
+	since, ok := p.since[flavor]
+	if !ok {
+		return fmt.Errorf("%s is not a privilege of %s", privType, flavor)
+	}
+	if since != "" && v.Core().LessThan(version.Must(version.NewVersion(since))) {
+		return fmt.Errorf("%s requires %s %s or later", privType, flavor, since)
+	}
+	return nil
+}
+
+// queryPrivileges returns the canonical names of the privileges which the
+// server has, including the dynamic privileges registered by plugins and
+// components. INFORMATION_SCHEMA has no table of them, so `SHOW PRIVILEGES`
+// is used.
+func queryPrivileges(ctx context.Context, conn *OneConnection) (map[string]bool, error) {
+	rows, err := conn.query(ctx, sqlStatement{query: "SHOW PRIVILEGES"})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	privileges := map[string]bool{}
+	for rows.Next() {
+		var name, privContext, comment string
+		if err := rows.Scan(&name, &privContext, &comment); err != nil {
+			return nil, err
+		}
+		privileges[normalizePrivilege(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return privileges, nil
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestNormalizePrivilege(t *testing.T) {
+	cases := map[string]string{
+		"ALL":                    "ALL PRIVILEGES",
+		"all privileges":         "ALL PRIVILEGES",
+		"Process":                "PROCESS",
+		"Show  databases":        "SHOW DATABASES",
+		"REPLICATION REPLICA":    "REPLICATION SLAVE",
+		"Binlog monitor":         "REPLICATION CLIENT",
+		"REPLICA MONITOR":        "SLAVE MONITOR",
+		"system_variables_admin": "SYSTEM_VARIABLES_ADMIN",
+	}
+	for privType, expected := range cases {
+		if got := normalizePrivilege(privType); got != expected {
+			t.Errorf("normalizePrivilege(%q) = %q, want %q", privType, got, expected)
+		}
+	}
+}
+
+func TestCheckPrivilege(t *testing.T) {
+	cases := []struct {
+		privType   string
+		hasColumns bool
+		level      grantLevel
+		expected   string
+	}{
+		{"SELECT", false, grantLevelTable, ""},
+		{"SELECT", true, grantLevelTable, ""},
+		{"ALL", false, grantLevelRoutine, ""},
+		{"EXECUTE", false, grantLevelRoutine, ""},
+		{"BACKUP_ADMIN", false, grantLevelGlobal, ""},
+		{"THIRD_PARTY_ADMIN", false, grantLevelGlobal, ""},
+		{"PROCESS", false, 0, ""},
+		{"SELEC", false, grantLevelTable, "SELEC is not a privilege"},
+		{"PROCESS", false, grantLevelDatabase, "PROCESS cannot be granted at the database level"},
+		{"SELECT", false, grantLevelRoutine, "SELECT cannot be granted at the routine level"},
+		{"EXECUTE", false, grantLevelTable, "EXECUTE cannot be granted at the table level"},
+		{"BACKUP_ADMIN", false, grantLevelDatabase, "BACKUP_ADMIN cannot be granted at the database level"},
+		{"THIRD_PARTY_ADMIN", false, grantLevelTable, "THIRD_PARTY_ADMIN cannot be granted at the table level"},
+		{"DELETE", true, grantLevelTable, "DELETE cannot be granted on columns"},
+		{"SELECT", true, grantLevelDatabase, "columns of SELECT can be granted only on a table, not at the database level"},
+	}
+	for _, c := range cases {
+		err := checkPrivilege(c.privType, c.hasColumns, c.level)
+		switch {
+		case c.expected == "" && err != nil:
+			t.Errorf("checkPrivilege(%q, %v, %s): unexpected error: %v", c.privType, c.hasColumns, c.level, err)
+		case c.expected != "" && (err == nil || err.Error() != c.expected):
+			t.Errorf("checkPrivilege(%q, %v, %s) = %v, want %q", c.privType, c.hasColumns, c.level, err, c.expected)
+		}
+	}
+}
+
+func TestPrivilegeSupportedBy(t *testing.T) {
+	cases := []struct {
+		privType string
+		flavor   Flavor
+		version  string
+		expected string
+	}{
+		{"SELECT", FlavorMariaDB, "10.0.4", ""},
+		{"SYSTEM_USER", FlavorMySQL, "8.0.16", ""},
+		{"SYSTEM_USER", FlavorPercona, "8.0.35-27", ""},
+		{"SYSTEM_USER", FlavorTiDB, "8.0.11-TiDB-v7.5.0", ""},
+		{"SYSTEM_USER", FlavorMySQL, "8.0.15", "SYSTEM_USER requires MySQL 8.0.16 or later"},
+		{"FLUSH_PRIVILEGES", FlavorMySQL, "8.0.36", "FLUSH_PRIVILEGES requires MySQL 8.4.0 or later"},
+		{"RESTORE_ADMIN", FlavorMySQL, "8.0.36", "RESTORE_ADMIN is not a privilege of MySQL"},
+		{"BINLOG ADMIN", FlavorMariaDB, "10.11.6-MariaDB-log", ""},
+		{"BINLOG ADMIN", FlavorMariaDB, "10.4.32-MariaDB", "BINLOG ADMIN requires MariaDB 10.5.2 or later"},
+		{"CREATE ROLE", FlavorMariaDB, "10.11.6-MariaDB-log", "CREATE ROLE is not a privilege of MariaDB"},
+	}
+	for _, c := range cases {
+		info, ok := lookupPrivilege(c.privType)
+		if !ok {
+			t.Fatalf("%s is not in the catalog", c.privType)
+		}
+		v, err := parseServerVersion(c.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = info.supportedBy(c.privType, c.flavor, v)
+		switch {
+		case c.expected == "" && err != nil:
+			t.Errorf("%s on %s %s: unexpected error: %v", c.privType, c.flavor, c.version, err)
+		case c.expected != "" && (err == nil || err.Error() != c.expected):
+			t.Errorf("%s on %s %s = %v, want %q", c.privType, c.flavor, c.version, err, c.expected)
+		}
+	}
+}
+
+func TestQueryPrivileges(t *testing.T) {
+	conf := testMySQLConfig()
+	conf.Config.User = "query_privileges"
+	conn := testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		return &fakeRows{
+			columns: []string{"Privilege", "Context", "Comment"},
+			values: [][]driver.Value{
+				{"Alter", "Tables", "To alter the table"},
+				{"Show databases", "Server Admin", "To see all databases with SHOW DATABASES"},
+				{"THIRD_PARTY_ADMIN", "Server Admin", ""},
+			},
+		}, nil
+	})
+
+	privileges, err := queryPrivileges(t.Context(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ALTER", "SHOW DATABASES", "THIRD_PARTY_ADMIN"} {
+		if !privileges[name] {
+			t.Errorf("%s is not read: %v", name, privileges)
+		}
+	}
+	if len(privileges) != 3 {
+		t.Errorf("unexpected privileges: %v", privileges)
+	}
+}
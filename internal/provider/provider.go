@@ -2,12 +2,15 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,23 +18,36 @@ import (
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/providervalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 
+	"github.com/okkez/terraform-provider-mysql/internal/utils"
+
 	"golang.org/x/net/proxy"
 )
 
 // Ensure mysqlProvider satisfies various provider interfaces.
-var _ provider.Provider = &mysqlProvider{}
+var (
+	_ provider.Provider                       = &mysqlProvider{}
+	_ provider.ProviderWithConfigValidators   = &mysqlProvider{}
+	_ provider.ProviderWithEphemeralResources = &mysqlProvider{}
+)
 
 // mysqlProvider defines the provider implementation.
 type mysqlProvider struct {
@@ -47,18 +63,88 @@ type mysqlProviderModel struct {
 	Username types.String `tfsdk:"username"`
 	Password types.String `tfsdk:"password"`
 	Proxy    types.String `tfsdk:"proxy"`
+	TLS      types.Object `tfsdk:"tls"`
+
+	SSHTunnel types.Object `tfsdk:"ssh_tunnel"`
+
+	PasswordFile    types.String `tfsdk:"password_file"`
+	PasswordCommand types.List   `tfsdk:"password_command"`
+
+	AWSRDSIAMAuth      types.Object `tfsdk:"aws_rds_iam_auth"`
+	GCPCloudSQLIAMAuth types.Object `tfsdk:"gcp_cloudsql_iam_auth"`
+	AzureEntraIDAuth   types.Object `tfsdk:"azure_entra_id_auth"`
+
+	MaxConnLifetime       types.String `tfsdk:"max_conn_lifetime"`
+	MaxConnIdleTime       types.String `tfsdk:"max_conn_idle_time"`
+	MaxOpenConns          types.Int64  `tfsdk:"max_open_conns"`
+	MaxIdleConns          types.Int64  `tfsdk:"max_idle_conns"`
+	ConnectRetryTimeout   types.String `tfsdk:"connect_retry_timeout"`
+	DialTimeout           types.String `tfsdk:"dial_timeout"`
+	ReadTimeout           types.String `tfsdk:"read_timeout"`
+	WriteTimeout          types.String `tfsdk:"write_timeout"`
+	StatementTimeout      types.String `tfsdk:"statement_timeout"`
+	StatementRetryTimeout types.String `tfsdk:"statement_retry_timeout"`
+
+	ConnectionParams types.Map  `tfsdk:"connection_params"`
+	InitStatements   types.List `tfsdk:"init_statements"`
+
+	StatementLog types.String `tfsdk:"statement_log"`
+	DryRun       types.Bool   `tfsdk:"dry_run"`
+
+	VerifyPrivileges types.Bool `tfsdk:"verify_privileges"`
 }
 
 type OneConnection struct {
-	Db      *sql.DB
-	Version *version.Version
+	Db           *sql.DB
+	Version      *version.Version
+	Flavor       Flavor
+	Capabilities Capabilities
+	// ANSIQuotes is true when the sql_mode of the sessions contains
+	// ANSI_QUOTES.
+	ANSIQuotes bool
+	// NoBackslashEscapes is true when the sql_mode of the sessions contains
+	// NO_BACKSLASH_ESCAPES.
+	NoBackslashEscapes bool
+	// StatementLog is the file which the statements changing the server are
+	// appended to.
+	StatementLog string
+	// DryRun is true when the statements changing the server are logged but
+	// not run.
+	DryRun bool
+	// StatementRetryTimeout is how long statements failing with transient
+	// errors are retried.
+	StatementRetryTimeout time.Duration
 }
 
 type MySQLConfiguration struct {
-	Config              *mysql.Config
-	MaxConnLifetime     time.Duration
-	MaxOpenConns        int
-	ConnectRetryTimeout time.Duration
+	Config                *mysql.Config
+	MaxConnLifetime       time.Duration
+	MaxConnIdleTime       time.Duration
+	MaxOpenConns          int
+	MaxIdleConns          int
+	ConnectRetryTimeout   time.Duration
+	StatementTimeout      time.Duration
+	StatementRetryTimeout time.Duration
+	InitStatements        []string
+	StatementLog          string
+	DryRun                bool
+	// TokenAuth is the configuration of the token authentication block, so
+	// that providers authenticating differently do not share a connection.
+	TokenAuth string
+	// VerifyPrivileges is true when the dynamic privileges of
+	// mysql_grant_privilege are checked against `SHOW PRIVILEGES` while
+	// planning.
+	VerifyPrivileges bool
+	// Unknown is set when the provider configuration contains values unknown
+	// until apply. Resources must not connect to the server then.
+	Unknown bool
+}
+
+// cacheKey returns the key of connectionCache. It contains the settings
+// applied by the connector in addition to the DSN.
+func (c *MySQLConfiguration) cacheKey() string {
+	settings := []string{c.Config.FormatDSN(), c.TokenAuth, c.StatementTimeout.String(), c.StatementRetryTimeout.String(), c.StatementLog, strconv.FormatBool(c.DryRun)}
+	return registrationName("connection", append(settings, c.InitStatements...)...)
 }
 
 var (
@@ -87,7 +173,9 @@ func (p *mysqlProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 			"endpoint": schema.StringAttribute{
 				MarkdownDescription: "The address of the MySQL server to use. " +
 					"Most often a `hostname:port` pair, but may also be an absolute path to a Unix socket when the host OS is Unix-compatible. " +
-					"Can also be sourced from the `MYSQL_ENDPOINT` environment variable.",
+					"`password` is optional and `proxy` is ignored when connecting through a Unix socket. " +
+					"Can also be sourced from the `MYSQL_ENDPOINT` environment variable. " +
+					"May be unknown while planning, e.g. when the server is created in the same apply; resources then keep their state without connecting.",
 				Optional: true,
 			},
 			"username": schema.StringAttribute{
@@ -97,10 +185,29 @@ func (p *mysqlProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 			},
 			"password": schema.StringAttribute{
 				MarkdownDescription: "Password for the given user, if that user has a password, " +
-					"can also be sourced from the `MYSQL_PASSWORD` environment variable.",
+					"can also be sourced from the `MYSQL_PASSWORD` environment variable. " +
+					"`MYSQL_PASSWORD` conflicts with `MYSQL_PASSWORD_FILE`, and is ignored when `password_file` or `password_command` is set.",
 				Optional:  true,
 				Sensitive: true,
 			},
+			"password_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file containing the password. A trailing newline is ignored. " +
+					"Conflicts with `password` and `password_command`. " +
+					"Can also be sourced from the `MYSQL_PASSWORD_FILE` environment variable, " +
+					"which conflicts with `MYSQL_PASSWORD` and is ignored when `password` or `password_command` is set.",
+				Optional: true,
+			},
+			"password_command": schema.ListAttribute{
+				MarkdownDescription: "A command and its arguments to run to get the password, " +
+					"e.g. `[\"vault\", \"kv\", \"get\", \"-field=password\", \"secret/mysql\"]`. " +
+					"The command is run without a shell, and its standard output, without a trailing newline, is used as the password. " +
+					"Conflicts with `password` and `password_file`.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+			},
 			"proxy": schema.StringAttribute{
 				MarkdownDescription: "Proxy socks url, can also be sourced from `ALL_PROXY` or `all_proxy` environment variables.",
 				Optional:            true,
@@ -110,10 +217,274 @@ func (p *mysqlProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 						"The proxy URL is not a valid socks URL."),
 				},
 			},
+			"max_conn_lifetime": schema.StringAttribute{
+				MarkdownDescription: "The maximum amount of time a connection may be reused, e.g. `8h`. Defaults to `8h`. " +
+					"Can also be sourced from the `MYSQL_MAX_CONN_LIFETIME` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"max_conn_idle_time": schema.StringAttribute{
+				MarkdownDescription: "The maximum amount of time a connection may be idle before being closed, e.g. `10m`. " +
+					"Idle connections are not closed due to idle time by default. " +
+					"Can also be sourced from the `MYSQL_MAX_CONN_IDLE_TIME` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"max_open_conns": schema.Int64Attribute{
+				MarkdownDescription: "The maximum number of open connections to the server. Defaults to `5`. " +
+					"Can also be sourced from the `MYSQL_MAX_OPEN_CONNS` environment variable.",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"max_idle_conns": schema.Int64Attribute{
+				MarkdownDescription: "The maximum number of connections in the idle connection pool. Defaults to `2`. " +
+					"Can also be sourced from the `MYSQL_MAX_IDLE_CONNS` environment variable.",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(0)},
+			},
+			"connect_retry_timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to keep retrying the initial connection to the server, e.g. `30s`. Defaults to `5m`. " +
+					"Can also be sourced from the `MYSQL_CONNECT_RETRY_TIMEOUT` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"dial_timeout": schema.StringAttribute{
+				MarkdownDescription: "Timeout for establishing a connection, e.g. `10s`. Defaults to the OS default. " +
+					"Can also be sourced from the `MYSQL_DIAL_TIMEOUT` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"read_timeout": schema.StringAttribute{
+				MarkdownDescription: "I/O read timeout, e.g. `30s`. No timeout by default. " +
+					"Can also be sourced from the `MYSQL_READ_TIMEOUT` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"write_timeout": schema.StringAttribute{
+				MarkdownDescription: "I/O write timeout, e.g. `30s`. No timeout by default. " +
+					"Can also be sourced from the `MYSQL_WRITE_TIMEOUT` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"statement_timeout": schema.StringAttribute{
+				MarkdownDescription: "The maximum amount of time a single SQL statement may run, e.g. `1m`. No timeout by default. " +
+					"Can also be sourced from the `MYSQL_STATEMENT_TIMEOUT` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"statement_retry_timeout": schema.StringAttribute{
+				MarkdownDescription: "How long statements failing with transient errors, e.g. deadlocks, lock wait timeouts or a read only server during a failover, " +
+					"are retried with exponential backoff, e.g. `1m`. `0s` disables the retries. Defaults to `30s`. " +
+					"Can also be sourced from the `MYSQL_STATEMENT_RETRY_TIMEOUT` environment variable.",
+				Optional:   true,
+				Validators: []validator.String{utils.DurationValidator()},
+			},
+			"connection_params": schema.MapAttribute{
+				MarkdownDescription: "Additional [DSN parameters](https://github.com/go-sql-driver/mysql#parameters) of the driver, " +
+					"e.g. `charset`, `collation` or `loc`. Parameters unknown to the driver are set as session system variables, " +
+					"so string values of them must be quoted, e.g. `time_zone = \"'+00:00'\"`. " +
+					"`tls`, `timeout`, `readTimeout` and `writeTimeout` must be set by the dedicated attributes.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"init_statements": schema.ListAttribute{
+				MarkdownDescription: "SQL statements to run on every new connection, e.g. `SET SESSION sql_log_bin=0`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"statement_log": schema.StringAttribute{
+				MarkdownDescription: "The file which every statement changing the server is appended to, with the arguments bound and passwords redacted. " +
+					"Can also be sourced from the `MYSQL_STATEMENT_LOG` environment variable.",
+				Optional: true,
+			},
+			"dry_run": schema.BoolAttribute{
+				MarkdownDescription: "Log the statements changing the server without running them. " +
+					"The state is saved as if the statements ran, so use it with a copy of the state or refresh the state afterwards. " +
+					"Can also be sourced from the `MYSQL_DRY_RUN` environment variable. Defaults to `false`.",
+				Optional: true,
+			},
+			"verify_privileges": schema.BoolAttribute{
+				MarkdownDescription: "Check the dynamic privileges of `mysql_grant_privilege` against the privileges registered on the server while planning, " +
+					"including the ones provided by plugins and components. The privileges are read with `SHOW PRIVILEGES`, since `INFORMATION_SCHEMA` has no table of them. " +
+					"Otherwise dynamic privileges unknown to the provider are warned about. " +
+					"Can also be sourced from the `MYSQL_VERIFY_PRIVILEGES` environment variable. Defaults to `false`.",
+				Optional: true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"tls": schema.SingleNestedBlock{
+				MarkdownDescription: "TLS configuration for the connection to the server. " +
+					"TLS is disabled if this block is omitted.",
+				// Required attributes of a block are validated even if the block is omitted.
+				Validators: []validator.Object{
+					objectvalidator.AlsoRequires(path.MatchRelative().AtName("mode")),
+				},
+				Attributes: map[string]schema.Attribute{
+					"mode": schema.StringAttribute{
+						MarkdownDescription: "The TLS mode. One of `disabled`, `preferred`, `required`, `verify-ca` or `verify-full`. " +
+							"`preferred` falls back to an unencrypted connection if the server does not support TLS. " +
+							"`required` does not verify the server certificate unless `ca_cert` is given. " +
+							"`verify-ca` verifies the server certificate but not the host name. " +
+							"`verify-full` verifies the server certificate and the host name. Required in this block.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf(tlsModes...),
+						},
+					},
+					"ca_cert": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded CA certificates, or a path to a file containing them. " +
+							"The system certificate pool is used if omitted.",
+						Optional: true,
+					},
+					"client_cert": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded client certificate, or a path to a file containing it. Requires `client_key`.",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("client_key")),
+						},
+					},
+					"client_key": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded client private key, or a path to a file containing it. Requires `client_cert`.",
+						Optional:            true,
+						Sensitive:           true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("client_cert")),
+						},
+					},
+					"server_name": schema.StringAttribute{
+						MarkdownDescription: "The host name used to verify the server certificate in `verify-full` mode. " +
+							"Defaults to the host of `endpoint`.",
+						Optional: true,
+					},
+				},
+			},
+			"aws_rds_iam_auth": schema.SingleNestedBlock{
+				MarkdownDescription: "Authenticate with an [RDS IAM authentication token](https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/UsingWithRDS.IAMDBAuth.html) instead of `password`. " +
+					"The token is signed with the credentials in the `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` and `AWS_SESSION_TOKEN` environment variables, " +
+					"and is refreshed whenever a new connection is opened after it has expired. " +
+					"TLS is required; the connection uses the `required` TLS mode if the `tls` block is omitted.",
+				Attributes: map[string]schema.Attribute{
+					"region": schema.StringAttribute{
+						MarkdownDescription: "The AWS region of the RDS instance. " +
+							"Can also be sourced from the `AWS_REGION` or `AWS_DEFAULT_REGION` environment variables.",
+						Optional: true,
+					},
+				},
+			},
+			"ssh_tunnel": schema.SingleNestedBlock{
+				MarkdownDescription: "Connect to the server through an SSH tunnel. Conflicts with `proxy`.",
+				Validators: []validator.Object{
+					objectvalidator.AlsoRequires(
+						path.MatchRelative().AtName("host"),
+						path.MatchRelative().AtName("user"),
+					),
+				},
+				Attributes: map[string]schema.Attribute{
+					"host": schema.StringAttribute{
+						MarkdownDescription: "The SSH server as a `host:port` pair. The port defaults to `22`. Required in this block.",
+						Optional:            true,
+					},
+					"user": schema.StringAttribute{
+						MarkdownDescription: "The user to log in to the SSH server. Required in this block.",
+						Optional:            true,
+					},
+					"private_key": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded private key, or a path to a file containing it.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"private_key_passphrase": schema.StringAttribute{
+						MarkdownDescription: "The passphrase of `private_key`.",
+						Optional:            true,
+						Sensitive:           true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("private_key")),
+						},
+					},
+					"use_agent": schema.BoolAttribute{
+						MarkdownDescription: "Whether to authenticate with the SSH agent listening on `SSH_AUTH_SOCK`. " +
+							"Defaults to `true` if `private_key` is omitted.",
+						Optional: true,
+					},
+					"known_hosts_file": schema.StringAttribute{
+						MarkdownDescription: "Path to the known_hosts file to verify the host keys of the SSH servers. " +
+							"Defaults to `~/.ssh/known_hosts`.",
+						Optional: true,
+					},
+					"insecure_ignore_host_key": schema.BoolAttribute{
+						MarkdownDescription: "Do not verify the host keys of the SSH servers. Conflicts with `known_hosts_file`.",
+						Optional:            true,
+						Validators: []validator.Bool{
+							boolvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("known_hosts_file")),
+						},
+					},
+					"jump_hosts": schema.ListAttribute{
+						MarkdownDescription: "SSH servers to connect through in order before `host`, as `[user@]host[:port]`. " +
+							"The user defaults to `user`, and the same credentials are used for all of them.",
+						ElementType: types.StringType,
+						Optional:    true,
+					},
+				},
+			},
+			"gcp_cloudsql_iam_auth": schema.SingleNestedBlock{
+				MarkdownDescription: "Authenticate with [Cloud SQL IAM database authentication](https://cloud.google.com/sql/docs/mysql/iam-authentication) instead of `password`. " +
+					"Unless `access_token` is given, access tokens of the service account attached to the instance are issued by the metadata server, " +
+					"and are refreshed whenever a new connection is opened after they have expired. " +
+					"TLS is required; the connection uses the `required` TLS mode if the `tls` block is omitted.",
+				Attributes: map[string]schema.Attribute{
+					"access_token": schema.StringAttribute{
+						MarkdownDescription: "An OAuth 2.0 access token, e.g. the output of `gcloud auth print-access-token`. " +
+							"Can also be sourced from the `GOOGLE_OAUTH_ACCESS_TOKEN` environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+				},
+			},
+			"azure_entra_id_auth": schema.SingleNestedBlock{
+				MarkdownDescription: "Authenticate to Azure Database for MySQL with a [Microsoft Entra ID](https://learn.microsoft.com/azure/mysql/flexible-server/concepts-azure-ad-authentication) access token instead of `password`. " +
+					"The token is issued for the service principal if `client_secret` is given, otherwise for the managed identity of the host, " +
+					"and is refreshed whenever a new connection is opened after it has expired. " +
+					"TLS is required; the connection uses the `required` TLS mode if the `tls` block is omitted.",
+				Attributes: map[string]schema.Attribute{
+					"tenant_id": schema.StringAttribute{
+						MarkdownDescription: "The tenant ID of the service principal. " +
+							"Can also be sourced from the `AZURE_TENANT_ID` environment variable.",
+						Optional: true,
+					},
+					"client_id": schema.StringAttribute{
+						MarkdownDescription: "The client ID of the service principal, or of the user-assigned managed identity. " +
+							"Can also be sourced from the `AZURE_CLIENT_ID` environment variable.",
+						Optional: true,
+					},
+					"client_secret": schema.StringAttribute{
+						MarkdownDescription: "The client secret of the service principal. " +
+							"Can also be sourced from the `AZURE_CLIENT_SECRET` environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+				},
+			},
 		},
 	}
 }
 
+func (p *mysqlProvider) ConfigValidators(ctx context.Context) []provider.ConfigValidator {
+	return []provider.ConfigValidator{
+		providervalidator.Conflicting(
+			path.MatchRoot("proxy"),
+			path.MatchRoot("ssh_tunnel"),
+		),
+		providervalidator.Conflicting(
+			path.MatchRoot("password"),
+			path.MatchRoot("password_file"),
+			path.MatchRoot("password_command"),
+			path.MatchRoot("aws_rds_iam_auth"),
+			path.MatchRoot("gcp_cloudsql_iam_auth"),
+			path.MatchRoot("azure_entra_id_auth"),
+		),
+	}
+}
+
 func (p *mysqlProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var data mysqlProviderModel
 
@@ -123,17 +494,18 @@ func (p *mysqlProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		return
 	}
 
-	if data.Endpoint.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(path.Root("endpoint"), "Unknown MySQL endpoint", "")
-	}
-	if data.Username.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(path.Root("username"), "Unknown MySQL username", "")
-	}
-	if data.Password.IsUnknown() {
-		resp.Diagnostics.AddAttributeError(path.Root("password"), "Unknown MySQL password", "")
-	}
-
-	if resp.Diagnostics.HasError() {
+	// The configuration may refer to a server created in the same apply, in
+	// which case its endpoint is unknown while planning. Do not fail then;
+	// resources do not connect until the configuration becomes known.
+	if !req.Config.Raw.IsFullyKnown() {
+		tflog.Info(ctx, "MySQL provider configuration is unknown, connections are deferred")
+		if req.ClientCapabilities.DeferralAllowed {
+			resp.Deferred = &provider.Deferred{Reason: provider.DeferredReasonProviderConfigUnknown}
+		}
+		unknownConf := &MySQLConfiguration{Unknown: true}
+		resp.DataSourceData = unknownConf
+		resp.ResourceData = unknownConf
+		resp.EphemeralResourceData = unknownConf
 		return
 	}
 
@@ -152,8 +524,42 @@ func (p *mysqlProvider) Configure(ctx context.Context, req provider.ConfigureReq
 	if !data.Username.IsNull() {
 		username = data.Username.ValueString()
 	}
-	if !data.Password.IsNull() {
+	passwordFile := os.Getenv("MYSQL_PASSWORD_FILE")
+	// The attributes conflict with each other by ConfigValidators, and the
+	// environment variables do too when both of them would be used.
+	if len(password) > 0 && len(passwordFile) > 0 && data.Password.IsNull() && data.PasswordFile.IsNull() && data.PasswordCommand.IsNull() {
+		resp.Diagnostics.AddError(
+			"Conflicting password sources",
+			"The MYSQL_PASSWORD and MYSQL_PASSWORD_FILE environment variables must not be set together. "+
+				"Unset one of them, or set `password`, `password_file` or `password_command` in the provider configuration.",
+		)
+		return
+	}
+	if !data.PasswordFile.IsNull() {
+		passwordFile = data.PasswordFile.ValueString()
+	}
+	switch {
+	case !data.Password.IsNull():
 		password = data.Password.ValueString()
+	case !data.PasswordCommand.IsNull():
+		var command []string
+		resp.Diagnostics.Append(data.PasswordCommand.ElementsAs(ctx, &command, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		output, err := runPasswordCommand(ctx, command)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("password_command"), "Failed running password command", err.Error())
+			return
+		}
+		password = output
+	case len(passwordFile) > 0:
+		content, err := readPasswordFile(passwordFile)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("password_file"), "Failed reading password file", err.Error())
+			return
+		}
+		password = content
 	}
 	if !data.Proxy.IsNull() {
 		proxy = data.Proxy.ValueString()
@@ -173,11 +579,41 @@ func (p *mysqlProvider) Configure(ctx context.Context, req provider.ConfigureReq
 			`You must set provider configuration by provider "mysql" block or environment variable "MYSQL_USERNAME"`,
 		)
 	}
-	if len(password) == 0 {
+	if !data.SSHTunnel.IsNull() {
+		if isUnixSocket(endpoint) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ssh_tunnel"),
+				"Invalid configuration",
+				"SSH tunnel cannot be used with a Unix socket endpoint",
+			)
+		}
+	}
+	// authBlock is the name of the block configuring token authentication.
+	// ConfigValidators ensure that at most one of them is set.
+	var authBlock string
+	for name, block := range map[string]types.Object{
+		"aws_rds_iam_auth":      data.AWSRDSIAMAuth,
+		"gcp_cloudsql_iam_auth": data.GCPCloudSQLIAMAuth,
+		"azure_entra_id_auth":   data.AzureEntraIDAuth,
+	} {
+		if block.IsNull() {
+			continue
+		}
+		authBlock = name
+		if isUnixSocket(endpoint) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Invalid configuration",
+				"Token authentication cannot be used with a Unix socket endpoint",
+			)
+		}
+	}
+	// Users authenticated with auth_socket or tokens have no password.
+	if len(password) == 0 && !isUnixSocket(endpoint) && len(authBlock) == 0 {
 		resp.Diagnostics.AddAttributeError(
-			path.Root("endpoint"),
+			path.Root("password"),
 			"Missing required configuration",
-			`You must set provider configuration by provider "mysql" block or environment variable "MYSQL_PASSWORD"`,
+			`You must set provider configuration by provider "mysql" block or environment variable "MYSQL_PASSWORD" or "MYSQL_PASSWORD_FILE"`,
 		)
 	}
 	if resp.Diagnostics.HasError() {
@@ -203,29 +639,201 @@ func (p *mysqlProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		Params:                  map[string]string{},
 	}
 
-	dialer, err := makeDialer(proxy)
-	if err != nil {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("proxy"),
-			"Failed making dialer",
-			fmt.Sprintf("%v", err),
-		)
-		return
+	if !data.TLS.IsNull() {
+		var tlsData tlsModel
+		resp.Diagnostics.Append(data.TLS.As(ctx, &tlsData, basetypes.ObjectAsOptions{})...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := configureTLS(&conf, tlsData); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("tls"),
+				"Failed configuring TLS",
+				err.Error(),
+			)
+			return
+		}
 	}
 
-	mysql.RegisterDialContext("tcp", func(ctx context.Context, network string) (net.Conn, error) {
-		return dialer.Dial("tcp", network)
-	})
+	switch {
+	case isUnixSocket(endpoint):
+		if len(proxy) > 0 {
+			tflog.Warn(ctx, "Ignoring proxy for the Unix socket endpoint", map[string]any{"proxy": proxy})
+		}
+		conf.Net = "unix"
+	case !data.SSHTunnel.IsNull():
+		var sshData sshTunnelModel
+		resp.Diagnostics.Append(data.SSHTunnel.As(ctx, &sshData, basetypes.ObjectAsOptions{})...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		tunnel, settings, err := makeSSHTunnel(sshData)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ssh_tunnel"),
+				"Failed making SSH tunnel",
+				err.Error(),
+			)
+			return
+		}
+		conf.Net = registerDialer(tunnel, append([]string{"ssh"}, settings...)...)
+	default:
+		dialer, err := makeDialer(proxy)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("proxy"),
+				"Failed making dialer",
+				fmt.Sprintf("%v", err),
+			)
+			return
+		}
+
+		conf.Net = registerDialer(dialer, proxy)
+	}
+	if conf.Net != "unix" {
+		// The driver adds the default port only when Net is "tcp".
+		conf.Addr = ensurePort(conf.Addr)
+	}
+
+	if !data.ConnectionParams.IsNull() {
+		var params map[string]string
+		resp.Diagnostics.Append(data.ConnectionParams.ElementsAs(ctx, &params, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := applyConnectionParams(&conf, params); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("connection_params"),
+				"Invalid connection parameters",
+				err.Error(),
+			)
+			return
+		}
+	}
+
+	var tokenAuth string
+	if len(authBlock) > 0 {
+		var source tokenSource
+		var err error
+		switch authBlock {
+		case "aws_rds_iam_auth":
+			var authData awsRDSIAMAuthModel
+			resp.Diagnostics.Append(data.AWSRDSIAMAuth.As(ctx, &authData, basetypes.ObjectAsOptions{})...)
+			source, err = newRDSIAMTokenSource(conf.Addr, conf.User, authData)
+			tokenAuth = authBlock + data.AWSRDSIAMAuth.String()
+		case "gcp_cloudsql_iam_auth":
+			var authData gcpCloudSQLIAMAuthModel
+			resp.Diagnostics.Append(data.GCPCloudSQLIAMAuth.As(ctx, &authData, basetypes.ObjectAsOptions{})...)
+			source = newGCPCloudSQLIAMTokenSource(authData)
+			tokenAuth = authBlock + data.GCPCloudSQLIAMAuth.String()
+		case "azure_entra_id_auth":
+			var authData azureEntraIDAuthModel
+			resp.Diagnostics.Append(data.AzureEntraIDAuth.As(ctx, &authData, basetypes.ObjectAsOptions{})...)
+			source, err = newAzureEntraIDTokenSource(authData)
+			tokenAuth = authBlock + data.AzureEntraIDAuth.String()
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(authBlock), "Failed configuring authentication", err.Error())
+			return
+		}
+
+		// The token is sent with the cleartext plugin, so it must be encrypted.
+		if data.TLS.IsNull() {
+			if err := configureTLS(&conf, tlsModel{Mode: types.StringValue(tlsModeRequired)}); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("tls"), "Failed configuring TLS", err.Error())
+				return
+			}
+		} else if conf.TLSConfig == "false" || conf.AllowFallbackToPlaintext {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("tls").AtName("mode"),
+				"Invalid configuration",
+				fmt.Sprintf("%s requires TLS mode `required`, `verify-ca` or `verify-full`", authBlock),
+			)
+			return
+		}
+		if err := configureTokenAuth(&conf, source); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(authBlock), "Failed configuring authentication", err.Error())
+			return
+		}
+	}
 
 	mysqlConf := &MySQLConfiguration{
-		Config:              &conf,
-		MaxConnLifetime:     time.Duration(8*60*60) * time.Second,
-		MaxOpenConns:        5,
-		ConnectRetryTimeout: time.Duration(300) * time.Second,
+		Config:       &conf,
+		TokenAuth:    tokenAuth,
+		StatementLog: stringWithEnv(data.StatementLog, "MYSQL_STATEMENT_LOG"),
+	}
+	dryRun, err := boolSetting(data.DryRun, "MYSQL_DRY_RUN")
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("dry_run"), "Invalid boolean", err.Error())
+	}
+	mysqlConf.DryRun = dryRun
+	if dryRun {
+		resp.Diagnostics.AddWarning("Dry run mode",
+			"The statements changing the server are logged but not run. The state is saved as if they ran.")
+	}
+	verifyPrivileges, err := boolSetting(data.VerifyPrivileges, "MYSQL_VERIFY_PRIVILEGES")
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("verify_privileges"), "Invalid boolean", err.Error())
+	}
+	mysqlConf.VerifyPrivileges = verifyPrivileges
+	if !data.InitStatements.IsNull() {
+		resp.Diagnostics.Append(data.InitStatements.ElementsAs(ctx, &mysqlConf.InitStatements, false)...)
+	}
+
+	durationSettings := []struct {
+		name         string
+		value        types.String
+		envKey       string
+		defaultValue time.Duration
+		target       *time.Duration
+	}{
+		{"max_conn_lifetime", data.MaxConnLifetime, "MYSQL_MAX_CONN_LIFETIME", 8 * time.Hour, &mysqlConf.MaxConnLifetime},
+		{"max_conn_idle_time", data.MaxConnIdleTime, "MYSQL_MAX_CONN_IDLE_TIME", 0, &mysqlConf.MaxConnIdleTime},
+		{"connect_retry_timeout", data.ConnectRetryTimeout, "MYSQL_CONNECT_RETRY_TIMEOUT", 5 * time.Minute, &mysqlConf.ConnectRetryTimeout},
+		{"dial_timeout", data.DialTimeout, "MYSQL_DIAL_TIMEOUT", 0, &conf.Timeout},
+		{"read_timeout", data.ReadTimeout, "MYSQL_READ_TIMEOUT", 0, &conf.ReadTimeout},
+		{"write_timeout", data.WriteTimeout, "MYSQL_WRITE_TIMEOUT", 0, &conf.WriteTimeout},
+		{"statement_timeout", data.StatementTimeout, "MYSQL_STATEMENT_TIMEOUT", 0, &mysqlConf.StatementTimeout},
+		{"statement_retry_timeout", data.StatementRetryTimeout, "MYSQL_STATEMENT_RETRY_TIMEOUT", 30 * time.Second, &mysqlConf.StatementRetryTimeout},
+	}
+	for _, setting := range durationSettings {
+		d, err := durationSetting(setting.value, setting.envKey, setting.defaultValue)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(setting.name), "Invalid duration", err.Error())
+			continue
+		}
+		*setting.target = d
+	}
+
+	intSettings := []struct {
+		name         string
+		value        types.Int64
+		envKey       string
+		defaultValue int
+		target       *int
+	}{
+		{"max_open_conns", data.MaxOpenConns, "MYSQL_MAX_OPEN_CONNS", 5, &mysqlConf.MaxOpenConns},
+		{"max_idle_conns", data.MaxIdleConns, "MYSQL_MAX_IDLE_CONNS", 2, &mysqlConf.MaxIdleConns},
+	}
+	for _, setting := range intSettings {
+		i, err := intSetting(setting.value, setting.envKey, setting.defaultValue)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(setting.name), "Invalid number", err.Error())
+			continue
+		}
+		*setting.target = i
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	resp.DataSourceData = mysqlConf
 	resp.ResourceData = mysqlConf
+	resp.EphemeralResourceData = mysqlConf
 }
 
 func (p *mysqlProvider) Resources(ctx context.Context) []func() resource.Resource {
@@ -237,6 +845,7 @@ func (p *mysqlProvider) Resources(ctx context.Context) []func() resource.Resourc
 		NewGlobalVariableResource,
 		NewGrantRoleResource,
 		NewGrantPrivilegeResource,
+		NewGrantsResource,
 	}
 }
 
@@ -247,6 +856,12 @@ func (p *mysqlProvider) DataSources(ctx context.Context) []func() datasource.Dat
 	}
 }
 
+func (p *mysqlProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewRandomPasswordEphemeralResource,
+	}
+}
+
 func New(version string) func() provider.Provider {
 	return func() provider.Provider {
 		return &mysqlProvider{
@@ -273,6 +888,147 @@ func makeDialer(proxyValue string) (proxy.Dialer, error) {
 	return proxyFromEnv, nil
 }
 
+// registerDialer registers dialer to the driver and returns the network name
+// to use in mysql.Config.Net. The name is unique to the given settings instead
+// of overriding "tcp", so every provider instance in the process keeps using
+// its own dialer.
+func registerDialer(dialer proxy.Dialer, settings ...string) string {
+	network := registrationName("dialer", settings...)
+	mysql.RegisterDialContext(network, func(ctx context.Context, addr string) (net.Conn, error) {
+		if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+			return contextDialer.DialContext(ctx, "tcp", addr)
+		}
+		return dialer.Dial("tcp", addr)
+	})
+	return network
+}
+
+// stringWithEnv returns value if it is set, otherwise the value of the
+// environment variable envKey.
+func stringWithEnv(value types.String, envKey string) string {
+	if !value.IsNull() {
+		return value.ValueString()
+	}
+	return os.Getenv(envKey)
+}
+
+// boolSetting returns the boolean from the provider configuration or the
+// environment variable envKey, or false.
+func boolSetting(value types.Bool, envKey string) (bool, error) {
+	if !value.IsNull() {
+		return value.ValueBool(), nil
+	}
+	s := os.Getenv(envKey)
+	if len(s) == 0 {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a valid boolean: %w", s, err)
+	}
+	return b, nil
+}
+
+// durationSetting returns the duration from the provider configuration,
+// the environment variable envKey or defaultValue in this order.
+func durationSetting(value types.String, envKey string, defaultValue time.Duration) (time.Duration, error) {
+	s := os.Getenv(envKey)
+	if !value.IsNull() {
+		s = value.ValueString()
+	}
+	if len(s) == 0 {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid duration: %w", s, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%q must not be negative", s)
+	}
+	return d, nil
+}
+
+// intSetting returns the number from the provider configuration,
+// the environment variable envKey or defaultValue in this order.
+func intSetting(value types.Int64, envKey string, defaultValue int) (int, error) {
+	if !value.IsNull() {
+		return int(value.ValueInt64()), nil
+	}
+	s := os.Getenv(envKey)
+	if len(s) == 0 {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid number: %w", s, err)
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("%q must not be negative", s)
+	}
+	return i, nil
+}
+
+// reservedConnectionParams maps the DSN parameters managed by the provider to
+// the attributes setting them.
+var reservedConnectionParams = map[string]string{
+	"tls":          "tls",
+	"timeout":      "dial_timeout",
+	"readTimeout":  "read_timeout",
+	"writeTimeout": "write_timeout",
+}
+
+// applyConnectionParams applies DSN parameters to conf in the same way as the
+// driver parses a DSN.
+func applyConnectionParams(conf *mysql.Config, params map[string]string) error {
+	if len(params) == 0 {
+		return nil
+	}
+	values := url.Values{}
+	for key, value := range params {
+		if attribute, ok := reservedConnectionParams[key]; ok {
+			return fmt.Errorf("%s must be set by the %s attribute", key, attribute)
+		}
+		values.Set(key, value)
+	}
+
+	dsn := conf.FormatDSN()
+	if strings.Contains(dsn[strings.LastIndex(dsn, "/"):], "?") {
+		dsn += "&" + values.Encode()
+	} else {
+		dsn += "?" + values.Encode()
+	}
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	*conf = *parsed
+	return nil
+}
+
+// isUnixSocket reports whether endpoint is a path to a Unix socket.
+func isUnixSocket(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "/")
+}
+
+func ensurePort(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return net.JoinHostPort(addr, "3306")
+	}
+	return addr
+}
+
+// registrationName returns a name to register settings to the driver globally.
+// The name is derived from the given values, so that providers configured
+// differently in the same process never overwrite each other.
+func registrationName(kind string, values ...string) string {
+	h := sha256.New()
+	for _, value := range values {
+		_, _ = fmt.Fprintf(h, "%d:%s;", len(value), value)
+	}
+	return fmt.Sprintf("terraform-provider-mysql-%s-%x", kind, h.Sum(nil)[:8])
+}
+
 // func connectToMySQL(ctx context.Context, conf *MySQLConfiguration) (*sql.DB, error) {
 // 	conn, err := connectToMySQLInternal(ctx, conf)
 // 	if err != nil {
@@ -283,30 +1039,25 @@ func makeDialer(proxyValue string) (proxy.Dialer, error) {
 // }
 
 func connectToMySQLInternal(ctx context.Context, conf *MySQLConfiguration) (*OneConnection, error) {
-	// This is fine - we'll connect serially, but we don't expect more than
-	// 1 or 2 connections starting at once.
+	// The key contains the network name registered by registerDialer or
+	// "unix" for sockets, so providers using different dialers never share
+	// a connection.
+	key := conf.cacheKey()
 	connectionCacheMtx.Lock()
-	defer connectionCacheMtx.Unlock()
-
-	dsn := conf.Config.FormatDSN()
-	if connectionCache[dsn] != nil {
-		return connectionCache[dsn], nil
+	cached := connectionCache[key]
+	connectionCacheMtx.Unlock()
+	if cached != nil {
+		return cached, nil
 	}
 	var db *sql.DB
 	var err error
 
-	driverName := "mysql"
-	if conf.Config.Net == "cloudsql" {
-		driverName = "cloudsql"
-	}
-	tflog.Debug(ctx, fmt.Sprintf("Using driverName: %s", driverName))
-
 	// When provisioning a database server there can often be a lag between
 	// when Terraform thinks it's available and when it is actually available.
 	// This is particularly acute when provisioning a server and then immediately
 	// trying to provision a database on it.
-	retryError := retry.RetryContext(ctx, conf.ConnectRetryTimeout, func() *retry.RetryError {
-		db, err = sql.Open(driverName, dsn)
+	connect := func() *retry.RetryError {
+		db, err = openDatabase(conf)
 		if err != nil {
 			if mysqlErrorNumber(err) != 0 || ctx.Err() != nil {
 				return retry.NonRetryableError(err)
@@ -324,58 +1075,100 @@ func connectToMySQLInternal(ctx context.Context, conf *MySQLConfiguration) (*One
 		}
 
 		return nil
-	})
+	}
+	var retryError error
+	if conf.ConnectRetryTimeout > 0 {
+		retryError = retry.RetryContext(ctx, conf.ConnectRetryTimeout, connect)
+	} else if err := connect(); err != nil {
+		retryError = err.Err
+	}
 
 	if retryError != nil {
 		return nil, fmt.Errorf("could not connect to server: %s", retryError)
 	}
 	db.SetConnMaxLifetime(conf.MaxConnLifetime)
+	db.SetConnMaxIdleTime(conf.MaxConnIdleTime)
 	db.SetMaxOpenConns(conf.MaxOpenConns)
+	db.SetMaxIdleConns(conf.MaxIdleConns)
 
-	currentVersion, err := afterConnectVersion(ctx, db)
-	tflog.Info(ctx, currentVersion.String())
+	currentVersion, flavor, err := afterConnectVersion(ctx, db)
 	if err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("failed running after connect command: %v", err)
 	}
+	tflog.Info(ctx, currentVersion.String(), map[string]any{"flavor": flavor.String()})
+	sqlMode, err := sessionSQLMode(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed getting sql_mode: %v", err)
+	}
+
+	conn := &OneConnection{
+		Db:           db,
+		Version:      currentVersion,
+		Flavor:       flavor,
+		Capabilities: newCapabilities(currentVersion, flavor),
+		ANSIQuotes:   hasANSIQuotes(sqlMode),
+		StatementLog: conf.StatementLog,
+		DryRun:       conf.DryRun,
 
-	connectionCache[dsn] = &OneConnection{
-		Db:      db,
-		Version: currentVersion,
+		NoBackslashEscapes:    hasNoBackslashEscapes(sqlMode),
+		StatementRetryTimeout: conf.StatementRetryTimeout,
 	}
 	tflog.Info(ctx, "connect internal")
-	return connectionCache[dsn], nil
+
+	// The mutex is not held while dialing, so that an unreachable server
+	// does not block the other connections. The first connection cached is
+	// used when two of them were dialed at once.
+	connectionCacheMtx.Lock()
+	defer connectionCacheMtx.Unlock()
+	if cached := connectionCache[key]; cached != nil {
+		_ = db.Close()
+		return cached, nil
+	}
+	connectionCache[key] = conn
+	return conn, nil
 }
 
-func afterConnectVersion(ctx context.Context, db *sql.DB) (*version.Version, error) {
-	// Set up env so that we won't create users randomly.
-	tflog.Info(ctx, "AAA Running after connect")
-	currentVersion, err := serverVersion(db)
+func openDatabase(conf *MySQLConfiguration) (*sql.DB, error) {
+	base, err := mysql.NewConnector(conf.Config)
 	if err != nil {
-		return nil, fmt.Errorf("failed getting server version: %v", err)
+		return nil, err
 	}
+	return sql.OpenDB(&connector{
+		Connector:        base,
+		statementTimeout: conf.StatementTimeout,
+		initStatements:   conf.InitStatements,
+	}), nil
+}
 
-	versionMinInclusive, _ := version.NewVersion("5.7.5")
-	versionMaxExclusive, _ := version.NewVersion("8.0.0")
-	if currentVersion.GreaterThanOrEqual(versionMinInclusive) &&
-		currentVersion.LessThan(versionMaxExclusive) {
-		// CONCAT and setting works even if there is no value.
-		_, err = db.ExecContext(ctx, `SET SESSION sql_mode=CONCAT(@@sql_mode, ',NO_AUTO_CREATE_USER')`)
-		if err != nil {
-			return nil, fmt.Errorf("failed setting SQL mode: %v", err)
-		}
+func afterConnectVersion(ctx context.Context, db *sql.DB) (*version.Version, Flavor, error) {
+	// Session statements are run on every connection by the connector.
+	currentVersion, flavor, err := serverVersion(db)
+	if err != nil {
+		return nil, flavor, fmt.Errorf("failed getting server version: %v", err)
 	}
 
-	return currentVersion, nil
+	return currentVersion, flavor, nil
 }
 
-func serverVersion(db *sql.DB) (*version.Version, error) {
-	var versionString string
-	err := db.QueryRow("SELECT @@GLOBAL.version").Scan(&versionString)
+func serverVersion(db *sql.DB) (*version.Version, Flavor, error) {
+	var versionString, versionComment string
+	err := db.QueryRow("SELECT @@GLOBAL.version, @@GLOBAL.version_comment").Scan(&versionString, &versionComment)
 	if err != nil {
-		return nil, err
+		return nil, FlavorMySQL, err
 	}
 
+	currentVersion, err := parseServerVersion(versionString)
+	return currentVersion, detectFlavor(versionString, versionComment), err
+}
+
+func parseServerVersion(versionString string) (*version.Version, error) {
 	versionString = strings.SplitN(versionString, ":", 2)[0]
+	// MariaDB may prefix the version with 5.5.5- for old clients.
+	if strings.Contains(versionString, "MariaDB") {
+		versionString = strings.TrimPrefix(versionString, "5.5.5-")
+	}
 	return version.NewVersion(versionString)
 }
 
@@ -384,8 +1177,8 @@ func mysqlErrorNumber(err error) uint16 {
 	if err == nil {
 		return 0
 	}
-	me, ok := err.(*mysql.MySQLError)
-	if !ok {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
 		return 0
 	}
 	return me.Number
@@ -3,12 +3,31 @@ package provider
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 
 	"github.com/okkez/terraform-provider-mysql/internal/utils"
 )
@@ -54,3 +73,983 @@ func testMySQLConfig() *MySQLConfiguration {
 		ConnectRetryTimeout: time.Duration(300) * time.Second,
 	}
 }
+
+// testProviderValidateConfig validates the provider configuration built from
+// attributes in the same way as Terraform does before configuring it.
+// Attributes not given are null.
+func testProviderValidateConfig(t *testing.T, attributes map[string]tftypes.Value) []*tfprotov6.Diagnostic {
+	t.Helper()
+	ctx := t.Context()
+
+	config := testProviderConfig(t, attributes)
+	value, err := tfprotov6.NewDynamicValue(config.Raw.Type(), config.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := testAccProtoV6ProviderFactories["mysql"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.ValidateProviderConfig(ctx, &tfprotov6.ValidateProviderConfigRequest{Config: &value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Diagnostics
+}
+
+func testHasError(diags []*tfprotov6.Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProviderValidateConfig_TLS(t *testing.T) {
+	// The framework reports the Required attributes of an omitted block as
+	// missing, so mode is Optional and required by the block itself.
+	if diags := testProviderValidateConfig(t, map[string]tftypes.Value{}); testHasError(diags) {
+		t.Errorf("the tls block must be optional: %v", diags)
+	}
+
+	tlsType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"mode":        tftypes.String,
+		"ca_cert":     tftypes.String,
+		"client_cert": tftypes.String,
+		"client_key":  tftypes.String,
+		"server_name": tftypes.String,
+	}}
+	diags := testProviderValidateConfig(t, map[string]tftypes.Value{
+		"tls": tftypes.NewValue(tlsType, map[string]tftypes.Value{
+			"mode":        tftypes.NewValue(tftypes.String, nil),
+			"ca_cert":     tftypes.NewValue(tftypes.String, "/etc/ssl/ca.pem"),
+			"client_cert": tftypes.NewValue(tftypes.String, nil),
+			"client_key":  tftypes.NewValue(tftypes.String, nil),
+			"server_name": tftypes.NewValue(tftypes.String, nil),
+		}),
+	})
+	if !testHasError(diags) {
+		t.Error("mode must be required in the tls block")
+	}
+}
+
+// testProviderConfig builds the provider configuration from attributes.
+// Attributes not given are null.
+func testProviderConfig(t *testing.T, attributes map[string]tftypes.Value) tfsdk.Config {
+	t.Helper()
+	ctx := t.Context()
+
+	var schemaResp provider.SchemaResponse
+	New("test")().Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	objectType, ok := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatal("provider schema is not an object")
+	}
+	values := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		} else {
+			values[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+	return tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(objectType, values),
+	}
+}
+
+// testProviderConfigure runs Configure of a new provider instance with the
+// given attributes. Attributes not given are null.
+func testProviderConfigure(t *testing.T, attributes map[string]tftypes.Value) (*MySQLConfiguration, provider.ConfigureResponse) {
+	t.Helper()
+	ctx := t.Context()
+	p := New("test")()
+
+	req := provider.ConfigureRequest{Config: testProviderConfig(t, attributes)}
+	var resp provider.ConfigureResponse
+	p.Configure(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		return nil, resp
+	}
+	mysqlConf, ok := resp.ResourceData.(*MySQLConfiguration)
+	if !ok {
+		t.Fatal("ResourceData is not *MySQLConfiguration")
+	}
+	return mysqlConf, resp
+}
+
+// testSOCKS5Server accepts SOCKS5 CONNECT requests, records the requested
+// addresses and closes the connections without connecting anywhere.
+type testSOCKS5Server struct {
+	listener  net.Listener
+	mu        sync.Mutex
+	requested []string
+}
+
+func newTestSOCKS5Server(t *testing.T) *testSOCKS5Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSOCKS5Server{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testSOCKS5Server) URL() string {
+	return "socks5://" + s.listener.Addr().String()
+}
+
+func (s *testSOCKS5Server) Requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requested...)
+}
+
+func (s *testSOCKS5Server) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	// Greeting: VER NMETHODS METHODS...
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+		return
+	}
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 0x01:
+		addr := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return
+		}
+		host = net.IP(addr).String()
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return
+		}
+		addr := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return
+		}
+		host = string(addr)
+	case 0x04:
+		addr := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return
+		}
+		host = net.IP(addr).String()
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.requested = append(s.requested, net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	s.mu.Unlock()
+}
+
+func TestProviderConfigure_ProxyPerProvider(t *testing.T) {
+	proxyA := newTestSOCKS5Server(t)
+	proxyB := newTestSOCKS5Server(t)
+
+	confA, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "db-a.example.com:3306"),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+		"proxy":    tftypes.NewValue(tftypes.String, proxyA.URL()),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	confB, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "db-b.example.com:3306"),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+		"proxy":    tftypes.NewValue(tftypes.String, proxyB.URL()),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+
+	if confA.Config.Net == confB.Config.Net {
+		t.Fatalf("providers with different proxies must not share the network name: %s", confA.Config.Net)
+	}
+	if confA.Config.Net == "tcp" || confB.Config.Net == "tcp" {
+		t.Fatal("the global tcp dialer must not be overridden")
+	}
+
+	// Connect with the provider configured first to make sure the later
+	// Configure did not replace its dialer.
+	for _, conf := range []*MySQLConfiguration{confA, confB} {
+		db, err := sql.Open("mysql", conf.Config.FormatDSN())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		if err := db.PingContext(ctx); err == nil {
+			t.Error("ping through the test proxy must fail")
+		}
+		cancel()
+		_ = db.Close()
+	}
+
+	for _, c := range []struct {
+		server   *testSOCKS5Server
+		endpoint string
+	}{
+		{proxyA, "db-a.example.com:3306"},
+		{proxyB, "db-b.example.com:3306"},
+	} {
+		requested := c.server.Requested()
+		if len(requested) == 0 {
+			t.Errorf("proxy for %s was not used", c.endpoint)
+		}
+		for _, addr := range requested {
+			if addr != c.endpoint {
+				t.Errorf("proxy for %s got a request for %s", c.endpoint, addr)
+			}
+		}
+	}
+}
+
+func TestProviderConfigure_PoolSettings(t *testing.T) {
+	baseAttributes := map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "localhost:3306"),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+	}
+
+	conf, resp := testProviderConfigure(t, baseAttributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.MaxConnLifetime != 8*time.Hour || conf.MaxOpenConns != 5 || conf.MaxIdleConns != 2 || conf.ConnectRetryTimeout != 5*time.Minute {
+		t.Errorf("unexpected defaults: %+v", conf)
+	}
+
+	t.Setenv("MYSQL_CONNECT_RETRY_TIMEOUT", "30s")
+	t.Setenv("MYSQL_MAX_OPEN_CONNS", "20")
+	t.Setenv("MYSQL_READ_TIMEOUT", "1m")
+	attributes := map[string]tftypes.Value{
+		"max_open_conns":    tftypes.NewValue(tftypes.Number, 10),
+		"max_idle_conns":    tftypes.NewValue(tftypes.Number, 0),
+		"dial_timeout":      tftypes.NewValue(tftypes.String, "5s"),
+		"statement_timeout": tftypes.NewValue(tftypes.String, "2m"),
+	}
+	for k, v := range baseAttributes {
+		attributes[k] = v
+	}
+	conf, resp = testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.ConnectRetryTimeout != 30*time.Second {
+		t.Errorf("connect_retry_timeout must be sourced from the environment: %s", conf.ConnectRetryTimeout)
+	}
+	if conf.MaxOpenConns != 10 {
+		t.Errorf("max_open_conns must take precedence over the environment: %d", conf.MaxOpenConns)
+	}
+	if conf.MaxIdleConns != 0 {
+		t.Errorf("unexpected max_idle_conns: %d", conf.MaxIdleConns)
+	}
+	if conf.Config.Timeout != 5*time.Second || conf.Config.ReadTimeout != time.Minute {
+		t.Errorf("unexpected timeouts: dial=%s read=%s", conf.Config.Timeout, conf.Config.ReadTimeout)
+	}
+	if conf.StatementTimeout != 2*time.Minute {
+		t.Errorf("unexpected statement_timeout: %s", conf.StatementTimeout)
+	}
+
+	t.Setenv("MYSQL_WRITE_TIMEOUT", "forever")
+	_, resp = testProviderConfigure(t, baseAttributes)
+	if !resp.Diagnostics.HasError() {
+		t.Error("invalid duration in the environment must be an error")
+	}
+}
+
+func TestProviderConfigure_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "mysqld.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets are not supported: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	accepted := make(chan struct{}, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- struct{}{}
+		_ = conn.Close()
+	}()
+
+	proxyServer := newTestSOCKS5Server(t)
+	conf, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, socket),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"proxy":    tftypes.NewValue(tftypes.String, proxyServer.URL()),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("password must be optional for sockets: %v", resp.Diagnostics)
+	}
+	if conf.Config.Net != "unix" || conf.Config.Addr != socket {
+		t.Fatalf("unexpected network: %s(%s)", conf.Config.Net, conf.Config.Addr)
+	}
+
+	tcpConf, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "localhost"),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.Config.FormatDSN() == tcpConf.Config.FormatDSN() {
+		t.Error("sockets must not share the connection cache key with TCP endpoints")
+	}
+
+	db, err := sql.Open("mysql", conf.Config.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	_ = db.Ping()
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the socket was not dialed")
+	}
+	if requested := proxyServer.Requested(); len(requested) != 0 {
+		t.Errorf("the proxy must not be used for sockets: %v", requested)
+	}
+}
+
+func TestProviderConfigure_AWSRDSIAMAuth(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("MYSQL_PASSWORD", "static")
+
+	iamAuth := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"region": tftypes.String}}, map[string]tftypes.Value{
+		"region": tftypes.NewValue(tftypes.String, nil),
+	})
+	conf, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint":         tftypes.NewValue(tftypes.String, "db.example.us-east-1.rds.amazonaws.com"),
+		"username":         tftypes.NewValue(tftypes.String, "iam_user"),
+		"aws_rds_iam_auth": iamAuth,
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.Config.Passwd != "" {
+		t.Error("the static password must not be used")
+	}
+	if !conf.Config.AllowCleartextPasswords {
+		t.Error("the cleartext plugin must be allowed")
+	}
+	if conf.Config.TLSConfig == "false" || conf.Config.AllowFallbackToPlaintext {
+		t.Errorf("TLS must be required: %s", conf.Config.TLSConfig)
+	}
+
+	otherRegion, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "db.example.us-east-1.rds.amazonaws.com"),
+		"username": tftypes.NewValue(tftypes.String, "iam_user"),
+		"aws_rds_iam_auth": tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"region": tftypes.String}}, map[string]tftypes.Value{
+			"region": tftypes.NewValue(tftypes.String, "us-west-2"),
+		}),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.cacheKey() == otherRegion.cacheKey() {
+		t.Error("providers authenticating in different regions must not share a connection")
+	}
+
+	tlsType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"mode":        tftypes.String,
+		"ca_cert":     tftypes.String,
+		"client_cert": tftypes.String,
+		"client_key":  tftypes.String,
+		"server_name": tftypes.String,
+	}}
+	_, resp = testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint":         tftypes.NewValue(tftypes.String, "db.example.us-east-1.rds.amazonaws.com"),
+		"username":         tftypes.NewValue(tftypes.String, "iam_user"),
+		"aws_rds_iam_auth": iamAuth,
+		"tls": tftypes.NewValue(tlsType, map[string]tftypes.Value{
+			"mode":        tftypes.NewValue(tftypes.String, tlsModePreferred),
+			"ca_cert":     tftypes.NewValue(tftypes.String, nil),
+			"client_cert": tftypes.NewValue(tftypes.String, nil),
+			"client_key":  tftypes.NewValue(tftypes.String, nil),
+			"server_name": tftypes.NewValue(tftypes.String, nil),
+		}),
+	})
+	if !resp.Diagnostics.HasError() {
+		t.Error("TLS mode preferred must be rejected")
+	}
+
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	_, resp = testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint":         tftypes.NewValue(tftypes.String, "db.example.us-east-1.rds.amazonaws.com"),
+		"username":         tftypes.NewValue(tftypes.String, "iam_user"),
+		"aws_rds_iam_auth": iamAuth,
+	})
+	if !resp.Diagnostics.HasError() {
+		t.Error("missing credentials must be an error")
+	}
+}
+
+func TestProviderConfigure_TokenAuthCacheKey(t *testing.T) {
+	entraIDType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"tenant_id":     tftypes.String,
+		"client_id":     tftypes.String,
+		"client_secret": tftypes.String,
+	}}
+	entraID := func(clientID string) tftypes.Value {
+		return tftypes.NewValue(entraIDType, map[string]tftypes.Value{
+			"tenant_id":     tftypes.NewValue(tftypes.String, nil),
+			"client_id":     tftypes.NewValue(tftypes.String, clientID),
+			"client_secret": tftypes.NewValue(tftypes.String, nil),
+		})
+	}
+	cloudSQLType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"access_token": tftypes.String}}
+	cloudSQL := func(accessToken string) tftypes.Value {
+		return tftypes.NewValue(cloudSQLType, map[string]tftypes.Value{
+			"access_token": tftypes.NewValue(tftypes.String, accessToken),
+		})
+	}
+
+	keys := map[string]string{}
+	for name, auth := range map[string]map[string]tftypes.Value{
+		"entra id client a": {"azure_entra_id_auth": entraID("client-a")},
+		"entra id client b": {"azure_entra_id_auth": entraID("client-b")},
+		"cloud sql token a": {"gcp_cloudsql_iam_auth": cloudSQL("token-a")},
+		"cloud sql token b": {"gcp_cloudsql_iam_auth": cloudSQL("token-b")},
+	} {
+		auth["endpoint"] = tftypes.NewValue(tftypes.String, "db.example.com")
+		auth["username"] = tftypes.NewValue(tftypes.String, "iam_user")
+		conf, resp := testProviderConfigure(t, auth)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("%s: %v", name, resp.Diagnostics)
+		}
+		if other, ok := keys[conf.cacheKey()]; ok {
+			t.Errorf("%s and %s must not share a connection", name, other)
+		}
+		keys[conf.cacheKey()] = name
+	}
+}
+
+func TestProviderConfigure_PasswordSources(t *testing.T) {
+	t.Setenv("MYSQL_PASSWORD", "")
+	t.Setenv("MYSQL_PASSWORD_FILE", "")
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	base := map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "localhost:3306"),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+	}
+	with := func(name string, value tftypes.Value) map[string]tftypes.Value {
+		attributes := map[string]tftypes.Value{name: value}
+		for k, v := range base {
+			attributes[k] = v
+		}
+		return attributes
+	}
+
+	conf, resp := testProviderConfigure(t, with("password_file", tftypes.NewValue(tftypes.String, file)))
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.Config.Passwd != "from-file" {
+		t.Errorf("unexpected password: %q", conf.Config.Passwd)
+	}
+
+	if _, err := exec.LookPath("echo"); err == nil {
+		conf, resp = testProviderConfigure(t, with("password_command", tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "echo"),
+			tftypes.NewValue(tftypes.String, "from-command"),
+		})))
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("%v", resp.Diagnostics)
+		}
+		if conf.Config.Passwd != "from-command" {
+			t.Errorf("unexpected password: %q", conf.Config.Passwd)
+		}
+	}
+
+	t.Setenv("MYSQL_PASSWORD_FILE", file)
+	conf, resp = testProviderConfigure(t, base)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.Config.Passwd != "from-file" {
+		t.Errorf("password file must be sourced from the environment: %q", conf.Config.Passwd)
+	}
+
+	t.Setenv("MYSQL_PASSWORD", "from-env")
+	_, resp = testProviderConfigure(t, base)
+	if errs := resp.Diagnostics.Errors(); len(errs) != 1 || errs[0].Summary() != "Conflicting password sources" {
+		t.Errorf("MYSQL_PASSWORD and MYSQL_PASSWORD_FILE must conflict: %v", resp.Diagnostics)
+	}
+	conf, resp = testProviderConfigure(t, with("password_file", tftypes.NewValue(tftypes.String, file)))
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.Config.Passwd != "from-file" {
+		t.Errorf("password_file must take precedence over the environment: %q", conf.Config.Passwd)
+	}
+
+	t.Setenv("MYSQL_PASSWORD", "")
+	t.Setenv("MYSQL_PASSWORD_FILE", "")
+	_, resp = testProviderConfigure(t, base)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("missing password must be an error")
+	}
+	for _, d := range resp.Diagnostics.Errors() {
+		if d, ok := d.(diag.DiagnosticWithPath); !ok || !d.Path().Equal(path.Root("password")) {
+			t.Errorf("missing password must be reported against password: %v", d)
+		}
+	}
+}
+
+func TestProviderConfigValidators_PasswordSources(t *testing.T) {
+	ctx := t.Context()
+	p, ok := New("test")().(provider.ProviderWithConfigValidators)
+	if !ok {
+		t.Fatal("provider does not implement ProviderWithConfigValidators")
+	}
+	validators := p.ConfigValidators(ctx)
+
+	validate := func(attributes map[string]tftypes.Value) diag.Diagnostics {
+		req := provider.ValidateConfigRequest{Config: testProviderConfig(t, attributes)}
+		var diags diag.Diagnostics
+		for _, v := range validators {
+			var resp provider.ValidateConfigResponse
+			v.ValidateProvider(ctx, req, &resp)
+			diags.Append(resp.Diagnostics...)
+		}
+		return diags
+	}
+
+	if diags := validate(map[string]tftypes.Value{
+		"password_file": tftypes.NewValue(tftypes.String, "/run/secrets/mysql"),
+	}); diags.HasError() {
+		t.Errorf("unexpected error: %v", diags)
+	}
+	if diags := validate(map[string]tftypes.Value{
+		"password":      tftypes.NewValue(tftypes.String, "password"),
+		"password_file": tftypes.NewValue(tftypes.String, "/run/secrets/mysql"),
+	}); !diags.HasError() {
+		t.Error("password and password_file must conflict")
+	}
+	if diags := validate(map[string]tftypes.Value{
+		"password_file": tftypes.NewValue(tftypes.String, "/run/secrets/mysql"),
+		"password_command": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "cat"),
+		}),
+	}); !diags.HasError() {
+		t.Error("password_file and password_command must conflict")
+	}
+}
+
+func TestProviderValidateConfig_SSHTunnel(t *testing.T) {
+	sshTunnelType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"host":                     tftypes.String,
+		"user":                     tftypes.String,
+		"private_key":              tftypes.String,
+		"private_key_passphrase":   tftypes.String,
+		"use_agent":                tftypes.Bool,
+		"known_hosts_file":         tftypes.String,
+		"insecure_ignore_host_key": tftypes.Bool,
+		"jump_hosts":               tftypes.List{ElementType: tftypes.String},
+	}}
+	sshTunnel := func(host string) tftypes.Value {
+		var hostValue tftypes.Value
+		if host == "" {
+			hostValue = tftypes.NewValue(tftypes.String, nil)
+		} else {
+			hostValue = tftypes.NewValue(tftypes.String, host)
+		}
+		return tftypes.NewValue(sshTunnelType, map[string]tftypes.Value{
+			"host":                     hostValue,
+			"user":                     tftypes.NewValue(tftypes.String, "tunnel"),
+			"private_key":              tftypes.NewValue(tftypes.String, nil),
+			"private_key_passphrase":   tftypes.NewValue(tftypes.String, nil),
+			"use_agent":                tftypes.NewValue(tftypes.Bool, true),
+			"known_hosts_file":         tftypes.NewValue(tftypes.String, nil),
+			"insecure_ignore_host_key": tftypes.NewValue(tftypes.Bool, nil),
+			"jump_hosts":               tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil),
+		})
+	}
+
+	if diags := testProviderValidateConfig(t, map[string]tftypes.Value{
+		"ssh_tunnel": sshTunnel("bastion:22"),
+	}); testHasError(diags) {
+		t.Errorf("unexpected error: %v", diags)
+	}
+	if diags := testProviderValidateConfig(t, map[string]tftypes.Value{
+		"ssh_tunnel": sshTunnel(""),
+	}); !testHasError(diags) {
+		t.Error("host must be required in the ssh_tunnel block")
+	}
+	if diags := testProviderValidateConfig(t, map[string]tftypes.Value{
+		"proxy":      tftypes.NewValue(tftypes.String, "socks5://127.0.0.1:1080"),
+		"ssh_tunnel": sshTunnel("bastion:22"),
+	}); !testHasError(diags) {
+		t.Error("proxy and ssh_tunnel must conflict")
+	}
+}
+
+func TestApplyConnectionParams(t *testing.T) {
+	conf := mysql.Config{
+		User:                 "root",
+		Passwd:               "p@ss/word?",
+		Net:                  "unix",
+		Addr:                 "/var/run/mysqld/mysqld.sock",
+		TLSConfig:            "false",
+		AllowNativePasswords: true,
+		InterpolateParams:    true,
+	}
+	err := applyConnectionParams(&conf, map[string]string{
+		"charset":           "utf8mb4",
+		"collation":         "utf8mb4_bin",
+		"loc":               "Asia/Tokyo",
+		"time_zone":         "'+09:00'",
+		"interpolateParams": "false",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Passwd != "p@ss/word?" || conf.Net != "unix" || conf.Addr != "/var/run/mysqld/mysqld.sock" {
+		t.Errorf("the connection settings must be kept: %+v", conf)
+	}
+	if conf.Collation != "utf8mb4_bin" || conf.Loc.String() != "Asia/Tokyo" || conf.InterpolateParams {
+		t.Errorf("driver parameters must be applied: %+v", conf)
+	}
+	if conf.Params["time_zone"] != "'+09:00'" {
+		t.Errorf("system variables must be applied: %v", conf.Params)
+	}
+
+	if err := applyConnectionParams(&conf, map[string]string{"tls": "skip-verify"}); err == nil {
+		t.Error("tls must be set by the tls block")
+	}
+}
+
+func TestProviderConfigure_InitStatements(t *testing.T) {
+	attributes := map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, "localhost:3306"),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+	}
+	conf, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+
+	attributes["init_statements"] = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "SET SESSION wsrep_on=OFF"),
+	})
+	withInit, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if len(withInit.InitStatements) != 1 || withInit.InitStatements[0] != "SET SESSION wsrep_on=OFF" {
+		t.Errorf("unexpected init_statements: %v", withInit.InitStatements)
+	}
+	if conf.cacheKey() == withInit.cacheKey() {
+		t.Error("providers with different init_statements must not share a connection")
+	}
+}
+
+func TestProviderConfigure_DryRun(t *testing.T) {
+	statementLog := filepath.Join(t.TempDir(), "statements.sql")
+	attributes := map[string]tftypes.Value{
+		"endpoint":      tftypes.NewValue(tftypes.String, "localhost:3306"),
+		"username":      tftypes.NewValue(tftypes.String, "root"),
+		"password":      tftypes.NewValue(tftypes.String, "password"),
+		"statement_log": tftypes.NewValue(tftypes.String, statementLog),
+		"dry_run":       tftypes.NewValue(tftypes.Bool, true),
+	}
+	conf, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("dry run mode must be warned: %v", resp.Diagnostics)
+	}
+	if conf.StatementLog != statementLog || !conf.DryRun {
+		t.Errorf("unexpected configuration: %+v", conf)
+	}
+
+	t.Setenv("MYSQL_DRY_RUN", "false")
+	attributes["dry_run"] = tftypes.NewValue(tftypes.Bool, nil)
+	withoutDryRun, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if withoutDryRun.DryRun {
+		t.Error("MYSQL_DRY_RUN=false must disable dry run mode")
+	}
+	if conf.cacheKey() == withoutDryRun.cacheKey() {
+		t.Error("providers with and without dry run mode must not share a connection")
+	}
+
+	t.Setenv("MYSQL_DRY_RUN", "maybe")
+	if _, resp := testProviderConfigure(t, attributes); !resp.Diagnostics.HasError() {
+		t.Error("an invalid MYSQL_DRY_RUN must be an error")
+	}
+}
+
+func TestProviderConfigure_VerifyPrivileges(t *testing.T) {
+	attributes := map[string]tftypes.Value{
+		"endpoint":          tftypes.NewValue(tftypes.String, "localhost:3306"),
+		"username":          tftypes.NewValue(tftypes.String, "root"),
+		"password":          tftypes.NewValue(tftypes.String, "password"),
+		"verify_privileges": tftypes.NewValue(tftypes.Bool, true),
+	}
+	conf, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if !conf.VerifyPrivileges {
+		t.Errorf("unexpected configuration: %+v", conf)
+	}
+
+	t.Setenv("MYSQL_VERIFY_PRIVILEGES", "false")
+	attributes["verify_privileges"] = tftypes.NewValue(tftypes.Bool, nil)
+	withoutVerification, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if withoutVerification.VerifyPrivileges {
+		t.Error("MYSQL_VERIFY_PRIVILEGES=false must disable the verification")
+	}
+}
+
+func TestProviderConfigure_UnknownEndpoint(t *testing.T) {
+	ctx := t.Context()
+	attributes := map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+	}
+	conf, resp := testProviderConfigure(t, attributes)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unknown endpoint must not fail: %v", resp.Diagnostics)
+	}
+	if !conf.Unknown {
+		t.Fatal("configuration must be unknown")
+	}
+	if resp.Deferred != nil {
+		t.Error("must not defer unless the client allows it")
+	}
+	if _, err := getDatabase(ctx, conf); !errors.Is(err, errUnknownConfiguration) {
+		t.Errorf("expected errUnknownConfiguration, got %v", err)
+	}
+
+	p := New("test")()
+	req := provider.ConfigureRequest{Config: testProviderConfig(t, attributes)}
+	req.ClientCapabilities.DeferralAllowed = true
+	resp = provider.ConfigureResponse{}
+	p.Configure(ctx, req, &resp)
+	if resp.Deferred == nil || resp.Deferred.Reason != provider.DeferredReasonProviderConfigUnknown {
+		t.Errorf("unexpected deferral: %v", resp.Deferred)
+	}
+
+	// Reading resources must keep the state without connecting.
+	for _, newResource := range p.Resources(ctx) {
+		r := newResource()
+		var metadataResp resource.MetadataResponse
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "mysql"}, &metadataResp)
+		t.Run(metadataResp.TypeName, func(t *testing.T) {
+			configurable, ok := r.(resource.ResourceWithConfigure)
+			if !ok {
+				t.Fatal("resource is not configurable")
+			}
+			var configureResp resource.ConfigureResponse
+			configurable.Configure(ctx, resource.ConfigureRequest{ProviderData: conf}, &configureResp)
+			if configureResp.Diagnostics.HasError() {
+				t.Fatalf("%v", configureResp.Diagnostics)
+			}
+
+			var schemaResp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+			state := tfsdk.State{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			readResp := resource.ReadResponse{State: state}
+			r.Read(ctx, resource.ReadRequest{State: state}, &readResp)
+			if readResp.Diagnostics.HasError() {
+				t.Fatalf("%v", readResp.Diagnostics)
+			}
+			if !readResp.State.Raw.Equal(state.Raw) {
+				t.Error("state must be kept")
+			}
+		})
+	}
+
+	// Reading data sources must not connect, and defers them when the client
+	// allows it.
+	for _, newDataSource := range p.DataSources(ctx) {
+		d := newDataSource()
+		var metadataResp datasource.MetadataResponse
+		d.Metadata(ctx, datasource.MetadataRequest{ProviderTypeName: "mysql"}, &metadataResp)
+		t.Run("data."+metadataResp.TypeName, func(t *testing.T) {
+			configurable, ok := d.(datasource.DataSourceWithConfigure)
+			if !ok {
+				t.Fatal("data source is not configurable")
+			}
+			var configureResp datasource.ConfigureResponse
+			configurable.Configure(ctx, datasource.ConfigureRequest{ProviderData: conf}, &configureResp)
+			if configureResp.Diagnostics.HasError() {
+				t.Fatalf("%v", configureResp.Diagnostics)
+			}
+
+			var schemaResp datasource.SchemaResponse
+			d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+			config := tfsdk.Config{
+				Schema: schemaResp.Schema,
+				Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+			}
+			for _, deferralAllowed := range []bool{false, true} {
+				req := datasource.ReadRequest{Config: config}
+				req.ClientCapabilities.DeferralAllowed = deferralAllowed
+				readResp := datasource.ReadResponse{State: tfsdk.State{Schema: config.Schema, Raw: config.Raw}}
+				d.Read(ctx, req, &readResp)
+				if readResp.Diagnostics.HasError() {
+					t.Fatalf("%v", readResp.Diagnostics)
+				}
+				deferred := readResp.Deferred != nil && readResp.Deferred.Reason == datasource.DeferredReasonProviderConfigUnknown
+				if deferred != deferralAllowed {
+					t.Errorf("unexpected deferral (deferral allowed: %t): %v", deferralAllowed, readResp.Deferred)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceRead_NotFound(t *testing.T) {
+	ctx := t.Context()
+	user := NewUser("user", "%")
+	attributes := map[string]map[string]any{
+		"mysql_database":        {"id": "db", "name": "db"},
+		"mysql_default_roles":   {"user": "user", "host": "%"},
+		"mysql_global_variable": {"name": "max_connections"},
+		"mysql_grant_privilege": {"on": PrivilegeLevelModel{Database: types.StringValue("db"), Table: types.StringValue("*")}, "to": user},
+		"mysql_grant_role":      {"to": user},
+		"mysql_grants":          {"user": "user", "host": "%"},
+		"mysql_role":            {"name": "role", "host": "%"},
+		"mysql_user":            {"name": "user", "host": "%"},
+	}
+	for name, tc := range map[string]struct {
+		handle  fakeHandler
+		removed bool
+	}{
+		"not found": {
+			handle: func(query string) (*fakeRows, error) {
+				switch {
+				case strings.Contains(query, "COUNT(*)"):
+					return &fakeRows{columns: []string{"COUNT(*)"}, values: [][]driver.Value{{int64(0)}}}, nil
+				case strings.HasPrefix(query, "SHOW GRANTS"):
+					return nil, &mysql.MySQLError{Number: erNonexistingGrant, Message: "There is no such grant defined"}
+				case strings.HasPrefix(query, "SELECT @@GLOBAL."):
+					return nil, &mysql.MySQLError{Number: erUnknownSystemVariable, Message: "Unknown system variable"}
+				default:
+					return nil, nil
+				}
+			},
+			removed: true,
+		},
+		"connection lost": {
+			handle: func(string) (*fakeRows, error) {
+				return nil, driver.ErrBadConn
+			},
+		},
+		"access denied": {
+			handle: func(string) (*fakeRows, error) {
+				return nil, &mysql.MySQLError{Number: 1142, Message: "SELECT command denied"}
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conf := testMySQLConfig()
+			conf.Config.User = "read_" + strings.ReplaceAll(name, " ", "_")
+			testFakeConnection(t, conf, tc.handle)
+
+			for _, newResource := range New("test")().Resources(ctx) {
+				r := newResource()
+				var metadataResp resource.MetadataResponse
+				r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "mysql"}, &metadataResp)
+				t.Run(metadataResp.TypeName, func(t *testing.T) {
+					configurable, ok := r.(resource.ResourceWithConfigure)
+					if !ok {
+						t.Fatal("resource is not configurable")
+					}
+					var configureResp resource.ConfigureResponse
+					configurable.Configure(ctx, resource.ConfigureRequest{ProviderData: conf}, &configureResp)
+					if configureResp.Diagnostics.HasError() {
+						t.Fatalf("%v", configureResp.Diagnostics)
+					}
+
+					var schemaResp resource.SchemaResponse
+					r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+					state := tfsdk.State{
+						Schema: schemaResp.Schema,
+						Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+					}
+					for attribute, value := range attributes[metadataResp.TypeName] {
+						if diags := state.SetAttribute(ctx, path.Root(attribute), value); diags.HasError() {
+							t.Fatalf("%v", diags)
+						}
+					}
+
+					readResp := resource.ReadResponse{State: state}
+					r.Read(ctx, resource.ReadRequest{State: state}, &readResp)
+					if tc.removed {
+						if readResp.Diagnostics.HasError() {
+							t.Fatalf("%v", readResp.Diagnostics)
+						}
+						if !readResp.State.Raw.IsNull() {
+							t.Error("state must be removed")
+						}
+						return
+					}
+					if !readResp.Diagnostics.HasError() {
+						t.Error("the error must be reported")
+					}
+					if !readResp.State.Raw.Equal(state.Raw) {
+						t.Error("state must be kept")
+					}
+				})
+			}
+		})
+	}
+}
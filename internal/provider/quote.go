@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// quoteIdentifier quotes identifier with backticks, doubling the backticks
+// in it, as sys.quote_identifier does. Backticks quote identifiers whether or
+// not the sql_mode contains ANSI_QUOTES, so the result is valid in any
+// session.
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func quoteIdentifiers(identifiers ...string) []string {
+	quotedIdentifiers := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		quotedIdentifiers[i] = quoteIdentifier(identifier)
+	}
+	return quotedIdentifiers
+}
+
+// stringLiteralReplacer escapes the characters which end or alter a string
+// literal quoted with single quotes.
+var stringLiteralReplacer = strings.NewReplacer(`\`, `\\`, `'`, `''`, "\x00", `\0`)
+
+// quoteString quotes value as a string literal. Literals are quoted with
+// single quotes, since double quotes quote identifiers when the sql_mode
+// contains ANSI_QUOTES. The literal is escaped with backslashes, so it must be
+// rewritten by withoutBackslashEscapes when the sql_mode contains
+// NO_BACKSLASH_ESCAPES.
+func quoteString(value string) string {
+	return "'" + stringLiteralReplacer.Replace(value) + "'"
+}
+
+// backslashEscapes are the characters written by the escape sequences of
+// string literals. Backslashes before other characters are dropped, except
+// for % and _, which keep them for LIKE patterns.
+var backslashEscapes = map[rune]string{
+	'0': "\x00",
+	'b': "\b",
+	'n': "\n",
+	'r': "\r",
+	't': "\t",
+	'Z': "\x1a",
+	'%': `\%`,
+	'_': `\_`,
+}
+
+// withoutBackslashEscapes rewrites the string literals quoted with single
+// quotes in query, which are escaped with backslashes, into literals for a
+// session whose sql_mode contains NO_BACKSLASH_ESCAPES. Only single quotes
+// are escaped then, by doubling them. Identifiers are kept.
+func withoutBackslashEscapes(query string) string {
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range query {
+		switch {
+		case escaped:
+			escaped = false
+			if escape, ok := backslashEscapes[r]; ok {
+				b.WriteString(escape)
+			} else if r == '\'' {
+				b.WriteString("''")
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		case quote == '\'' && r == '\\':
+			escaped = true
+			continue
+		case quote != 0:
+			// A doubled quote closes and reopens the literal, which needs no
+			// special handling.
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hasSQLMode reports whether sqlMode, the value of @@sql_mode, contains
+// mode. The server expands combination modes such as ANSI.
+func hasSQLMode(sqlMode, mode string) bool {
+	for _, m := range strings.Split(sqlMode, ",") {
+		if strings.EqualFold(strings.TrimSpace(m), mode) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasANSIQuotes reports whether sqlMode, the value of @@sql_mode, contains
+// ANSI_QUOTES.
+func hasANSIQuotes(sqlMode string) bool {
+	return hasSQLMode(sqlMode, "ANSI_QUOTES")
+}
+
+// hasNoBackslashEscapes reports whether sqlMode, the value of @@sql_mode,
+// contains NO_BACKSLASH_ESCAPES.
+func hasNoBackslashEscapes(sqlMode string) bool {
+	return hasSQLMode(sqlMode, "NO_BACKSLASH_ESCAPES")
+}
+
+// sessionSQLMode returns the sql_mode of the sessions of db. The server
+// writes SHOW GRANTS with double quotes when it contains ANSI_QUOTES, and
+// takes backslashes in literals as is when it contains NO_BACKSLASH_ESCAPES.
+func sessionSQLMode(ctx context.Context, db *sql.DB) (string, error) {
+	var sqlMode string
+	if err := db.QueryRowContext(ctx, "SELECT @@SESSION.sql_mode").Scan(&sqlMode); err != nil {
+		return "", err
+	}
+	return sqlMode, nil
+}
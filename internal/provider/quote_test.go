@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	for identifier, expected := range map[string]string{
+		"db":       "`db`",
+		"my`table": "`my``table`",
+		"``":       "``````",
+		`my"table`: "`my\"table`",
+		"":         "``",
+		"データベース":   "`データベース`",
+	} {
+		if quoted := quoteIdentifier(identifier); quoted != expected {
+			t.Errorf("quoteIdentifier(%q) = %s, want %s", identifier, quoted, expected)
+		}
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	for value, expected := range map[string]string{
+		"password":   `'password'`,
+		"it's":       `'it''s'`,
+		`back\`:      `'back\\'`,
+		`"double"`:   `'"double"'`,
+		"nul\x00nul": `'nul\0nul'`,
+	} {
+		if quoted := quoteString(value); quoted != expected {
+			t.Errorf("quoteString(%q) = %s, want %s", value, quoted, expected)
+		}
+	}
+}
+
+func TestHasANSIQuotes(t *testing.T) {
+	for sqlMode, expected := range map[string]bool{
+		"": false,
+		"ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE": false,
+		"ANSI_QUOTES": true,
+		"REAL_AS_FLOAT,PIPES_AS_CONCAT,ANSI_QUOTES,IGNORE_SPACE,ONLY_FULL_GROUP_BY,ANSI": true,
+		"ansi_quotes,STRICT_ALL_TABLES": true,
+	} {
+		if got := hasANSIQuotes(sqlMode); got != expected {
+			t.Errorf("hasANSIQuotes(%q) = %v", sqlMode, got)
+		}
+	}
+}
+
+func TestHasNoBackslashEscapes(t *testing.T) {
+	for sqlMode, expected := range map[string]bool{
+		"": false,
+		"ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE": false,
+		"NO_BACKSLASH_ESCAPES":                   true,
+		"STRICT_ALL_TABLES,no_backslash_escapes": true,
+	} {
+		if got := hasNoBackslashEscapes(sqlMode); got != expected {
+			t.Errorf("hasNoBackslashEscapes(%q) = %v", sqlMode, got)
+		}
+	}
+}
+
+func TestWithoutBackslashEscapes(t *testing.T) {
+	for query, expected := range map[string]string{
+		"CREATE USER 'user'@'%'":                                     "CREATE USER 'user'@'%'",
+		"CREATE USER " + quoteString(`back\`) + "@'%'":               `CREATE USER 'back\'@'%'`,
+		"ALTER USER 'u'@'%' IDENTIFIED BY " + quoteString(`a\'b''c`): `ALTER USER 'u'@'%' IDENTIFIED BY 'a\''b''''c'`,
+		"SELECT " + quoteString("nul\x00nul"):                        "SELECT 'nul\x00nul'",
+		`SELECT 'tab\t', 'it\'s', 'like\%'`:                          "SELECT 'tab\t', 'it''s', 'like\\%'",
+		"GRANT SELECT ON `db\\`.* TO 'user'":                         "GRANT SELECT ON `db\\`.* TO 'user'",
+	} {
+		if got := withoutBackslashEscapes(query); got != expected {
+			t.Errorf("withoutBackslashEscapes(%q) = %q, want %q", query, got, expected)
+		}
+	}
+}
+
+func TestParseGrantPrivilegeStatement_ANSIQuotes(t *testing.T) {
+	grant, err := ParseGrantPrivilegeStatement(`GRANT SELECT ON "my""db".* TO "user"@"%"`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !grant.Match(objectTypeTable, `my"db`, "*", "user", "%") {
+		t.Errorf("unexpected grant: %+v", grant)
+	}
+}
+
+// testQuoteIdentifierOnServer compares quoteIdentifier to
+// sys.quote_identifier when acceptance tests run against a server.
+func testQuoteIdentifierOnServer(t *testing.T, identifier, quoted string) {
+	t.Helper()
+	if os.Getenv("TF_ACC") == "" {
+		return
+	}
+	var expected string
+	if err := testDatabase().QueryRowContext(t.Context(), "SELECT sys.quote_identifier(?)", identifier).Scan(&expected); err != nil {
+		t.Skipf("sys.quote_identifier is not available: %v", err)
+	}
+	if quoted != expected {
+		t.Errorf("quoteIdentifier(%q) = %s, sys.quote_identifier returns %s", identifier, quoted, expected)
+	}
+}
+
+// testQuoteStringOnServer checks that the server reads the literal back as
+// value when acceptance tests run against a server.
+func testQuoteStringOnServer(t *testing.T, value, quoted string) {
+	t.Helper()
+	if os.Getenv("TF_ACC") == "" {
+		return
+	}
+	var got string
+	if err := testDatabase().QueryRowContext(t.Context(), "SELECT "+quoted).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != value {
+		t.Errorf("the server reads %s as %q, want %q", quoted, got, value)
+	}
+}
+
+func FuzzQuoteIdentifier(f *testing.F) {
+	for _, seed := range []string{"db", "my`table", "``", `my"table`, "a b", "データベース"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, identifier string) {
+		if identifier == "" || !utf8.ValidString(identifier) || strings.ContainsRune(identifier, 0) || strings.HasSuffix(identifier, " ") {
+			// The server rejects these names.
+			t.Skip()
+		}
+		quoted := quoteIdentifier(identifier)
+		for _, ansiQuotes := range []bool{false, true} {
+			grant, err := ParseGrantPrivilegeStatement("GRANT SELECT ON "+quoted+".* TO 'user'@'%'", ansiQuotes)
+			if err != nil {
+				t.Fatalf("failed parsing %s (ANSI_QUOTES=%v): %v", quoted, ansiQuotes, err)
+			}
+			if grant.DBName != identifier {
+				t.Errorf("%s is read as %q (ANSI_QUOTES=%v)", quoted, grant.DBName, ansiQuotes)
+			}
+		}
+		testQuoteIdentifierOnServer(t, identifier, quoted)
+	})
+}
+
+func FuzzQuoteString(f *testing.F) {
+	for _, seed := range []string{"password", "it's", `back\`, `"double"`, "nul\x00nul", `\'; DROP USER root; --`} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		if !utf8.ValidString(value) {
+			t.Skip()
+		}
+		quoted := quoteString(value)
+		for _, ansiQuotes := range []bool{false, true} {
+			stmt, err := parse("SELECT "+quoted, ansiQuotes)
+			if err != nil {
+				t.Fatalf("failed parsing %s (ANSI_QUOTES=%v): %v", quoted, ansiQuotes, err)
+			}
+			selectStmt, ok := (*stmt).(*ast.SelectStmt)
+			if !ok || len(selectStmt.Fields.Fields) != 1 {
+				t.Fatalf("%s is not a single field (ANSI_QUOTES=%v)", quoted, ansiQuotes)
+			}
+			literal, ok := selectStmt.Fields.Fields[0].Expr.(ast.ValueExpr)
+			if !ok {
+				t.Fatalf("%s is not a literal (ANSI_QUOTES=%v)", quoted, ansiQuotes)
+			}
+			if got := literal.GetString(); got != value {
+				t.Errorf("%s is read as %q (ANSI_QUOTES=%v)", quoted, got, ansiQuotes)
+			}
+		}
+		testQuoteStringOnServer(t, value, quoted)
+	})
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultRandomPasswordLength is the length of generated passwords unless
+// the configuration or the password policy requires longer ones.
+const defaultRandomPasswordLength = 20
+
+func NewRandomPasswordEphemeralResource() ephemeral.EphemeralResource {
+	return &RandomPasswordEphemeralResource{}
+}
+
+var (
+	_ ephemeral.EphemeralResource              = &RandomPasswordEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &RandomPasswordEphemeralResource{}
+)
+
+type RandomPasswordEphemeralResource struct {
+	mysqlConfig *MySQLConfiguration
+}
+
+type RandomPasswordEphemeralResourceModel struct {
+	Length  types.Int64  `tfsdk:"length"`
+	Special types.Bool   `tfsdk:"special"`
+	Result  types.String `tfsdk:"result"`
+}
+
+func (e *RandomPasswordEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_random_password"
+}
+
+func (e *RandomPasswordEphemeralResource) Schema(_ context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "The `mysql_random_password` ephemeral resource generates a password which satisfies the password policy " +
+			"of the server, i.e. the `validate_password` component or plugin of MySQL or the `simple_password_check` plugin of MariaDB. " +
+			"The password is not saved in the plan or the state. Pass it to write-only attributes such as `auth_option.auth_string_wo` of `mysql_user`.",
+		Attributes: map[string]schema.Attribute{
+			"length": schema.Int64Attribute{
+				MarkdownDescription: "The minimum length of the password. The password is longer when the password policy requires. Defaults to `20`.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"special": schema.BoolAttribute{
+				MarkdownDescription: "Use special characters. Defaults to `true`. Fails when `false` and the password policy requires special characters.",
+				Optional:            true,
+			},
+			"result": schema.StringAttribute{
+				MarkdownDescription: "The generated password.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+		},
+	}
+}
+
+func (e *RandomPasswordEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	if mysqlConfig, ok := req.ProviderData.(*MySQLConfiguration); ok {
+		e.mysqlConfig = mysqlConfig
+	} else {
+		resp.Diagnostics.AddError("Failed type assertion", "")
+	}
+}
+
+func (e *RandomPasswordEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data RandomPasswordEphemeralResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The server may not exist yet while planning. Write-only attributes do
+	// not keep the password of the plan, so the policy is applied on apply.
+	var policy passwordPolicy
+	if !e.mysqlConfig.Unknown {
+		conn, err := getConnection(ctx, e.mysqlConfig)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
+			return
+		}
+		policy, err = queryPasswordPolicy(ctx, conn)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed reading the password policy", err.Error())
+			return
+		}
+	}
+
+	length := defaultRandomPasswordLength
+	if !data.Length.IsNull() {
+		length = int(data.Length.ValueInt64())
+	}
+	special := data.Special.IsNull() || data.Special.ValueBool()
+	password, err := generatePassword(policy, length, special)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed generating a password", err.Error())
+		return
+	}
+	data.Result = types.StringValue(password)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
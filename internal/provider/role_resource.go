@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -20,6 +21,7 @@ var (
 	_ resource.Resource                = &RoleResource{}
 	_ resource.ResourceWithConfigure   = &RoleResource{}
 	_ resource.ResourceWithImportState = &RoleResource{}
+	_ resource.ResourceWithModifyPlan  = &RoleResource{}
 )
 
 func NewRoleResource() resource.Resource {
@@ -44,7 +46,8 @@ func (r *RoleResource) Metadata(ctx context.Context, req resource.MetadataReques
 
 func (r *RoleResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "MySQL role",
+		MarkdownDescription: "MySQL role\n\n" +
+			"~> **Note:** Roles have no host on MariaDB, where `host` is kept as configured and not sent to the server.",
 
 		Attributes: map[string]schema.Attribute{
 			"id":   utils.IDAttribute(),
@@ -67,8 +70,16 @@ func (r *RoleResource) Configure(ctx context.Context, req resource.ConfigureRequ
 	}
 }
 
+func (r *RoleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	resp.Diagnostics.Append(checkCapability(ctx, r.mysqlConfig, path.Empty(), "Roles", func(c Capabilities) bool { return c.Roles })...)
+}
+
 func (r *RoleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -80,25 +91,22 @@ func (r *RoleResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	name := data.Name.ValueString()
-	host := data.Host.ValueString()
-	var args []interface{}
-	args = append(args, name)
-	args = append(args, host)
-	sql := "CREATE ROLE ?@?"
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-	_, err = db.ExecContext(ctx, sql, args...)
+	stmt := createRoleStatement(conn.Flavor, data.Name.ValueString(), data.Host.ValueString())
+	err = conn.exec(ctx, stmt)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed creating role", err.Error())
 		return
 	}
 
-	data.ID = types.StringValue(fmt.Sprintf("%s@%s", args...))
+	data.ID = types.StringValue(fmt.Sprintf("%s@%s", data.Name.ValueString(), data.Host.ValueString()))
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *RoleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -110,31 +118,22 @@ func (r *RoleResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	var args []interface{}
-	args = append(args, data.Name.ValueString())
-	args = append(args, data.Host.ValueString())
-
-	sql := `
-SELECT
-  User
-, Host
-FROM
-  mysql.user
-WHERE
-  User = ?
-  AND Host = ?
-  AND authentication_string = ''
-  AND password_expired = 'Y'
-`
-	tflog.Info(ctx, sql, map[string]any{"args": args})
+	stmt := readRoleStatement(conn.Flavor, data.Name.ValueString(), data.Host.ValueString())
+	tflog.Info(ctx, stmt.query, map[string]any{"args": stmt.args})
 
 	var name, host string
-	if err = db.QueryRowContext(ctx, sql, args...).Scan(&name, &host); err != nil {
+	if err = conn.queryRow(ctx, stmt, &name, &host); errors.Is(err, errNotFound) {
 		resp.State.RemoveResource(ctx)
 		return
+	} else if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading role (%s@%s)", data.Name.ValueString(), data.Host.ValueString()), err.Error())
+		return
 	} else {
 		data.Name = types.StringValue(name)
-		data.Host = types.StringValue(host)
+		// MariaDB roles have no host. Keep the configured one.
+		if conn.Flavor.rolesHaveHosts() {
+			data.Host = types.StringValue(host)
+		}
 	}
 
 	// Save updated data into Terraform state
@@ -146,7 +145,7 @@ func (r *RoleResource) Update(ctx context.Context, req resource.UpdateRequest, r
 }
 
 func (r *RoleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -160,15 +159,10 @@ func (r *RoleResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 
 	name := data.Name.ValueString()
 	host := data.Host.ValueString()
-	sql := "DROP ROLE IF EXISTS ?@?"
-	var args []interface{}
-	args = append(args, name)
-	args = append(args, host)
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err = db.ExecContext(ctx, sql, name, host)
+	stmt := dropRoleStatement(conn.Flavor, name, host)
+	err = conn.exec(ctx, stmt)
 	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed deleting role (%s@%s)", args...), err.Error())
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed deleting role (%s@%s)", name, host), err.Error())
 		return
 	}
 }
@@ -183,3 +177,58 @@ func (r *RoleResource) ImportState(ctx context.Context, req resource.ImportState
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), types.StringValue(nameHost[0]))...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("host"), types.StringValue(nameHost[1]))...)
 }
+
+// roleAccount returns the account of the role, which has no host on MariaDB.
+func roleAccount(flavor Flavor, name, host string) (string, []any) {
+	if !flavor.rolesHaveHosts() {
+		return flavor.role(name, host)
+	}
+	return flavor.account(name, host)
+}
+
+func createRoleStatement(flavor Flavor, name, host string) sqlStatement {
+	account, args := roleAccount(flavor, name, host)
+	return sqlStatement{query: "CREATE ROLE " + account, args: args}
+}
+
+func dropRoleStatement(flavor Flavor, name, host string) sqlStatement {
+	account, args := roleAccount(flavor, name, host)
+	return sqlStatement{query: "DROP ROLE IF EXISTS " + account, args: args}
+}
+
+// readRoleStatement returns the query selecting the name and the host of the
+// role. MySQL stores roles as locked accounts without a password, while
+// MariaDB flags them in mysql.global_priv.
+func readRoleStatement(flavor Flavor, name, host string) sqlStatement {
+	if flavor == FlavorMariaDB {
+		return sqlStatement{
+			query: `
+SELECT
+  User
+, Host
+FROM
+  mysql.global_priv
+WHERE
+  User = ?
+  AND Host = ''
+  AND JSON_VALUE(Priv, '$.is_role') = 'true'
+`,
+			args: []any{name},
+		}
+	}
+	return sqlStatement{
+		query: `
+SELECT
+  User
+, Host
+FROM
+  mysql.user
+WHERE
+  User = ?
+  AND Host = ?
+  AND authentication_string = ''
+  AND password_expired = 'Y'
+`,
+		args: []any{name, host},
+	}
+}
@@ -98,3 +98,17 @@ func testAccRoleResource_CheckDestroy(roles []RoleModel) resource.TestCheckFunc
 		return nil
 	}
 }
+
+func TestRoleStatements(t *testing.T) {
+	testStatement(t, createRoleStatement(FlavorMySQL, "role", "%"), sqlStatement{query: "CREATE ROLE ?@?", args: []any{"role", "%"}})
+	testStatement(t, createRoleStatement(FlavorMariaDB, "role", "%"), sqlStatement{query: "CREATE ROLE 'role'"})
+	testStatement(t, dropRoleStatement(FlavorMySQL, "role", "%"), sqlStatement{query: "DROP ROLE IF EXISTS ?@?", args: []any{"role", "%"}})
+	testStatement(t, dropRoleStatement(FlavorMariaDB, "role", "%"), sqlStatement{query: "DROP ROLE IF EXISTS 'role'"})
+
+	if stmt := readRoleStatement(FlavorMySQL, "role", "%"); !regexp.MustCompile(`mysql\.user`).MatchString(stmt.query) || len(stmt.args) != 2 {
+		t.Errorf("unexpected MySQL query: %s %v", stmt.query, stmt.args)
+	}
+	if stmt := readRoleStatement(FlavorMariaDB, "role", "%"); !regexp.MustCompile(`mysql\.global_priv[\s\S]*is_role`).MatchString(stmt.query) || len(stmt.args) != 1 {
+		t.Errorf("unexpected MariaDB query: %s %v", stmt.query, stmt.args)
+	}
+}
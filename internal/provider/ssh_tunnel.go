@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// sshTunnelModel describes the `ssh_tunnel` block of the provider
+// configuration.
+type sshTunnelModel struct {
+	Host                  types.String `tfsdk:"host"`
+	User                  types.String `tfsdk:"user"`
+	PrivateKey            types.String `tfsdk:"private_key"`
+	PrivateKeyPassphrase  types.String `tfsdk:"private_key_passphrase"`
+	UseAgent              types.Bool   `tfsdk:"use_agent"`
+	KnownHostsFile        types.String `tfsdk:"known_hosts_file"`
+	InsecureIgnoreHostKey types.Bool   `tfsdk:"insecure_ignore_host_key"`
+	JumpHosts             []string     `tfsdk:"jump_hosts"`
+}
+
+var (
+	sshTunnelsMtx sync.Mutex
+	// sshTunnels holds the tunnels by their settings, so that providers with
+	// the same settings share the SSH connection.
+	sshTunnels = map[string]*sshTunnel{}
+)
+
+// sshHop is an SSH server on the way to the MySQL server.
+type sshHop struct {
+	addr string
+	user string
+}
+
+// sshTunnel dials the MySQL server through the SSH server, connecting through
+// the jump hosts in order. The SSH connection is opened on the first dial and
+// reopened when it is lost.
+type sshTunnel struct {
+	hops []sshHop
+	auth []ssh.AuthMethod
+	// agentSocket is the socket of the SSH agent to authenticate with, if
+	// any. The agent is connected along with the SSH connection.
+	agentSocket     string
+	hostKeyCallback ssh.HostKeyCallback
+
+	mu        sync.Mutex
+	clients   []*ssh.Client
+	agentConn net.Conn
+}
+
+// makeSSHTunnel returns the tunnel for the settings and the settings to
+// register it with.
+func makeSSHTunnel(data sshTunnelModel) (*sshTunnel, []string, error) {
+	user := data.User.ValueString()
+	var hops []sshHop
+	for _, jumpHost := range data.JumpHosts {
+		hop, err := parseSSHHop(jumpHost, user)
+		if err != nil {
+			return nil, nil, err
+		}
+		hops = append(hops, hop)
+	}
+	hop, err := parseSSHHop(data.Host.ValueString(), user)
+	if err != nil {
+		return nil, nil, err
+	}
+	hops = append(hops, hop)
+
+	privateKey, err := readPEMOrFile(data.PrivateKey.ValueString())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading private_key: %w", err)
+	}
+	// The agent is used by default unless a private key is given.
+	useAgent := len(privateKey) == 0
+	if !data.UseAgent.IsNull() {
+		useAgent = data.UseAgent.ValueBool()
+	}
+	knownHostsFile := data.KnownHostsFile.ValueString()
+	if knownHostsFile == "" && !data.InsecureIgnoreHostKey.ValueBool() {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed finding known_hosts_file: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	settings := []string{
+		string(privateKey),
+		data.PrivateKeyPassphrase.ValueString(),
+		fmt.Sprintf("%t", useAgent),
+		knownHostsFile,
+		fmt.Sprintf("%t", data.InsecureIgnoreHostKey.ValueBool()),
+	}
+	for _, hop := range hops {
+		settings = append(settings, hop.user+"@"+hop.addr)
+	}
+	name := registrationName("ssh", settings...)
+
+	sshTunnelsMtx.Lock()
+	defer sshTunnelsMtx.Unlock()
+	if tunnel, ok := sshTunnels[name]; ok {
+		return tunnel, settings, nil
+	}
+
+	tunnel := &sshTunnel{hops: hops}
+	if len(privateKey) > 0 {
+		var signer ssh.Signer
+		if passphrase := data.PrivateKeyPassphrase.ValueString(); passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(privateKey)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed parsing private_key: %w", err)
+		}
+		tunnel.auth = append(tunnel.auth, ssh.PublicKeys(signer))
+	}
+	if useAgent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, nil, fmt.Errorf("SSH_AUTH_SOCK environment variable must be set to use the SSH agent")
+		}
+		// Fail early when the agent is not running. The connection is used by
+		// the first SSH connection.
+		tunnel.agentSocket = socket
+		tunnel.agentConn, err = net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed connecting to the SSH agent: %w", err)
+		}
+	}
+	if len(tunnel.auth) == 0 && tunnel.agentSocket == "" {
+		return nil, nil, fmt.Errorf("private_key or use_agent must be set")
+	}
+	if data.InsecureIgnoreHostKey.ValueBool() {
+		tunnel.hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	} else {
+		tunnel.hostKeyCallback, err = knownhosts.New(knownHostsFile)
+		if err != nil {
+			if tunnel.agentConn != nil {
+				_ = tunnel.agentConn.Close()
+			}
+			return nil, nil, fmt.Errorf("failed reading known_hosts_file: %w", err)
+		}
+	}
+
+	sshTunnels[name] = tunnel
+	return tunnel, settings, nil
+}
+
+// parseSSHHop parses `[user@]host[:port]`.
+func parseSSHHop(value, defaultUser string) (sshHop, error) {
+	hop := sshHop{addr: value, user: defaultUser}
+	if i := strings.LastIndex(value, "@"); i >= 0 {
+		hop.user, hop.addr = value[:i], value[i+1:]
+	}
+	if hop.addr == "" || hop.user == "" {
+		return sshHop{}, fmt.Errorf("invalid SSH host: %q", value)
+	}
+	if _, _, err := net.SplitHostPort(hop.addr); err != nil {
+		hop.addr = net.JoinHostPort(hop.addr, "22")
+	}
+	return hop, nil
+}
+
+func (t *sshTunnel) Dial(network, addr string) (net.Conn, error) {
+	return t.DialContext(context.Background(), network, addr)
+}
+
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err == nil || ctx.Err() != nil {
+		return conn, err
+	}
+
+	// The SSH connection may be lost. Reconnect once.
+	t.reset(client)
+	client, err = t.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.DialContext(ctx, network, addr)
+}
+
+// client returns the SSH client connected to the last hop, connecting to
+// the hops if not connected yet.
+func (t *sshTunnel) client(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.clients) > 0 {
+		return t.clients[len(t.clients)-1], nil
+	}
+
+	auth := t.auth
+	if t.agentSocket != "" {
+		if t.agentConn == nil {
+			conn, err := net.Dial("unix", t.agentSocket)
+			if err != nil {
+				return nil, fmt.Errorf("failed connecting to the SSH agent: %w", err)
+			}
+			t.agentConn = conn
+		}
+		auth = append(slices.Clip(auth), ssh.PublicKeysCallback(agent.NewClient(t.agentConn).Signers))
+	}
+
+	var clients []*ssh.Client
+	closeClients := func() {
+		t.closeLocked(clients)
+	}
+	for _, hop := range t.hops {
+		var conn net.Conn
+		var err error
+		if len(clients) == 0 {
+			var dialer net.Dialer
+			conn, err = dialer.DialContext(ctx, "tcp", hop.addr)
+		} else {
+			conn, err = clients[len(clients)-1].DialContext(ctx, "tcp", hop.addr)
+		}
+		if err != nil {
+			closeClients()
+			return nil, fmt.Errorf("failed connecting to SSH server %s: %w", hop.addr, err)
+		}
+		// The handshake does not take a context.
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		clientConn, chans, reqs, err := ssh.NewClientConn(conn, hop.addr, &ssh.ClientConfig{
+			User:            hop.user,
+			Auth:            auth,
+			HostKeyCallback: t.hostKeyCallback,
+		})
+		_ = conn.SetDeadline(time.Time{})
+		if err != nil {
+			_ = conn.Close()
+			closeClients()
+			return nil, fmt.Errorf("failed connecting to SSH server %s: %w", hop.addr, err)
+		}
+		clients = append(clients, ssh.NewClient(clientConn, chans, reqs))
+	}
+
+	t.clients = clients
+	return clients[len(clients)-1], nil
+}
+
+// reset closes the connections if client is still the current one.
+func (t *sshTunnel) reset(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.clients) == 0 || t.clients[len(t.clients)-1] != client {
+		return
+	}
+	t.closeLocked(t.clients)
+	t.clients = nil
+}
+
+// closeLocked closes clients, the last one first, and the connection to the
+// SSH agent. t.mu must be held.
+func (t *sshTunnel) closeLocked(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		_ = clients[i].Close()
+	}
+	if t.agentConn != nil {
+		_ = t.agentConn.Close()
+		t.agentConn = nil
+	}
+}
@@ -0,0 +1,461 @@
+package provider
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is a minimal SSH server which accepts a single public key
+// and forwards direct-tcpip channels.
+type testSSHServer struct {
+	listener net.Listener
+	hostKey  ssh.Signer
+
+	mu        sync.Mutex
+	forwarded []string
+}
+
+func newTestSSHServer(t *testing.T, authorizedKey ssh.PublicKey) *testSSHServer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorizedKey.Marshal()) {
+				return nil, errors.New("unauthorized key")
+			}
+			return &ssh.Permissions{}, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	s := &testSSHServer{listener: listener, hostKey: hostKey}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testSSHServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testSSHServer) Forwarded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.forwarded...)
+}
+
+func (s *testSSHServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	defer func() { _ = serverConn.Close() }()
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		var payload struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			_ = newChannel.Reject(ssh.Prohibited, err.Error())
+			continue
+		}
+		target := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+		s.mu.Lock()
+		s.forwarded = append(s.forwarded, target)
+		s.mu.Unlock()
+
+		targetConn, err := net.Dial("tcp", target)
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			_ = targetConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			defer func() { _ = channel.Close() }()
+			_, _ = io.Copy(channel, targetConn)
+		}()
+		go func() {
+			defer func() { _ = targetConn.Close() }()
+			_, _ = io.Copy(targetConn, channel)
+		}()
+	}
+}
+
+// newTestSSHClientKey returns a private key in the OpenSSH PEM format.
+func newTestSSHClientKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(block)), publicKey
+}
+
+func newTestKnownHostsFile(t *testing.T, servers ...*testSSHServer) string {
+	t.Helper()
+	var lines []string
+	for _, server := range servers {
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(server.Addr())}, server.hostKey.PublicKey()))
+	}
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func newTestEchoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func testEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("unexpected echo: %q", buf)
+	}
+}
+
+func TestSSHTunnel_JumpHosts(t *testing.T) {
+	privateKey, publicKey := newTestSSHClientKey(t)
+	jump := newTestSSHServer(t, publicKey)
+	bastion := newTestSSHServer(t, publicKey)
+	echo := newTestEchoServer(t)
+
+	tunnel, _, err := makeSSHTunnel(sshTunnelModel{
+		Host:           types.StringValue(bastion.Addr()),
+		User:           types.StringValue("tunnel"),
+		PrivateKey:     types.StringValue(privateKey),
+		KnownHostsFile: types.StringValue(newTestKnownHostsFile(t, jump, bastion)),
+		JumpHosts:      []string{"jump@" + jump.Addr()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := tunnel.DialContext(t.Context(), "tcp", echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEcho(t, conn)
+
+	if forwarded := jump.Forwarded(); len(forwarded) != 1 || forwarded[0] != bastion.Addr() {
+		t.Errorf("the jump host must forward to the SSH server: %v", forwarded)
+	}
+	if forwarded := bastion.Forwarded(); len(forwarded) != 1 || forwarded[0] != echo {
+		t.Errorf("the SSH server must forward to the endpoint: %v", forwarded)
+	}
+
+	// The SSH connection is reopened when it is lost.
+	tunnel.reset(tunnel.clients[len(tunnel.clients)-1])
+	conn, err = tunnel.DialContext(t.Context(), "tcp", echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEcho(t, conn)
+}
+
+// newTestSSHAgent starts an SSH agent holding privateKey, sets
+// SSH_AUTH_SOCK to its socket and returns the number of open connections.
+func newTestSSHAgent(t *testing.T, privateKey string) *atomic.Int32 {
+	t.Helper()
+	key, err := ssh.ParseRawPrivateKey([]byte(privateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	var open atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			open.Add(1)
+			go func() {
+				_ = agent.ServeAgent(keyring, conn)
+				_ = conn.Close()
+				open.Add(-1)
+			}()
+		}
+	}()
+	return &open
+}
+
+// testOpenConnections waits until open is expected.
+func testOpenConnections(t *testing.T, open *atomic.Int32, expected int32) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for open.Load() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d connections to the SSH agent are open, want %d", open.Load(), expected)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSSHTunnel_Agent(t *testing.T) {
+	privateKey, publicKey := newTestSSHClientKey(t)
+	server := newTestSSHServer(t, publicKey)
+	echo := newTestEchoServer(t)
+	open := newTestSSHAgent(t, privateKey)
+
+	// The agent is disconnected when the settings are invalid.
+	_, _, err := makeSSHTunnel(sshTunnelModel{
+		Host:           types.StringValue(server.Addr()),
+		User:           types.StringValue("tunnel"),
+		UseAgent:       types.BoolValue(true),
+		KnownHostsFile: types.StringValue(filepath.Join(t.TempDir(), "missing")),
+	})
+	if err == nil {
+		t.Fatal("expected known_hosts_file error")
+	}
+	testOpenConnections(t, open, 0)
+
+	tunnel, _, err := makeSSHTunnel(sshTunnelModel{
+		Host:           types.StringValue(server.Addr()),
+		User:           types.StringValue("tunnel"),
+		UseAgent:       types.BoolValue(true),
+		KnownHostsFile: types.StringValue(newTestKnownHostsFile(t, server)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := tunnel.DialContext(t.Context(), "tcp", echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEcho(t, conn)
+	testOpenConnections(t, open, 1)
+
+	// The agent is disconnected along with the SSH connection, and connected
+	// again when the SSH connection is reopened.
+	tunnel.reset(tunnel.clients[len(tunnel.clients)-1])
+	testOpenConnections(t, open, 0)
+	conn, err = tunnel.DialContext(t.Context(), "tcp", echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEcho(t, conn)
+	testOpenConnections(t, open, 1)
+	tunnel.reset(tunnel.clients[len(tunnel.clients)-1])
+}
+
+func TestSSHTunnel_HostKeyMismatch(t *testing.T) {
+	privateKey, publicKey := newTestSSHClientKey(t)
+	server := newTestSSHServer(t, publicKey)
+	other := newTestSSHServer(t, publicKey)
+
+	// Register the host key of the other server for the address of server.
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.Addr())}, other.hostKey.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tunnel, _, err := makeSSHTunnel(sshTunnelModel{
+		Host:           types.StringValue(server.Addr()),
+		User:           types.StringValue("tunnel"),
+		PrivateKey:     types.StringValue(privateKey),
+		KnownHostsFile: types.StringValue(knownHostsFile),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tunnel.DialContext(t.Context(), "tcp", newTestEchoServer(t)); err == nil {
+		t.Error("expected host key mismatch error")
+	}
+}
+
+func TestParseSSHHop(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected sshHop
+		wantErr  bool
+	}{
+		{value: "bastion", expected: sshHop{addr: "bastion:22", user: "default"}},
+		{value: "admin@bastion:2222", expected: sshHop{addr: "bastion:2222", user: "admin"}},
+		{value: "admin@[::1]:2222", expected: sshHop{addr: "[::1]:2222", user: "admin"}},
+		{value: "admin@", wantErr: true},
+	}
+	for _, c := range cases {
+		actual, err := parseSSHHop(c.value, "default")
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", c.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.value, err)
+			continue
+		}
+		if actual != c.expected {
+			t.Errorf("%s: unexpected hop: %+v", c.value, actual)
+		}
+	}
+}
+
+func TestProviderConfigure_SSHTunnel(t *testing.T) {
+	privateKey, publicKey := newTestSSHClientKey(t)
+	server := newTestSSHServer(t, publicKey)
+
+	mysqlListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mysqlListener.Close() }()
+	accepted := make(chan struct{}, 1)
+	go func() {
+		conn, err := mysqlListener.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- struct{}{}
+		_ = conn.Close()
+	}()
+
+	sshTunnelType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"host":                     tftypes.String,
+		"user":                     tftypes.String,
+		"private_key":              tftypes.String,
+		"private_key_passphrase":   tftypes.String,
+		"use_agent":                tftypes.Bool,
+		"known_hosts_file":         tftypes.String,
+		"insecure_ignore_host_key": tftypes.Bool,
+		"jump_hosts":               tftypes.List{ElementType: tftypes.String},
+	}}
+	conf, resp := testProviderConfigure(t, map[string]tftypes.Value{
+		"endpoint": tftypes.NewValue(tftypes.String, mysqlListener.Addr().String()),
+		"username": tftypes.NewValue(tftypes.String, "root"),
+		"password": tftypes.NewValue(tftypes.String, "password"),
+		"ssh_tunnel": tftypes.NewValue(sshTunnelType, map[string]tftypes.Value{
+			"host":                     tftypes.NewValue(tftypes.String, server.Addr()),
+			"user":                     tftypes.NewValue(tftypes.String, "tunnel"),
+			"private_key":              tftypes.NewValue(tftypes.String, privateKey),
+			"private_key_passphrase":   tftypes.NewValue(tftypes.String, nil),
+			"use_agent":                tftypes.NewValue(tftypes.Bool, nil),
+			"known_hosts_file":         tftypes.NewValue(tftypes.String, nil),
+			"insecure_ignore_host_key": tftypes.NewValue(tftypes.Bool, true),
+			"jump_hosts":               tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil),
+		}),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	if conf.Config.Net == "tcp" {
+		t.Fatal("the SSH tunnel must be registered as its own network")
+	}
+
+	db, err := sql.Open("mysql", conf.Config.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	_ = db.Ping()
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the endpoint was not dialed through the SSH tunnel")
+	}
+	if forwarded := server.Forwarded(); len(forwarded) == 0 || forwarded[0] != mysqlListener.Addr().String() {
+		t.Errorf("unexpected forwarded addresses: %v", forwarded)
+	}
+}
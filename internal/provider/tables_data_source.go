@@ -59,7 +59,10 @@ func (d *TablesDataSource) Schema(_ context.Context, req datasource.SchemaReques
 }
 
 func (d *TablesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
-	db, err := getDatabase(ctx, d.mysqlConfig)
+	if deferDataSourceRead(d.mysqlConfig, req, resp) {
+		return
+	}
+	conn, err := getConnection(ctx, d.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -68,13 +71,7 @@ func (d *TablesDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 	var data TablesDataSourceModel
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
 
-	database, err := quoteIdentifier(ctx, db, data.Database.ValueString())
-	if err != nil {
-		resp.Diagnostics.AddError("Failed quoting identifier", err.Error())
-		return
-	}
-
-	sql := fmt.Sprintf("SHOW TABLES FROM %s", database)
+	sql := fmt.Sprintf("SHOW TABLES FROM %s", quoteIdentifier(data.Database.ValueString()))
 	var args []interface{}
 	if !data.Pattern.IsNull() {
 		sql = sql + " LIKE ?"
@@ -83,7 +80,7 @@ func (d *TablesDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 
 	tflog.Info(ctx, fmt.Sprintf("SQL: %s", sql), map[string]any{"pattern": data.Pattern.ValueString()})
 
-	rows, err := db.QueryContext(ctx, sql, args...)
+	rows, err := conn.query(ctx, sqlStatement{query: sql, args: args})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed querying for tables", err.Error())
 		return
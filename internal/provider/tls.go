@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	tlsModeDisabled   = "disabled"
+	tlsModePreferred  = "preferred"
+	tlsModeRequired   = "required"
+	tlsModeVerifyCA   = "verify-ca"
+	tlsModeVerifyFull = "verify-full"
+)
+
+var tlsModes = []string{
+	tlsModeDisabled,
+	tlsModePreferred,
+	tlsModeRequired,
+	tlsModeVerifyCA,
+	tlsModeVerifyFull,
+}
+
+// tlsModel describes the `tls` block of the provider configuration.
+type tlsModel struct {
+	Mode       types.String `tfsdk:"mode"`
+	CACert     types.String `tfsdk:"ca_cert"`
+	ClientCert types.String `tfsdk:"client_cert"`
+	ClientKey  types.String `tfsdk:"client_key"`
+	ServerName types.String `tfsdk:"server_name"`
+}
+
+// configureTLS builds a *tls.Config from the `tls` block and registers it to
+// the driver. The registered name is derived from the settings, so providers
+// with different certificates never overwrite each other's configuration.
+func configureTLS(conf *mysql.Config, data tlsModel) error {
+	mode := data.Mode.ValueString()
+	if mode == tlsModeDisabled {
+		conf.TLSConfig = "false"
+		return nil
+	}
+
+	caCert, err := readPEMOrFile(data.CACert.ValueString())
+	if err != nil {
+		return fmt.Errorf("failed reading ca_cert: %w", err)
+	}
+	clientCert, err := readPEMOrFile(data.ClientCert.ValueString())
+	if err != nil {
+		return fmt.Errorf("failed reading client_cert: %w", err)
+	}
+	clientKey, err := readPEMOrFile(data.ClientKey.ValueString())
+	if err != nil {
+		return fmt.Errorf("failed reading client_key: %w", err)
+	}
+
+	tlsConfig, err := buildTLSConfig(mode, caCert, clientCert, clientKey, data.ServerName.ValueString())
+	if err != nil {
+		return err
+	}
+
+	name := registrationName("tls", mode, string(caCert), string(clientCert), string(clientKey), data.ServerName.ValueString())
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return fmt.Errorf("failed registering TLS config: %w", err)
+	}
+
+	conf.TLSConfig = name
+	conf.AllowFallbackToPlaintext = mode == tlsModePreferred
+	return nil
+}
+
+func buildTLSConfig(mode string, caCert, clientCert, clientKey []byte, serverName string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+
+	if len(caCert) > 0 {
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("ca_cert does not contain any valid PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if len(clientCert) > 0 || len(clientKey) > 0 {
+		certificate, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	switch mode {
+	case tlsModePreferred:
+		tlsConfig.InsecureSkipVerify = true
+	case tlsModeRequired:
+		// Same as the mysql client: when a CA is given, REQUIRED behaves like VERIFY_CA.
+		tlsConfig.InsecureSkipVerify = true
+		if tlsConfig.RootCAs != nil {
+			tlsConfig.VerifyPeerCertificate = verifyCertificateChain(tlsConfig.RootCAs)
+		}
+	case tlsModeVerifyCA:
+		// Verify the certificate chain, but not the host name.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyCertificateChain(tlsConfig.RootCAs)
+	case tlsModeVerifyFull:
+		// The driver fills ServerName with the host of the endpoint when it is empty.
+	default:
+		return nil, fmt.Errorf("unknown TLS mode: %s", mode)
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyCertificateChain verifies the server certificate against rootCAs
+// without checking the host name. The system pool is used when rootCAs is nil.
+func verifyCertificateChain(rootCAs *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("server did not present a certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, rawCert := range rawCerts {
+			cert, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				return fmt.Errorf("failed parsing server certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         rootCAs,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
+
+// readPEMOrFile returns value as is if it looks like PEM encoded data,
+// otherwise reads the file at the path given by value.
+func readPEMOrFile(value string) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	if strings.Contains(value, "-----BEGIN ") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
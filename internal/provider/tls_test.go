@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{commonName}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func testTLSHandshake(t *testing.T, clientConfig *tls.Config, server *testCertificate) error {
+	t.Helper()
+	serverCert, err := tls.X509KeyPair(server.certPEM, server.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		serverConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = serverConn.Close() }()
+		_ = tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{serverCert}}).Handshake()
+	}()
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = clientConn.Close() }()
+	return tls.Client(clientConn, clientConfig).Handshake()
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	ca := newTestCertificate(t, "test-ca", nil)
+	otherCA := newTestCertificate(t, "other-ca", nil)
+	server := newTestCertificate(t, "db.example.com", ca)
+
+	cases := []struct {
+		name       string
+		mode       string
+		caCert     []byte
+		serverName string
+		wantErr    bool
+	}{
+		{name: "preferred", mode: tlsModePreferred},
+		{name: "required without CA", mode: tlsModeRequired},
+		{name: "required with CA", mode: tlsModeRequired, caCert: ca.certPEM},
+		{name: "required with wrong CA", mode: tlsModeRequired, caCert: otherCA.certPEM, wantErr: true},
+		{name: "verify-ca ignores host name", mode: tlsModeVerifyCA, caCert: ca.certPEM, serverName: "other.example.com"},
+		{name: "verify-ca with wrong CA", mode: tlsModeVerifyCA, caCert: otherCA.certPEM, wantErr: true},
+		{name: "verify-full", mode: tlsModeVerifyFull, caCert: ca.certPEM, serverName: "db.example.com"},
+		{name: "verify-full with wrong host name", mode: tlsModeVerifyFull, caCert: ca.certPEM, serverName: "other.example.com", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tlsConfig, err := buildTLSConfig(c.mode, c.caCert, nil, nil, c.serverName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = testTLSHandshake(t, tlsConfig, server)
+			if c.wantErr && err == nil {
+				t.Error("expected handshake error, got nil")
+			}
+			if !c.wantErr && err != nil {
+				t.Errorf("unexpected handshake error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildTLSConfig_Invalid(t *testing.T) {
+	if _, err := buildTLSConfig(tlsModeVerifyFull, []byte("not a certificate"), nil, nil, ""); err == nil {
+		t.Error("expected error for invalid ca_cert")
+	}
+	ca := newTestCertificate(t, "test-ca", nil)
+	if _, err := buildTLSConfig(tlsModeVerifyFull, nil, ca.certPEM, nil, ""); err == nil {
+		t.Error("expected error for client_cert without client_key")
+	}
+	if _, err := buildTLSConfig("unknown", nil, nil, nil, ""); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestReadPEMOrFile(t *testing.T) {
+	ca := newTestCertificate(t, "test-ca", nil)
+
+	inline, err := readPEMOrFile(string(ca.certPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(inline) != string(ca.certPEM) {
+		t.Error("inline PEM must be returned as is")
+	}
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(file, ca.certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := readPEMOrFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fromFile) != string(ca.certPEM) {
+		t.Error("PEM must be read from the file")
+	}
+}
+
+func TestConfigureTLS_PerSettings(t *testing.T) {
+	ca1 := newTestCertificate(t, "ca-1", nil)
+	ca2 := newTestCertificate(t, "ca-2", nil)
+
+	var conf1, conf2 mysql.Config
+	if err := configureTLS(&conf1, tlsModel{Mode: types.StringValue(tlsModeVerifyCA), CACert: types.StringValue(string(ca1.certPEM))}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configureTLS(&conf2, tlsModel{Mode: types.StringValue(tlsModeVerifyCA), CACert: types.StringValue(string(ca2.certPEM))}); err != nil {
+		t.Fatal(err)
+	}
+	if conf1.TLSConfig == conf2.TLSConfig {
+		t.Errorf("providers with different CAs must not share the TLS config name: %s", conf1.TLSConfig)
+	}
+	if conf1.FormatDSN() == conf2.FormatDSN() {
+		t.Error("providers with different CAs must not share the connection cache key")
+	}
+}
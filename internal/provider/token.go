@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tokenRefreshMargin is how long before the expiry a token is refreshed, so
+// that a token never expires during the handshake.
+const tokenRefreshMargin = time.Minute
+
+// tokenSource issues short-lived tokens which are sent as the password.
+type tokenSource interface {
+	Token(ctx context.Context) (token string, expiry time.Time, err error)
+}
+
+// cachingTokenSource reuses the token issued by source until it is about to
+// expire.
+type cachingTokenSource struct {
+	source tokenSource
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newCachingTokenSource(source tokenSource) *cachingTokenSource {
+	return &cachingTokenSource{source: source, now: time.Now}
+}
+
+func (s *cachingTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(tokenRefreshMargin).Before(s.expiry) {
+		return s.token, s.expiry, nil
+	}
+	token, expiry, err := s.source.Token(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	s.token, s.expiry = token, expiry
+	return token, expiry, nil
+}
+
+// configureTokenAuth makes the driver send a token issued by source as the
+// password with the cleartext plugin. The token is fetched whenever the pool
+// opens a new connection, and is refreshed when it is about to expire.
+func configureTokenAuth(conf *mysql.Config, source tokenSource) error {
+	conf.Passwd = ""
+	conf.AllowCleartextPasswords = true
+
+	cached := newCachingTokenSource(source)
+	return conf.Apply(mysql.BeforeConnect(func(ctx context.Context, cfg *mysql.Config) error {
+		token, _, err := cached.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed issuing authentication token: %w", err)
+		}
+		cfg.Passwd = token
+		return nil
+	}))
+}
+
+// fetchOAuthToken sends req and parses the OAuth 2.0 access token response.
+func fetchOAuthToken(client *http.Client, req *http.Request, now time.Time) (string, time.Time, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("token endpoint returned %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		// Some endpoints return expires_in as a string.
+		ExpiresIn json.RawMessage `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed parsing token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token response does not contain access_token")
+	}
+	expiresIn, err := strconv.Atoi(strings.Trim(string(token.ExpiresIn), `"`))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid expires_in in token response: %s", token.ExpiresIn)
+	}
+	return token.AccessToken, now.Add(time.Duration(expiresIn) * time.Second), nil
+}
+
+// staticTokenSource always returns the same token.
+type staticTokenSource string
+
+func (s staticTokenSource) Token(_ context.Context) (string, time.Time, error) {
+	return string(s), time.Time{}, nil
+}
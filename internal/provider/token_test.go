@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type testTokenSource struct {
+	calls int
+	err   error
+}
+
+func (s *testTokenSource) Token(_ context.Context) (string, time.Time, error) {
+	s.calls++
+	return "token", time.Time{}, s.err
+}
+
+func TestConfigureTokenAuth(t *testing.T) {
+	source := &testTokenSource{err: errors.New("no credentials")}
+	conf := mysql.Config{User: "app", Passwd: "static", Net: "tcp", Addr: "127.0.0.1:1"}
+	if err := configureTokenAuth(&conf, source); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Passwd != "" || !conf.AllowCleartextPasswords {
+		t.Errorf("unexpected config: %+v", conf)
+	}
+
+	connector, err := mysql.NewConnector(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer func() { _ = db.Close() }()
+	err = db.PingContext(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "no credentials") {
+		t.Errorf("the token must be issued before connecting: %v", err)
+	}
+	if source.calls == 0 {
+		t.Error("the token source was not called")
+	}
+}
+
+func TestFetchOAuthToken(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		expiry  time.Time
+		wantErr bool
+	}{
+		{name: "numeric expires_in", status: http.StatusOK, body: `{"access_token":"token","expires_in":3599}`, expiry: now.Add(3599 * time.Second)},
+		{name: "string expires_in", status: http.StatusOK, body: `{"access_token":"token","expires_in":"86400"}`, expiry: now.Add(24 * time.Hour)},
+		{name: "missing access_token", status: http.StatusOK, body: `{"expires_in":3599}`, wantErr: true},
+		{name: "error status", status: http.StatusBadRequest, body: `{"error":"invalid_client"}`, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+				_, _ = w.Write([]byte(c.body))
+			}))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, expiry, err := fetchOAuthToken(server.Client(), req, now)
+			if c.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != "token" || !expiry.Equal(c.expiry) {
+				t.Errorf("unexpected token: %s, %s", token, expiry)
+			}
+		})
+	}
+}
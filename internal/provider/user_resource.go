@@ -2,20 +2,29 @@ package provider
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/go-sql-driver/mysql"
-
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
@@ -27,6 +36,7 @@ var (
 	_ resource.Resource                = &UserResource{}
 	_ resource.ResourceWithConfigure   = &UserResource{}
 	_ resource.ResourceWithImportState = &UserResource{}
+	_ resource.ResourceWithModifyPlan  = &UserResource{}
 )
 
 const (
@@ -49,18 +59,87 @@ type UserResourceModel struct {
 	Host       types.String `tfsdk:"host"`
 	Lock       types.Bool   `tfsdk:"lock"`
 	AuthOption types.Object `tfsdk:"auth_option"`
+
+	MaxQueriesPerHour     types.Int64 `tfsdk:"max_queries_per_hour"`
+	MaxUpdatesPerHour     types.Int64 `tfsdk:"max_updates_per_hour"`
+	MaxConnectionsPerHour types.Int64 `tfsdk:"max_connections_per_hour"`
+	MaxUserConnections    types.Int64 `tfsdk:"max_user_connections"`
+
+	PasswordExpireInterval types.Int64 `tfsdk:"password_expire_interval"`
+	PasswordHistory        types.Int64 `tfsdk:"password_history"`
+	PasswordReuseInterval  types.Int64 `tfsdk:"password_reuse_interval"`
+	PasswordRequireCurrent types.Bool  `tfsdk:"password_require_current"`
+	FailedLoginAttempts    types.Int64 `tfsdk:"failed_login_attempts"`
+	PasswordLockTime       types.Int64 `tfsdk:"password_lock_time"`
+
+	TLSRequirement types.Object `tfsdk:"tls_requirement"`
+
+	Comment    types.String `tfsdk:"comment"`
+	Attributes types.Map    `tfsdk:"attributes"`
+
+	AdditionalFactors types.List `tfsdk:"additional_factor"`
+}
+
+// userDefaults has the defaults of the resource limits and the password
+// policy, which CREATE USER does not need to set.
+var userDefaults = UserResourceModel{
+	MaxQueriesPerHour:     types.Int64Value(0),
+	MaxUpdatesPerHour:     types.Int64Value(0),
+	MaxConnectionsPerHour: types.Int64Value(0),
+	MaxUserConnections:    types.Int64Value(0),
+	FailedLoginAttempts:   types.Int64Value(0),
+	PasswordLockTime:      types.Int64Value(0),
 }
 
 type AuthOptionModel struct {
-	Plugin         types.String `tfsdk:"plugin"`
-	AuthString     types.String `tfsdk:"auth_string"`
-	RandomPassword types.Bool   `tfsdk:"random_password"`
+	Plugin              types.String `tfsdk:"plugin"`
+	AuthString          types.String `tfsdk:"auth_string"`
+	AuthStringWO        types.String `tfsdk:"auth_string_wo"`
+	AuthStringWOVersion types.Int64  `tfsdk:"auth_string_wo_version"`
+	RandomPassword      types.Bool   `tfsdk:"random_password"`
+	PGPKey              types.String `tfsdk:"pgp_key"`
+	GeneratedPassword   types.String `tfsdk:"generated_password"`
+	EncryptedPassword   types.String `tfsdk:"encrypted_password"`
+	InitialAuthString   types.String `tfsdk:"initial_auth_string"`
 }
 
 var AuthOptionModelTypes = map[string]attr.Type{
-	"plugin":          types.StringType,
-	"auth_string":     types.StringType,
-	"random_password": types.BoolType,
+	"plugin":                 types.StringType,
+	"auth_string":            types.StringType,
+	"auth_string_wo":         types.StringType,
+	"auth_string_wo_version": types.Int64Type,
+	"random_password":        types.BoolType,
+	"pgp_key":                types.StringType,
+	"generated_password":     types.StringType,
+	"encrypted_password":     types.StringType,
+	"initial_auth_string":    types.StringType,
+}
+
+// AuthFactorModel is the second or the third authentication factor.
+type AuthFactorModel struct {
+	Plugin           types.String `tfsdk:"plugin"`
+	AuthString       types.String `tfsdk:"auth_string"`
+	StoredAuthString types.String `tfsdk:"stored_auth_string"`
+}
+
+var AuthFactorModelTypes = map[string]attr.Type{
+	"plugin":             types.StringType,
+	"auth_string":        types.StringType,
+	"stored_auth_string": types.StringType,
+}
+
+type TLSRequirementModel struct {
+	Type    types.String `tfsdk:"type"`
+	Issuer  types.String `tfsdk:"issuer"`
+	Subject types.String `tfsdk:"subject"`
+	Cipher  types.String `tfsdk:"cipher"`
+}
+
+var TLSRequirementModelTypes = map[string]attr.Type{
+	"type":    types.StringType,
+	"issuer":  types.StringType,
+	"subject": types.StringType,
+	"cipher":  types.StringType,
 }
 
 func (r *UserResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -73,9 +152,11 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 		MarkdownDescription: "The `mysql_user` resource creates and manages a user on a MySQL server.\n\n" +
 			"~> **Note:** The password for the user is provided in plain text, and is obscured by an unsalted hash in the " +
 			"state [Read more about sensitive data in state](https://www.terraform.io/language/state/sensitive-data). " +
-			"Care is required when using this resource, to avoid disclosing the password.\n\n" +
-			"~> **Note about random password:** The generated random password will be shown in the log immediately after running `terraform apply`. " +
-			"Be sure to save the password, as there is no way to check it after that.",
+			"Care is required when using this resource, to avoid disclosing the password. " +
+			"With Terraform 1.11 or later, use the write-only `auth_string_wo` instead of `auth_string` to keep the password " +
+			"out of the plan and the state, e.g. with the `mysql_random_password` ephemeral resource.\n\n" +
+			"~> **Note about random password:** The generated random password is saved in the state as `auth_option.generated_password`, " +
+			"unless `auth_option.pgp_key` is set, in which case it is saved encrypted as `auth_option.encrypted_password`.",
 		Attributes: map[string]schema.Attribute{
 			"id":   utils.IDAttribute(),
 			"name": utils.NameAttribute("user", true),
@@ -86,6 +167,72 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				Computed:            true,
 				Default:             booldefault.StaticBool(false),
 			},
+			"max_queries_per_hour":     resourceLimitAttribute("queries the user can issue per hour"),
+			"max_updates_per_hour":     resourceLimitAttribute("updates the user can issue per hour"),
+			"max_connections_per_hour": resourceLimitAttribute("times the user can connect per hour"),
+			"max_user_connections":     resourceLimitAttribute("simultaneous connections of the user"),
+			"password_expire_interval": schema.Int64Attribute{
+				MarkdownDescription: "The number of days after which the password expires. `0` means the password never expires. " +
+					"The `default_password_lifetime` of the server applies if omitted.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.Between(0, 65535),
+				},
+			},
+			"password_history": schema.Int64Attribute{
+				MarkdownDescription: "The number of the recent passwords which cannot be reused. " +
+					"The `password_history` of the server applies if omitted. Requires MySQL 8.0.3 or later.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.Between(0, 65535),
+				},
+			},
+			"password_reuse_interval": schema.Int64Attribute{
+				MarkdownDescription: "The number of days for which the passwords cannot be reused. " +
+					"The `password_reuse_interval` of the server applies if omitted. Requires MySQL 8.0.3 or later.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.Between(0, 65535),
+				},
+			},
+			"password_require_current": schema.BoolAttribute{
+				MarkdownDescription: "Require the current password to change the password. " +
+					"The `password_require_current` of the server applies if omitted. Requires MySQL 8.0.13 or later.",
+				Optional: true,
+			},
+			"failed_login_attempts": schema.Int64Attribute{
+				MarkdownDescription: "The number of consecutive failed logins which lock the account temporarily. " +
+					"`0` disables the tracking. Defaults to `0`. Requires MySQL 8.0.19 or later.",
+				Optional: true,
+				Computed: true,
+				Default:  int64default.StaticInt64(0),
+				Validators: []validator.Int64{
+					int64validator.Between(0, 32767),
+				},
+			},
+			"password_lock_time": schema.Int64Attribute{
+				MarkdownDescription: "The number of days for which the account is locked after `failed_login_attempts` failed logins. " +
+					"`-1` locks the account until it is unlocked. Defaults to `0`. Requires MySQL 8.0.19 or later.",
+				Optional: true,
+				Computed: true,
+				Default:  int64default.StaticInt64(0),
+				Validators: []validator.Int64{
+					int64validator.Between(-1, 32767),
+				},
+			},
+			"comment": schema.StringAttribute{
+				MarkdownDescription: "The comment of the user. Requires MySQL 8.0.21 or later.",
+				Optional:            true,
+			},
+			"attributes": schema.MapAttribute{
+				MarkdownDescription: "The attributes of the user, e.g. the owner team and the ticket, " +
+					"which are shown in `INFORMATION_SCHEMA.USER_ATTRIBUTES` with `comment`. Requires MySQL 8.0.21 or later.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.KeysAre(stringvalidator.NoneOf("comment")),
+				},
+			},
 		},
 		Blocks: map[string]schema.Block{
 			"auth_option": schema.SingleNestedBlock{
@@ -98,11 +245,123 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 						Optional: true,
 					},
 					"auth_string": schema.StringAttribute{
-						MarkdownDescription: "Plain text password. Conflicts with `random_password`.",
+						MarkdownDescription: "Plain text password. Conflicts with `auth_string_wo`, `random_password`.",
+						Optional:            true,
+					},
+					"auth_string_wo": schema.StringAttribute{
+						MarkdownDescription: "Plain text password, which is not saved in the plan or the state. " +
+							"It is set when the user is created and when `auth_string_wo_version` changes. " +
+							"Requires Terraform 1.11 or later. Conflicts with `auth_string`, `random_password`.",
+						Optional:  true,
+						Sensitive: true,
+						WriteOnly: true,
+					},
+					"auth_string_wo_version": schema.Int64Attribute{
+						MarkdownDescription: "The version of `auth_string_wo`. Change it to set the password again.",
 						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.AlsoRequires(path.MatchRelative().AtParent().AtName("auth_string_wo")),
+						},
 					},
 					"random_password": schema.BoolAttribute{
-						MarkdownDescription: "Generate random password when create user. Display generated password after creating user. Conflicts with `auth_string`.",
+						MarkdownDescription: "Generate random password when create user. The password is generated again when `plugin` or `pgp_key` changes. " +
+							"Conflicts with `auth_string`.",
+						Optional: true,
+					},
+					"pgp_key": schema.StringAttribute{
+						MarkdownDescription: "A PGP public key, ASCII armored or base64-encoded (e.g. `gpg --export <id> | base64`), " +
+							"to encrypt the generated random password with. Requires `random_password`.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("random_password")),
+						},
+					},
+					"generated_password": schema.StringAttribute{
+						MarkdownDescription: "The generated random password. Null when `pgp_key` is set.",
+						Computed:            true,
+						Sensitive:           true,
+					},
+					"encrypted_password": schema.StringAttribute{
+						MarkdownDescription: "The generated random password encrypted with `pgp_key` and base64-encoded. " +
+							"Decrypt it with `base64 -d | gpg -d`.",
+						Computed: true,
+					},
+					"initial_auth_string": schema.StringAttribute{
+						MarkdownDescription: "The password of the initial authentication (`INITIAL AUTHENTICATION IDENTIFIED BY`) of a passwordless `plugin` " +
+							"such as `authentication_webauthn`, with which the user logs in to register the device. " +
+							"Used only when the user is created. Requires MySQL 8.0.27 or later. Conflicts with `auth_string`, `auth_string_wo`, `random_password`.",
+						Optional:  true,
+						Sensitive: true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("plugin")),
+							stringvalidator.ConflictsWith(
+								path.MatchRelative().AtParent().AtName("auth_string"),
+								path.MatchRelative().AtParent().AtName("auth_string_wo"),
+								path.MatchRelative().AtParent().AtName("random_password"),
+							),
+						},
+					},
+				},
+			},
+			"additional_factor": schema.ListNestedBlock{
+				MarkdownDescription: "The second and the third authentication factors in order, `auth_option` being the first one. " +
+					"The first factor stays in `auth_option` rather than in this list, so that the configurations and the states of " +
+					"single-factor users keep working unchanged. A passwordless first factor created with `initial_auth_string`, " +
+					"which the server stores among the factors, is read back into `auth_option` too. " +
+					"Requires `auth_option` and MySQL 8.0.27 or later.",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(2),
+					listvalidator.AlsoRequires(path.MatchRoot("auth_option")),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"plugin": schema.StringAttribute{
+							MarkdownDescription: "An authentication plugin name, e.g. `authentication_ldap_sasl` or `authentication_webauthn`.",
+							Required:            true,
+						},
+						"auth_string": schema.StringAttribute{
+							MarkdownDescription: "Plain text password, which the plugin hashes (`IDENTIFIED WITH plugin BY`). Conflicts with `stored_auth_string`.",
+							Optional:            true,
+							Sensitive:           true,
+							Validators: []validator.String{
+								stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("stored_auth_string")),
+							},
+						},
+						"stored_auth_string": schema.StringAttribute{
+							MarkdownDescription: "The authentication string stored as is (`IDENTIFIED WITH plugin AS`), e.g. the DN of the LDAP user.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"tls_requirement": schema.SingleNestedBlock{
+				MarkdownDescription: "TLS requirements of the connections of the user (`REQUIRE` clause). " +
+					"Set either `type` or any combination of `issuer`, `subject` and `cipher`. " +
+					"Omit the block or `type` to require nothing (`REQUIRE NONE`).",
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						MarkdownDescription: "`NONE`, `SSL` (encrypted connections) or `X509` (encrypted connections with a valid client certificate). " +
+							"Conflicts with `issuer`, `subject`, `cipher`.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf("NONE", "SSL", "X509"),
+							stringvalidator.ConflictsWith(
+								path.MatchRelative().AtParent().AtName("issuer"),
+								path.MatchRelative().AtParent().AtName("subject"),
+								path.MatchRelative().AtParent().AtName("cipher"),
+							),
+						},
+					},
+					"issuer": schema.StringAttribute{
+						MarkdownDescription: "The issuer which the client certificate must have, e.g. `/C=US/O=Example/CN=CA`.",
+						Optional:            true,
+					},
+					"subject": schema.StringAttribute{
+						MarkdownDescription: "The subject which the client certificate must have, e.g. `/C=US/O=Example/CN=client`.",
+						Optional:            true,
+					},
+					"cipher": schema.StringAttribute{
+						MarkdownDescription: "The cipher which the connections must use, e.g. `ECDHE-RSA-AES256-GCM-SHA384`.",
 						Optional:            true,
 					},
 				},
@@ -111,12 +370,34 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 	}
 }
 
+// resourceLimitAttribute returns the schema of the resource limit of the
+// maximum number of description.
+func resourceLimitAttribute(description string) schema.Int64Attribute {
+	return schema.Int64Attribute{
+		MarkdownDescription: fmt.Sprintf("The maximum number of %s. `0` means no limit. Defaults to `0`.", description),
+		Optional:            true,
+		Computed:            true,
+		Default:             int64default.StaticInt64(0),
+		Validators: []validator.Int64{
+			int64validator.AtLeast(0),
+		},
+	}
+}
+
 func (r *UserResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
 	return []resource.ConfigValidator{
 		resourcevalidator.Conflicting(
 			path.MatchRoot("auth_option").AtName("auth_string"),
 			path.MatchRoot("auth_option").AtName("random_password"),
 		),
+		resourcevalidator.Conflicting(
+			path.MatchRoot("auth_option").AtName("auth_string_wo"),
+			path.MatchRoot("auth_option").AtName("auth_string"),
+		),
+		resourcevalidator.Conflicting(
+			path.MatchRoot("auth_option").AtName("auth_string_wo"),
+			path.MatchRoot("auth_option").AtName("random_password"),
+		),
 	}
 }
 
@@ -133,8 +414,58 @@ func (r *UserResource) Configure(ctx context.Context, req resource.ConfigureRequ
 	}
 }
 
+func (r *UserResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan *UserResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(checkAccountOptions(ctx, r.mysqlConfig, plan)...)
+
+	var authOption, stateAuthOption *AuthOptionModel
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("auth_option"), &authOption)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("auth_option"), &stateAuthOption)...)
+	}
+	if resp.Diagnostics.HasError() || authOption == nil {
+		return
+	}
+
+	generatedPassword, encryptedPassword := types.StringNull(), types.StringNull()
+	switch {
+	case !authOption.RandomPassword.ValueBool():
+	case regeneratesPassword(stateAuthOption, authOption):
+		if authOption.PGPKey.IsNull() {
+			generatedPassword = types.StringUnknown()
+		} else {
+			encryptedPassword = types.StringUnknown()
+		}
+	default:
+		generatedPassword, encryptedPassword = stateAuthOption.GeneratedPassword, stateAuthOption.EncryptedPassword
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("auth_option").AtName("generated_password"), generatedPassword)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("auth_option").AtName("encrypted_password"), encryptedPassword)...)
+
+	if !authOption.PGPKey.IsNull() && !authOption.PGPKey.IsUnknown() {
+		if _, err := readPGPKey(authOption.PGPKey.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("auth_option").AtName("pgp_key"), "Invalid PGP key", err.Error())
+		}
+	}
+	if !authOption.InitialAuthString.IsNull() {
+		resp.Diagnostics.Append(checkCapability(ctx, r.mysqlConfig, path.Root("auth_option").AtName("initial_auth_string"), "INITIAL AUTHENTICATION", func(c Capabilities) bool { return c.MultiFactorAuth })...)
+	}
+	if !authOption.RandomPassword.ValueBool() {
+		return
+	}
+	resp.Diagnostics.Append(checkCapability(ctx, r.mysqlConfig, path.Root("auth_option").AtName("random_password"), "Random password", func(c Capabilities) bool { return c.RandomPassword })...)
+}
+
 func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -146,73 +477,55 @@ func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	callExec := true
-	var args []interface{}
-	args = append(args, data.Name.ValueString())
-	args = append(args, data.Host.ValueString())
-	sql := `CREATE USER ?@?`
+	var authOption *AuthOptionModel
 	if !data.AuthOption.IsNull() {
-		var authOption *AuthOptionModel
 		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("auth_option"), &authOption)...)
-		if authOption.Plugin.IsNull() {
-			if authOption.RandomPassword.ValueBool() {
-				sql += ` IDENTIFIED BY RANDOM PASSWORD`
-			} else if !authOption.AuthString.IsNull() {
-				sql += ` IDENTIFIED BY ?`
-				args = append(args, authOption.AuthString.ValueString())
-			} else {
-				resp.Diagnostics.AddWarning("Could not add IDENTIFIED clause without plugin", "")
-			}
-		} else {
-			plugin := authOption.Plugin.ValueString()
-			if plugin == awsAuthenticationPlugin {
-				sql += fmt.Sprintf(` IDENTIFIED WITH %s AS 'RDS'`, plugin)
-			} else {
-				sql += fmt.Sprintf(` IDENTIFIED WITH %s`, plugin)
-				if authOption.RandomPassword.ValueBool() {
-					sql += ` BY RANDOM PASSWORD`
-				} else if !authOption.AuthString.IsNull() {
-					sql += ` BY ?`
-					args = append(args, authOption.AuthString.ValueString())
-				}
-			}
+		resp.Diagnostics.Append(applyWriteOnlyAuthString(ctx, req.Config, authOption)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if lacksIdentifiedClause(authOption) {
+			resp.Diagnostics.AddWarning("Could not add IDENTIFIED clause without plugin", "")
 		}
 	}
-	if data.Lock.ValueBool() {
-		sql += ` ACCOUNT LOCK`
+	options, diags := accountOptionsClause(ctx, data, &userDefaults)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-	if callExec {
-		_, err = db.ExecContext(ctx, sql, args...)
-		if err != nil {
-			resp.Diagnostics.AddError("Failed creating user", err.Error())
-		}
+	attribute, diags := userAttributeClause(ctx, data, &userDefaults)
+	resp.Diagnostics.Append(diags...)
+	factors, diags := authFactors(ctx, data.AdditionalFactors)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	stmt := createUserStatement(conn.Flavor, data.Name.ValueString(), data.Host.ValueString(), authOption, factors, options, data.Lock.ValueBool(), attribute)
+	var generatedPassword string
+	if authOption != nil && authOption.RandomPassword.ValueBool() {
+		err = conn.execRows(ctx, stmt, func(rows *sql.Rows) error {
+			return scanGeneratedPassword(rows, &generatedPassword)
+		})
 	} else {
-		rows, err := db.QueryContext(ctx, sql, args...)
-		if err != nil {
-			resp.Diagnostics.AddError("Failed creating user", err.Error())
-		}
-		defer func() { _ = rows.Close() }()
-		for rows.Next() {
-			var _host, _user, generatedPassword, _authFactor string
-			if err = rows.Scan(&_host, &_user, &generatedPassword, &_authFactor); err != nil {
-				resp.Diagnostics.AddError("Failed scanning MySQL rows", err.Error())
-				return
-			}
-			resp.Diagnostics.AddWarning(
-				fmt.Sprintf("Generated password: %s", generatedPassword),
-				"The generated password is not saved in tfstate")
-		}
+		err = conn.exec(ctx, stmt)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed creating user", err.Error())
 	}
 
 	data.ID = types.StringValue(fmt.Sprintf("%s@%s", data.Name.ValueString(), data.Host.ValueString()))
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	if authOption != nil && authOption.RandomPassword.ValueBool() {
+		resp.Diagnostics.Append(setGeneratedPassword(ctx, &resp.State, authOption.PGPKey, generatedPassword)...)
+	}
 }
 
 func (r *UserResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	if readWhileUnknown(r.mysqlConfig) {
+		return
+	}
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -226,60 +539,92 @@ func (r *UserResource) Read(ctx context.Context, req resource.ReadRequest, resp
 
 	user := data.Name.ValueString()
 	host := data.Host.ValueString()
-	var args []interface{}
-	args = append(args, host)
-	args = append(args, user)
-
-	sql := `
-SELECT
-  Host
-, User
-, plugin
-, authentication_string
-, account_locked
-FROM
-   mysql.user
-WHERE
-  Host = ?
-  AND User = ?
-`
-	tflog.Info(ctx, sql, map[string]any{"args": args})
+	stmt := readUserStatement(conn.Flavor, conn.Capabilities, user, host)
+	tflog.Info(ctx, stmt.query, map[string]any{"args": stmt.args})
 	var _host, _user, plugin, authString, accountLocked string
-	if err = db.QueryRowContext(ctx, sql, args...).Scan(&_host, &_user, &plugin, &authString, &accountLocked); err != nil {
+	var maxQueries, maxUpdates, maxConnections, maxUserConnections, failedLoginAttempts, passwordLockTime int64
+	var passwordLifetime, passwordHistory, passwordReuseTime sql.NullInt64
+	var passwordRequireCurrent sql.NullString
+	var sslType, x509Issuer, x509Subject, sslCipher, metadata, multiFactorAuth string
+	if err = conn.queryRow(ctx, stmt, &_host, &_user, &plugin, &authString, &accountLocked,
+		&maxQueries, &maxUpdates, &maxConnections, &maxUserConnections,
+		&passwordLifetime, &passwordHistory, &passwordReuseTime, &passwordRequireCurrent,
+		&failedLoginAttempts, &passwordLockTime,
+		&sslType, &x509Issuer, &x509Subject, &sslCipher, &metadata, &multiFactorAuth); errors.Is(err, errNotFound) {
 		resp.State.RemoveResource(ctx)
 		return
+	} else if err != nil {
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed reading user (%s@%s)", user, host), err.Error())
+		return
 	} else {
 		data.Name = types.StringValue(user)
 		data.Host = types.StringValue(host)
 		data.Lock = types.BoolValue(accountLocked == "Y")
+		data.MaxQueriesPerHour = types.Int64Value(maxQueries)
+		data.MaxUpdatesPerHour = types.Int64Value(maxUpdates)
+		data.MaxConnectionsPerHour = types.Int64Value(maxConnections)
+		data.MaxUserConnections = types.Int64Value(maxUserConnections)
+		data.PasswordExpireInterval = nullInt64Value(passwordLifetime)
+		data.PasswordHistory = nullInt64Value(passwordHistory)
+		data.PasswordReuseInterval = nullInt64Value(passwordReuseTime)
+		data.PasswordRequireCurrent = types.BoolNull()
+		if passwordRequireCurrent.Valid {
+			data.PasswordRequireCurrent = types.BoolValue(passwordRequireCurrent.String == "Y")
+		}
+		data.FailedLoginAttempts = types.Int64Value(failedLoginAttempts)
+		data.PasswordLockTime = types.Int64Value(passwordLockTime)
+		// Keep the configured requirement unless it differs from the server,
+		// e.g. an omitted block for REQUIRE NONE.
+		tlsRequirement := tlsRequirementValue(sslType, x509Issuer, x509Subject, sslCipher)
+		serverRequire, diags := requireClause(ctx, tlsRequirement)
+		resp.Diagnostics.Append(diags...)
+		stateRequire, diags := requireClause(ctx, data.TLSRequirement)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if serverRequire != stateRequire {
+			data.TLSRequirement = tlsRequirement
+		}
+		data.Comment, data.Attributes, err = userMetadataValues(metadata, data.Attributes)
+		if err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed reading the attributes of user (%s@%s)", user, host), err.Error())
+			return
+		}
+		factors, err := parseAuthFactors(multiFactorAuth)
+		if err != nil {
+			resp.Diagnostics.AddError(fmt.Sprintf("Failed reading the authentication factors of user (%s@%s)", user, host), err.Error())
+			return
+		}
+		// A passwordless factor, e.g. authentication_webauthn created with
+		// INITIAL AUTHENTICATION, is the first factor of auth_option.
+		if len(factors) > 0 && factors[0].Passwordless != 0 {
+			plugin = factors[0].Plugin
+			factors = factors[1:]
+		}
+		data.AdditionalFactors, diags = authFactorsValue(ctx, factors, data.AdditionalFactors)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 
 		if data.AuthOption.IsNull() {
-			// https://dev.mysql.com/doc/refman/8.4/en/native-pluggable-authentication.html
-			// The mysql_native_password authentication plugin is deprecated as of MySQL 8.0.34, disabled by default in MySQL 8.4,
-			// and removed as of MySQL 9.0.0.
-			// See https://dev.mysql.com/doc/refman/8.0/en/server-system-variables.html#sysvar_default_authentication_plugin
-			var defaultAuthenticationPlugin string
-			err := db.QueryRowContext(ctx, "SELECT @@default_authentication_plugin").Scan(&defaultAuthenticationPlugin)
+			defaultAuthenticationPlugin, err := queryDefaultAuthenticationPlugin(ctx, conn)
 			if err != nil {
-				// Check if error is specifically about the unknown variable (MySQL 8.4+)
-				if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1193 {
-					// ER_UNKNOWN_SYSTEM_VARIABLE: For MySQL 8.4+ where default_authentication_plugin is removed
-					// Default authentication plugin is caching_sha2_password
-					defaultAuthenticationPlugin = "caching_sha2_password"
-					tflog.Info(ctx, fmt.Sprintf("Using hardcoded default plugin for MySQL 8.4+: %s", defaultAuthenticationPlugin))
-				} else {
-					// Other database errors should be surfaced
-					resp.Diagnostics.AddError("Failed to query default authentication plugin", err.Error())
-					return
-				}
-			} else {
-				tflog.Info(ctx, fmt.Sprintf("default_authentication_plugin=%s", defaultAuthenticationPlugin))
+				resp.Diagnostics.AddError("Failed to query default authentication plugin", err.Error())
+				return
 			}
 			if plugin != defaultAuthenticationPlugin {
 				attributes := map[string]attr.Value{
-					"plugin":          types.StringValue(plugin),
-					"auth_string":     types.StringNull(),
-					"random_password": types.BoolNull(),
+					"plugin":                 types.StringValue(plugin),
+					"auth_string":            types.StringNull(),
+					"auth_string_wo":         types.StringNull(),
+					"auth_string_wo_version": types.Int64Null(),
+					"random_password":        types.BoolNull(),
+					"pgp_key":                types.StringNull(),
+					"generated_password":     types.StringNull(),
+					"encrypted_password":     types.StringNull(),
+					"initial_auth_string":    types.StringNull(),
 				}
 				data.AuthOption = types.ObjectValueMust(AuthOptionModelTypes, attributes)
 			}
@@ -296,7 +641,16 @@ WHERE
 			if !authOption.AuthString.IsNull() {
 				attributes["auth_string"] = authOption.AuthString
 			}
+			// The write-only password is never read back.
+			attributes["auth_string_wo"] = types.StringNull()
+			attributes["auth_string_wo_version"] = authOption.AuthStringWOVersion
 			attributes["random_password"] = authOption.RandomPassword
+			// The generated password is never read back either.
+			attributes["pgp_key"] = authOption.PGPKey
+			attributes["generated_password"] = authOption.GeneratedPassword
+			attributes["encrypted_password"] = authOption.EncryptedPassword
+			// The initial password is used only when the user is created.
+			attributes["initial_auth_string"] = authOption.InitialAuthString
 
 			data.AuthOption = types.ObjectValueMust(AuthOptionModelTypes, attributes)
 		}
@@ -306,7 +660,7 @@ WHERE
 }
 
 func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -319,70 +673,80 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	var args []interface{}
-	args = append(args, data.Name.ValueString())
-
-	sql := `ALTER USER ?`
-	if !data.Host.IsNull() {
-		sql += `@?`
-		args = append(args, data.Host.ValueString())
-	}
-
+	var authOption *AuthOptionModel
 	if !data.AuthOption.IsNull() {
-		var authOption *AuthOptionModel
 		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("auth_option"), &authOption)...)
-		if authOption.Plugin.IsNull() {
-			if authOption.RandomPassword.ValueBool() {
-				sql += ` IDENTIFIED BY RANDOM PASSWORD`
-			} else if !authOption.AuthString.IsNull() {
-				sql += ` IDENTIFIED BY ?`
-				args = append(args, authOption.AuthString.ValueString())
-			} else {
-				resp.Diagnostics.AddWarning("Could not add IDENTIFIED clause without plugin", "")
-			}
-		} else {
-			plugin := authOption.Plugin.ValueString()
-			if plugin == awsAuthenticationPlugin {
-				sql += fmt.Sprintf(` IDENTIFIED WITH %s AS 'RDS'`, plugin)
-			} else {
-				sql += fmt.Sprintf(` IDENTIFIED WITH %s`, plugin)
-				if authOption.RandomPassword.ValueBool() {
-					sql += ` BY RANDOM PASSWORD`
-				} else if !authOption.AuthString.IsNull() {
-					sql += ` BY ?`
-					args = append(args, authOption.AuthString.ValueString())
-				}
-			}
+		var stateAuthOption *AuthOptionModel
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("auth_option"), &stateAuthOption)...)
+		resp.Diagnostics.Append(applyWriteOnlyAuthString(ctx, req.Config, authOption)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		// The write-only password is set again only when its version or the
+		// plugin changes, and the random password as regeneratesPassword
+		// tells.
+		switch {
+		case !authOption.AuthStringWO.IsNull() && stateAuthOption != nil &&
+			authOption.AuthStringWOVersion.Equal(stateAuthOption.AuthStringWOVersion) &&
+			authOption.Plugin.Equal(stateAuthOption.Plugin):
+			authOption = nil
+		case authOption.RandomPassword.ValueBool() && !regeneratesPassword(stateAuthOption, authOption):
+			authOption = nil
+		case !authOption.InitialAuthString.IsNull() && stateAuthOption != nil &&
+			authOption.Plugin.Equal(stateAuthOption.Plugin):
+			// The passwordless factor registered by the user must not be
+			// reset.
+			authOption = nil
+		case lacksIdentifiedClause(authOption):
+			resp.Diagnostics.AddWarning("Could not add IDENTIFIED clause without plugin", "")
 		}
 	}
-	if data.Lock.ValueBool() {
-		sql += ` ACCOUNT LOCK`
+	host := "%"
+	if !data.Host.IsNull() {
+		host = data.Host.ValueString()
+	}
+	options, diags := accountOptionsClause(ctx, data, state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	attribute, diags := userAttributeClause(ctx, data, state)
+	resp.Diagnostics.Append(diags...)
+	factors, diags := authFactors(ctx, data.AdditionalFactors)
+	resp.Diagnostics.Append(diags...)
+	stateFactors, diags := authFactors(ctx, state.AdditionalFactors)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	stmt := alterUserStatement(conn.Flavor, data.Name.ValueString(), host, authOption, options, data.Lock.ValueBool(), attribute)
+	var generatedPassword string
+	if authOption != nil && authOption.RandomPassword.ValueBool() {
+		err = conn.execRows(ctx, stmt, func(rows *sql.Rows) error {
+			return scanGeneratedPassword(rows, &generatedPassword)
+		})
 	} else {
-		sql += ` ACCOUNT UNLOCK`
+		err = conn.exec(ctx, stmt)
 	}
-
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-	rows, err := db.QueryContext(ctx, sql, args...)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed creating user", err.Error())
-	}
-	defer func() { _ = rows.Close() }()
-	for rows.Next() {
-		var _host, _user, generatedPassword, _authFactor string
-		if err = rows.Scan(&_host, &_user, &generatedPassword, &_authFactor); err != nil {
-			resp.Diagnostics.AddError("Failed scanning MySQL rows", err.Error())
-			return
+	} else {
+		for _, stmt := range alterFactorsStatements(conn.Flavor, data.Name.ValueString(), host, factors, stateFactors) {
+			if err := conn.exec(ctx, stmt); err != nil {
+				resp.Diagnostics.AddError("Failed altering the authentication factors", err.Error())
+				break
+			}
 		}
-		resp.Diagnostics.AddWarning(
-			fmt.Sprintf("Generated password: %s", generatedPassword),
-			"The generated password is not saved in tfstate")
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	if authOption != nil && authOption.RandomPassword.ValueBool() {
+		resp.Diagnostics.Append(setGeneratedPassword(ctx, &resp.State, authOption.PGPKey, generatedPassword)...)
+	}
 }
 
 func (r *UserResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	db, err := getDatabase(ctx, r.mysqlConfig)
+	conn, err := getConnection(ctx, r.mysqlConfig)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to connect MySQL", err.Error())
 		return
@@ -397,15 +761,10 @@ func (r *UserResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	user := data.Name.ValueString()
 	host := data.Host.ValueString()
 
-	sql := `DROP USER ?@?`
-	var args []interface{}
-	args = append(args, user)
-	args = append(args, host)
-	tflog.Info(ctx, sql, map[string]any{"args": args})
-
-	_, err = db.ExecContext(ctx, sql, args...)
+	account, args := conn.Flavor.account(user, host)
+	err = conn.exec(ctx, sqlStatement{query: `DROP USER ` + account, args: args})
 	if err != nil {
-		resp.Diagnostics.AddError(fmt.Sprintf("Failed deleting user (%s@%s)", args...), err.Error())
+		resp.Diagnostics.AddError(fmt.Sprintf("Failed deleting user (%s@%s)", user, host), err.Error())
 		return
 	}
 }
@@ -420,3 +779,668 @@ func (r *UserResource) ImportState(ctx context.Context, req resource.ImportState
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("host"), types.StringValue(userHost[1]))...)
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
+
+// scanGeneratedPassword scans the password generated by RANDOM PASSWORD from
+// a row of CREATE USER or ALTER USER into password. The row is the user, the
+// host, the password and, since MySQL 8.0.27, the authentication factor.
+func scanGeneratedPassword(rows *sql.Rows, password *string) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) < 3 {
+		return fmt.Errorf("unexpected columns of the generated password: %v", columns)
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return fmt.Errorf("failed scanning MySQL rows: %w", err)
+	}
+	*password = values[2].String
+	return nil
+}
+
+// regeneratesPassword reports whether the random password is generated again,
+// i.e. random_password is newly set or the plugin or pgp_key changes. The
+// prior password cannot be encrypted for a new key, since it is not kept.
+func regeneratesPassword(state, plan *AuthOptionModel) bool {
+	if !plan.RandomPassword.ValueBool() {
+		return false
+	}
+	return state == nil || !state.RandomPassword.ValueBool() ||
+		!plan.Plugin.Equal(state.Plugin) || !plan.PGPKey.Equal(state.PGPKey)
+}
+
+// setGeneratedPassword saves password, which RANDOM PASSWORD generated, to
+// state, encrypted when pgpKey is set. The password is empty in dry run mode,
+// and saved as null then.
+func setGeneratedPassword(ctx context.Context, state *tfsdk.State, pgpKey types.String, password string) diag.Diagnostics {
+	generatedPassword, encryptedPassword := types.StringNull(), types.StringNull()
+	var diags diag.Diagnostics
+	switch {
+	case password == "":
+	case pgpKey.IsNull():
+		generatedPassword = types.StringValue(password)
+	default:
+		encrypted, err := encryptWithPGPKey(pgpKey.ValueString(), password)
+		if err != nil {
+			diags.AddAttributeError(path.Root("auth_option").AtName("pgp_key"), "Failed encrypting the generated password", err.Error())
+		} else {
+			encryptedPassword = types.StringValue(encrypted)
+		}
+	}
+	diags.Append(state.SetAttribute(ctx, path.Root("auth_option").AtName("generated_password"), generatedPassword)...)
+	diags.Append(state.SetAttribute(ctx, path.Root("auth_option").AtName("encrypted_password"), encryptedPassword)...)
+	return diags
+}
+
+// applyWriteOnlyAuthString sets the auth_string_wo of config, which is null in
+// the plan, to authOption. The password is used as auth_string then.
+func applyWriteOnlyAuthString(ctx context.Context, config tfsdk.Config, authOption *AuthOptionModel) diag.Diagnostics {
+	var authString types.String
+	diags := config.GetAttribute(ctx, path.Root("auth_option").AtName("auth_string_wo"), &authString)
+	if diags.HasError() || authString.IsNull() {
+		return diags
+	}
+	authOption.AuthStringWO = authString
+	authOption.AuthString = authString
+	return diags
+}
+
+// lacksIdentifiedClause reports whether authOption sets neither a plugin nor a
+// password, in which case no IDENTIFIED clause is added.
+func lacksIdentifiedClause(authOption *AuthOptionModel) bool {
+	return authOption.Plugin.IsNull() && !authOption.RandomPassword.ValueBool() && authOption.AuthString.IsNull()
+}
+
+// identifiedClause returns the IDENTIFIED clause of CREATE USER and ALTER USER
+// for authOption. MariaDB takes the plugin with VIA and the password as a
+// literal. The password is a secret of the clause.
+func identifiedClause(flavor Flavor, authOption *AuthOptionModel) sqlStatement {
+	if authOption == nil {
+		return sqlStatement{}
+	}
+	authString := authOption.AuthString.ValueString()
+	password := func(prefix string) sqlStatement {
+		if flavor == FlavorMariaDB {
+			return sqlStatement{query: prefix + quoteString(authString), secrets: []string{authString}}
+		}
+		return sqlStatement{query: prefix + "?", args: []any{authString}, secrets: []string{authString}}
+	}
+
+	if authOption.Plugin.IsNull() {
+		switch {
+		case authOption.RandomPassword.ValueBool():
+			return sqlStatement{query: ` IDENTIFIED BY RANDOM PASSWORD`}
+		case !authOption.AuthString.IsNull():
+			return password(` IDENTIFIED BY `)
+		default:
+			return sqlStatement{}
+		}
+	}
+
+	plugin := authOption.Plugin.ValueString()
+	if plugin == awsAuthenticationPlugin {
+		return sqlStatement{query: fmt.Sprintf(` IDENTIFIED WITH %s AS 'RDS'`, plugin)}
+	}
+	if flavor == FlavorMariaDB {
+		clause := sqlStatement{query: fmt.Sprintf(` IDENTIFIED VIA %s`, plugin)}
+		if !authOption.AuthString.IsNull() {
+			clause.query += fmt.Sprintf(` USING PASSWORD(%s)`, quoteString(authString))
+			clause.secrets = []string{authString}
+		}
+		return clause
+	}
+	clause := sqlStatement{query: fmt.Sprintf(` IDENTIFIED WITH %s`, plugin)}
+	switch {
+	case authOption.RandomPassword.ValueBool():
+		clause.query += ` BY RANDOM PASSWORD`
+	case !authOption.AuthString.IsNull():
+		passwordClause := password(` BY `)
+		clause.query += passwordClause.query
+		clause.args = passwordClause.args
+		clause.secrets = passwordClause.secrets
+	}
+	return clause
+}
+
+func createUserStatement(flavor Flavor, name, host string, authOption *AuthOptionModel, factors []AuthFactorModel, options string, lock bool, attribute string) sqlStatement {
+	account, args := flavor.account(name, host)
+	clause := identifiedClause(flavor, authOption)
+	if authOption != nil && !authOption.InitialAuthString.IsNull() {
+		initial := authOption.InitialAuthString.ValueString()
+		clause.query += ` INITIAL AUTHENTICATION IDENTIFIED BY ?`
+		clause.args = append(clause.args, initial)
+		clause.secrets = append(clause.secrets, initial)
+	}
+	for _, factor := range factors {
+		factorClause := factorIdentifiedClause(factor)
+		clause.query += ` AND` + factorClause.query
+		clause.args = append(clause.args, factorClause.args...)
+		clause.secrets = append(clause.secrets, factorClause.secrets...)
+	}
+	sql := `CREATE USER ` + account + clause.query + options
+	args = append(args, clause.args...)
+	if lock {
+		sql += ` ACCOUNT LOCK`
+	}
+	sql += attribute
+	return sqlStatement{query: sql, args: args, secrets: clause.secrets}
+}
+
+func alterUserStatement(flavor Flavor, name, host string, authOption *AuthOptionModel, options string, lock bool, attribute string) sqlStatement {
+	account, args := flavor.account(name, host)
+	clause := identifiedClause(flavor, authOption)
+	sql := `ALTER USER ` + account + clause.query + options
+	args = append(args, clause.args...)
+	if lock {
+		sql += ` ACCOUNT LOCK`
+	} else {
+		sql += ` ACCOUNT UNLOCK`
+	}
+	sql += attribute
+	return sqlStatement{query: sql, args: args, secrets: clause.secrets}
+}
+
+// authFactors returns the additional factors of list, which are empty when
+// list is null.
+func authFactors(ctx context.Context, list types.List) ([]AuthFactorModel, diag.Diagnostics) {
+	var factors []AuthFactorModel
+	if list.IsNull() || list.IsUnknown() {
+		return factors, nil
+	}
+	diags := list.ElementsAs(ctx, &factors, false)
+	return factors, diags
+}
+
+// factorIdentifiedClause returns the IDENTIFIED WITH clause of the second or
+// the third authentication factor, which only MySQL supports.
+func factorIdentifiedClause(factor AuthFactorModel) sqlStatement {
+	clause := sqlStatement{query: ` IDENTIFIED WITH ` + factor.Plugin.ValueString()}
+	switch {
+	case !factor.AuthString.IsNull():
+		clause.query += ` BY ?`
+		clause.args = []any{factor.AuthString.ValueString()}
+		clause.secrets = []string{factor.AuthString.ValueString()}
+	case !factor.StoredAuthString.IsNull():
+		clause.query += ` AS ?`
+		clause.args = []any{factor.StoredAuthString.ValueString()}
+	}
+	return clause
+}
+
+// alterFactorsStatements returns the ALTER USER statements which change the
+// additional factors of state to those of plan. The password of a factor is
+// modified in place (MODIFY n FACTOR). The factors from the first one whose
+// plugin or stored authentication string differs are dropped (DROP n FACTOR)
+// and added again (ADD n FACTOR), since MySQL cannot change them in place.
+func alterFactorsStatements(flavor Flavor, name, host string, plan, state []AuthFactorModel) []sqlStatement {
+	account, accountArgs := flavor.account(name, host)
+	statement := func(clause sqlStatement) sqlStatement {
+		return sqlStatement{
+			query:   `ALTER USER ` + account + clause.query,
+			args:    append(append([]any{}, accountArgs...), clause.args...),
+			secrets: clause.secrets,
+		}
+	}
+
+	var modify, drop, add sqlStatement
+	kept := 0
+	for ; kept < len(plan) && kept < len(state); kept++ {
+		p, s := plan[kept], state[kept]
+		if !p.Plugin.Equal(s.Plugin) || !p.StoredAuthString.Equal(s.StoredAuthString) {
+			break
+		}
+		// A password which is removed from the configuration is kept.
+		if !p.AuthString.IsNull() && !p.AuthString.Equal(s.AuthString) {
+			modify.query += fmt.Sprintf(` MODIFY %d FACTOR IDENTIFIED BY ?`, kept+2)
+			modify.args = append(modify.args, p.AuthString.ValueString())
+			modify.secrets = append(modify.secrets, p.AuthString.ValueString())
+		}
+	}
+	for i := kept; i < len(state); i++ {
+		drop.query += fmt.Sprintf(` DROP %d FACTOR`, i+2)
+	}
+	for i := kept; i < len(plan); i++ {
+		clause := factorIdentifiedClause(plan[i])
+		add.query += fmt.Sprintf(` ADD %d FACTOR`, i+2) + clause.query
+		add.args = append(add.args, clause.args...)
+		add.secrets = append(add.secrets, clause.secrets...)
+	}
+
+	var stmts []sqlStatement
+	for _, clause := range []sqlStatement{modify, drop, add} {
+		if clause.query != "" {
+			stmts = append(stmts, statement(clause))
+		}
+	}
+	return stmts
+}
+
+// authFactor is an element of the multi_factor_authentication array of
+// mysql.user.User_attributes.
+type authFactor struct {
+	Plugin               string `json:"plugin"`
+	Passwordless         int    `json:"passwordless"`
+	AuthenticationString string `json:"authentication_string"`
+	RequiresRegistration int    `json:"requires_registration"`
+}
+
+// parseAuthFactors parses the multi_factor_authentication array of
+// mysql.user.User_attributes, which is empty for users without additional
+// factors.
+func parseAuthFactors(value string) ([]authFactor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var factors []authFactor
+	if err := json.Unmarshal([]byte(value), &factors); err != nil {
+		return nil, err
+	}
+	return factors, nil
+}
+
+// authFactorsValue returns the additional factors read from the server. The
+// passwords, which cannot be read back, are kept from state, as are the
+// stored authentication strings unless state has them.
+func authFactorsValue(ctx context.Context, factors []authFactor, state types.List) (types.List, diag.Diagnostics) {
+	var stateFactors []AuthFactorModel
+	if !state.IsNull() && !state.IsUnknown() {
+		if diags := state.ElementsAs(ctx, &stateFactors, false); diags.HasError() {
+			return state, diags
+		}
+	}
+	if len(factors) == 0 && len(stateFactors) == 0 {
+		return state, nil
+	}
+	models := make([]AuthFactorModel, len(factors))
+	for i, factor := range factors {
+		models[i] = AuthFactorModel{
+			Plugin:           types.StringValue(factor.Plugin),
+			AuthString:       types.StringNull(),
+			StoredAuthString: types.StringNull(),
+		}
+		if i < len(stateFactors) {
+			models[i].AuthString = stateFactors[i].AuthString
+			if !stateFactors[i].StoredAuthString.IsNull() {
+				models[i].StoredAuthString = types.StringValue(factor.AuthenticationString)
+			}
+		}
+	}
+	return types.ListValueFrom(ctx, types.ObjectType{AttrTypes: AuthFactorModelTypes}, models)
+}
+
+// accountOptionsClause returns the TLS requirement (REQUIRE ...), the
+// resource limits (WITH ...) and the password options of CREATE USER and
+// ALTER USER which differ between plan and state. Null password options are
+// set to DEFAULT.
+func accountOptionsClause(ctx context.Context, plan, state *UserResourceModel) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	planRequire, d := requireClause(ctx, plan.TLSRequirement)
+	diags.Append(d...)
+	stateRequire, d := requireClause(ctx, state.TLSRequirement)
+	diags.Append(d...)
+	if diags.HasError() {
+		return "", diags
+	}
+
+	var limits, options []string
+	limit := func(name string, planValue, stateValue types.Int64) {
+		if !planValue.IsNull() && !planValue.IsUnknown() && !planValue.Equal(stateValue) {
+			limits = append(limits, fmt.Sprintf("%s %d", name, planValue.ValueInt64()))
+		}
+	}
+	limit("MAX_QUERIES_PER_HOUR", plan.MaxQueriesPerHour, state.MaxQueriesPerHour)
+	limit("MAX_UPDATES_PER_HOUR", plan.MaxUpdatesPerHour, state.MaxUpdatesPerHour)
+	limit("MAX_CONNECTIONS_PER_HOUR", plan.MaxConnectionsPerHour, state.MaxConnectionsPerHour)
+	limit("MAX_USER_CONNECTIONS", plan.MaxUserConnections, state.MaxUserConnections)
+
+	if !plan.PasswordExpireInterval.Equal(state.PasswordExpireInterval) {
+		switch {
+		case plan.PasswordExpireInterval.IsNull():
+			options = append(options, "PASSWORD EXPIRE DEFAULT")
+		case plan.PasswordExpireInterval.ValueInt64() == 0:
+			options = append(options, "PASSWORD EXPIRE NEVER")
+		default:
+			options = append(options, fmt.Sprintf("PASSWORD EXPIRE INTERVAL %d DAY", plan.PasswordExpireInterval.ValueInt64()))
+		}
+	}
+	if !plan.PasswordHistory.Equal(state.PasswordHistory) {
+		if plan.PasswordHistory.IsNull() {
+			options = append(options, "PASSWORD HISTORY DEFAULT")
+		} else {
+			options = append(options, fmt.Sprintf("PASSWORD HISTORY %d", plan.PasswordHistory.ValueInt64()))
+		}
+	}
+	if !plan.PasswordReuseInterval.Equal(state.PasswordReuseInterval) {
+		if plan.PasswordReuseInterval.IsNull() {
+			options = append(options, "PASSWORD REUSE INTERVAL DEFAULT")
+		} else {
+			options = append(options, fmt.Sprintf("PASSWORD REUSE INTERVAL %d DAY", plan.PasswordReuseInterval.ValueInt64()))
+		}
+	}
+	if !plan.PasswordRequireCurrent.Equal(state.PasswordRequireCurrent) {
+		switch {
+		case plan.PasswordRequireCurrent.IsNull():
+			options = append(options, "PASSWORD REQUIRE CURRENT DEFAULT")
+		case plan.PasswordRequireCurrent.ValueBool():
+			options = append(options, "PASSWORD REQUIRE CURRENT")
+		default:
+			options = append(options, "PASSWORD REQUIRE CURRENT OPTIONAL")
+		}
+	}
+	if !plan.FailedLoginAttempts.IsNull() && !plan.FailedLoginAttempts.Equal(state.FailedLoginAttempts) {
+		options = append(options, fmt.Sprintf("FAILED_LOGIN_ATTEMPTS %d", plan.FailedLoginAttempts.ValueInt64()))
+	}
+	if !plan.PasswordLockTime.IsNull() && !plan.PasswordLockTime.Equal(state.PasswordLockTime) {
+		if plan.PasswordLockTime.ValueInt64() < 0 {
+			options = append(options, "PASSWORD_LOCK_TIME UNBOUNDED")
+		} else {
+			options = append(options, fmt.Sprintf("PASSWORD_LOCK_TIME %d", plan.PasswordLockTime.ValueInt64()))
+		}
+	}
+
+	var clause string
+	if planRequire != stateRequire {
+		clause += " " + planRequire
+	}
+	if len(limits) > 0 {
+		clause += " WITH " + strings.Join(limits, " ")
+	}
+	if len(options) > 0 {
+		clause += " " + strings.Join(options, " ")
+	}
+	return clause, diags
+}
+
+// requireClause returns the REQUIRE clause of tls. A null or empty
+// requirement is REQUIRE NONE.
+func requireClause(ctx context.Context, tls types.Object) (string, diag.Diagnostics) {
+	if tls.IsNull() || tls.IsUnknown() {
+		return "REQUIRE NONE", nil
+	}
+	var data TLSRequirementModel
+	diags := tls.As(ctx, &data, basetypes.ObjectAsOptions{})
+	if diags.HasError() {
+		return "", diags
+	}
+	var options []string
+	for _, option := range []struct {
+		name  string
+		value types.String
+	}{
+		{"ISSUER", data.Issuer},
+		{"SUBJECT", data.Subject},
+		{"CIPHER", data.Cipher},
+	} {
+		if !option.value.IsNull() {
+			options = append(options, option.name+" "+quoteString(option.value.ValueString()))
+		}
+	}
+	switch {
+	case len(options) > 0:
+		return "REQUIRE " + strings.Join(options, " AND "), diags
+	case data.Type.IsNull():
+		return "REQUIRE NONE", diags
+	default:
+		return "REQUIRE " + data.Type.ValueString(), diags
+	}
+}
+
+// tlsRequirementValue returns the TLS requirement of the ssl_type (empty,
+// ANY, X509 or SPECIFIED), the issuer, the subject and the cipher of
+// mysql.user.
+func tlsRequirementValue(sslType, issuer, subject, cipher string) types.Object {
+	optional := func(s string) types.String {
+		if s == "" {
+			return types.StringNull()
+		}
+		return types.StringValue(s)
+	}
+	requirementType := types.StringNull()
+	switch sslType {
+	case "":
+		requirementType = types.StringValue("NONE")
+	case "ANY":
+		requirementType = types.StringValue("SSL")
+	case "X509":
+		requirementType = types.StringValue("X509")
+	}
+	return types.ObjectValueMust(TLSRequirementModelTypes, map[string]attr.Value{
+		"type":    requirementType,
+		"issuer":  optional(issuer),
+		"subject": optional(subject),
+		"cipher":  optional(cipher),
+	})
+}
+
+// userAttributeClause returns the ATTRIBUTE clause of CREATE USER and ALTER
+// USER with the comment and the attributes which differ between plan and
+// state. The server merges the JSON object into the metadata of the user, so
+// removed keys are set to null. COMMENT is not used since a statement cannot
+// have both.
+func userAttributeClause(ctx context.Context, plan, state *UserResourceModel) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	planAttributes, stateAttributes := map[string]string{}, map[string]string{}
+	if !plan.Attributes.IsNull() && !plan.Attributes.IsUnknown() {
+		diags.Append(plan.Attributes.ElementsAs(ctx, &planAttributes, false)...)
+	}
+	if !state.Attributes.IsNull() && !state.Attributes.IsUnknown() {
+		diags.Append(state.Attributes.ElementsAs(ctx, &stateAttributes, false)...)
+	}
+	if diags.HasError() {
+		return "", diags
+	}
+
+	changes := map[string]any{}
+	for key, value := range planAttributes {
+		if stateValue, ok := stateAttributes[key]; !ok || stateValue != value {
+			changes[key] = value
+		}
+	}
+	for key := range stateAttributes {
+		if _, ok := planAttributes[key]; !ok {
+			changes[key] = nil
+		}
+	}
+	if plan.Comment.IsNull() != state.Comment.IsNull() || plan.Comment.ValueString() != state.Comment.ValueString() {
+		changes["comment"] = nil
+		if !plan.Comment.IsNull() {
+			changes["comment"] = plan.Comment.ValueString()
+		}
+	}
+	if len(changes) == 0 {
+		return "", diags
+	}
+	attribute, err := json.Marshal(changes)
+	if err != nil {
+		diags.AddError("Failed encoding the attributes", err.Error())
+		return "", diags
+	}
+	return " ATTRIBUTE " + quoteString(string(attribute)), diags
+}
+
+// userMetadataValues returns the comment and the attributes of metadata, the
+// JSON object of the metadata of mysql.user.User_attributes. Attributes which
+// are not strings are kept as JSON. The attributes are null rather than empty
+// when attributes, the prior value, is null.
+func userMetadataValues(metadata string, attributes types.Map) (types.String, types.Map, error) {
+	comment := types.StringNull()
+	values := map[string]attr.Value{}
+	if metadata != "" {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(metadata), &object); err != nil {
+			return comment, attributes, err
+		}
+		for key, raw := range object {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				value = string(raw)
+			}
+			if key == "comment" {
+				comment = types.StringValue(value)
+			} else {
+				values[key] = types.StringValue(value)
+			}
+		}
+	}
+	if len(values) == 0 && attributes.IsNull() {
+		return comment, types.MapNull(types.StringType), nil
+	}
+	return comment, types.MapValueMust(types.StringType, values), nil
+}
+
+// checkAccountOptions returns errors for the resource limits and the password
+// options of plan which the server does not support. Options left to the
+// defaults are not checked.
+func checkAccountOptions(ctx context.Context, conf *MySQLConfiguration, plan *UserResourceModel) diag.Diagnostics {
+	resourceLimits := func(c Capabilities) bool { return c.ResourceLimits }
+	passwordReuse := func(c Capabilities) bool { return c.PasswordReuse }
+	failedLoginTracking := func(c Capabilities) bool { return c.FailedLoginTracking }
+	userAttributes := func(c Capabilities) bool { return c.UserAttributes }
+	var diags diag.Diagnostics
+	for _, option := range []struct {
+		attribute string
+		set       bool
+		feature   string
+		supported func(Capabilities) bool
+	}{
+		{"max_queries_per_hour", plan.MaxQueriesPerHour.ValueInt64() != 0, "MAX_QUERIES_PER_HOUR", resourceLimits},
+		{"max_updates_per_hour", plan.MaxUpdatesPerHour.ValueInt64() != 0, "MAX_UPDATES_PER_HOUR", resourceLimits},
+		{"max_connections_per_hour", plan.MaxConnectionsPerHour.ValueInt64() != 0, "MAX_CONNECTIONS_PER_HOUR", resourceLimits},
+		{"max_user_connections", plan.MaxUserConnections.ValueInt64() != 0, "MAX_USER_CONNECTIONS", resourceLimits},
+		{"password_expire_interval", !plan.PasswordExpireInterval.IsNull(), "PASSWORD EXPIRE", func(c Capabilities) bool { return c.PasswordExpire }},
+		{"password_history", !plan.PasswordHistory.IsNull(), "PASSWORD HISTORY", passwordReuse},
+		{"password_reuse_interval", !plan.PasswordReuseInterval.IsNull(), "PASSWORD REUSE INTERVAL", passwordReuse},
+		{"password_require_current", !plan.PasswordRequireCurrent.IsNull(), "PASSWORD REQUIRE CURRENT", func(c Capabilities) bool { return c.PasswordRequireCurrent }},
+		{"failed_login_attempts", plan.FailedLoginAttempts.ValueInt64() != 0, "FAILED_LOGIN_ATTEMPTS", failedLoginTracking},
+		{"password_lock_time", plan.PasswordLockTime.ValueInt64() != 0, "PASSWORD_LOCK_TIME", failedLoginTracking},
+		{"tls_requirement", !plan.TLSRequirement.IsNull(), "REQUIRE", func(c Capabilities) bool { return c.TLSRequirement }},
+		{"comment", !plan.Comment.IsNull(), "COMMENT", userAttributes},
+		{"attributes", len(plan.Attributes.Elements()) > 0, "ATTRIBUTE", userAttributes},
+		{"additional_factor", len(plan.AdditionalFactors.Elements()) > 0, "Multi-factor authentication", func(c Capabilities) bool { return c.MultiFactorAuth }},
+	} {
+		if option.set {
+			diags.Append(checkCapability(ctx, conf, path.Root(option.attribute), option.feature, option.supported)...)
+		}
+	}
+	return diags
+}
+
+// readUserStatement returns the query selecting the host, the name, the
+// plugin, the authentication string, whether the account is locked (Y or N),
+// the resource limits, the password lifetime, history, reuse time and
+// whether the current password is required (Y, N or NULL for the default),
+// the failed login attempts, the password lock time and the ssl_type,
+// issuer, subject and cipher of the TLS requirement, the JSON of the
+// comment and the attributes and the JSON of the additional authentication
+// factors (empty if none). The options which
+// caps lacks are selected as the defaults. MariaDB keeps them in the JSON of
+// mysql.global_priv.
+func readUserStatement(flavor Flavor, caps Capabilities, user, host string) sqlStatement {
+	column := func(supported bool, expr, otherwise string) string {
+		if supported {
+			return expr
+		}
+		return otherwise
+	}
+	if flavor == FlavorMariaDB {
+		return sqlStatement{
+			query: `
+SELECT
+  Host
+, User
+, COALESCE(JSON_VALUE(Priv, '$.plugin'), '')
+, COALESCE(JSON_VALUE(Priv, '$.authentication_string'), '')
+, IF(JSON_VALUE(Priv, '$.account_locked') = 'true', 'Y', 'N')
+, COALESCE(JSON_VALUE(Priv, '$.max_questions'), 0)
+, COALESCE(JSON_VALUE(Priv, '$.max_updates'), 0)
+, COALESCE(JSON_VALUE(Priv, '$.max_connections'), 0)
+, COALESCE(JSON_VALUE(Priv, '$.max_user_connections'), 0)
+, ` + column(caps.PasswordExpire, `NULLIF(JSON_VALUE(Priv, '$.password_lifetime'), -1)`, `NULL`) + `
+, NULL
+, NULL
+, NULL
+, 0
+, 0
+, COALESCE(ELT(JSON_VALUE(Priv, '$.ssl_type') + 1, '', 'ANY', 'X509', 'SPECIFIED'), '')
+, COALESCE(JSON_VALUE(Priv, '$.x509_issuer'), '')
+, COALESCE(JSON_VALUE(Priv, '$.x509_subject'), '')
+, COALESCE(JSON_VALUE(Priv, '$.ssl_cipher'), '')
+, ''
+, ''
+FROM
+   mysql.global_priv
+WHERE
+  Host = ?
+  AND User = ?
+`,
+			args: []any{host, user},
+		}
+	}
+	return sqlStatement{
+		query: `
+SELECT
+  Host
+, User
+, plugin
+, authentication_string
+, account_locked
+, ` + column(caps.ResourceLimits, `max_questions`, `0`) + `
+, ` + column(caps.ResourceLimits, `max_updates`, `0`) + `
+, ` + column(caps.ResourceLimits, `max_connections`, `0`) + `
+, ` + column(caps.ResourceLimits, `max_user_connections`, `0`) + `
+, ` + column(caps.PasswordExpire, `password_lifetime`, `NULL`) + `
+, ` + column(caps.PasswordReuse, `Password_reuse_history`, `NULL`) + `
+, ` + column(caps.PasswordReuse, `Password_reuse_time`, `NULL`) + `
+, ` + column(caps.PasswordRequireCurrent, `Password_require_current`, `NULL`) + `
+, ` + column(caps.FailedLoginTracking, `COALESCE(CAST(JSON_EXTRACT(User_attributes, '$.Password_locking.failed_login_attempts') AS SIGNED), 0)`, `0`) + `
+, ` + column(caps.FailedLoginTracking, `COALESCE(CAST(JSON_EXTRACT(User_attributes, '$.Password_locking.password_lock_time_days') AS SIGNED), 0)`, `0`) + `
+, ` + column(caps.TLSRequirement, `ssl_type`, `''`) + `
+, ` + column(caps.TLSRequirement, `x509_issuer`, `''`) + `
+, ` + column(caps.TLSRequirement, `x509_subject`, `''`) + `
+, ` + column(caps.TLSRequirement, `ssl_cipher`, `''`) + `
+, ` + column(caps.UserAttributes, `COALESCE(CAST(JSON_EXTRACT(User_attributes, '$.metadata') AS CHAR), '')`, `''`) + `
+, ` + column(caps.MultiFactorAuth, `COALESCE(CAST(JSON_EXTRACT(User_attributes, '$.multi_factor_authentication') AS CHAR), '')`, `''`) + `
+FROM
+   mysql.user
+WHERE
+  Host = ?
+  AND User = ?
+`,
+		args: []any{host, user},
+	}
+}
+
+// nullInt64Value returns v as an Int64 value, which is null when v is NULL.
+func nullInt64Value(v sql.NullInt64) types.Int64 {
+	if !v.Valid {
+		return types.Int64Null()
+	}
+	return types.Int64Value(v.Int64)
+}
+
+// queryDefaultAuthenticationPlugin returns the plugin of users created
+// without one.
+func queryDefaultAuthenticationPlugin(ctx context.Context, conn *OneConnection) (string, error) {
+	if !conn.Capabilities.DefaultAuthenticationPlugin {
+		// MariaDB has no default_authentication_plugin variable, and MySQL
+		// 8.4 removed it in favor of caching_sha2_password.
+		// https://dev.mysql.com/doc/refman/8.4/en/native-pluggable-authentication.html
+		if conn.Flavor == FlavorMariaDB {
+			return "mysql_native_password", nil
+		}
+		return "caching_sha2_password", nil
+	}
+	// See https://dev.mysql.com/doc/refman/8.0/en/server-system-variables.html#sysvar_default_authentication_plugin
+	var defaultAuthenticationPlugin string
+	err := conn.queryRow(ctx, sqlStatement{query: "SELECT @@default_authentication_plugin"}, &defaultAuthenticationPlugin)
+	if err != nil {
+		return "", err
+	}
+	tflog.Info(ctx, fmt.Sprintf("default_authentication_plugin=%s", defaultAuthenticationPlugin))
+	return defaultAuthenticationPlugin, nil
+}
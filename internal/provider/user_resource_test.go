@@ -1,12 +1,23 @@
 package provider
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
 	"github.com/okkez/terraform-provider-mysql/internal/utils"
 )
 
@@ -298,3 +309,894 @@ func testAccUserResource_CheckDestroy(users []UserModel) resource.TestCheckFunc
 		return nil
 	}
 }
+
+func TestUserStatements(t *testing.T) {
+	password := &AuthOptionModel{
+		Plugin:         types.StringNull(),
+		AuthString:     types.StringValue("secret"),
+		RandomPassword: types.BoolNull(),
+	}
+	withPlugin := &AuthOptionModel{
+		Plugin:         types.StringValue("ed25519"),
+		AuthString:     types.StringValue("secret"),
+		RandomPassword: types.BoolNull(),
+	}
+	random := &AuthOptionModel{
+		Plugin:         types.StringNull(),
+		AuthString:     types.StringNull(),
+		RandomPassword: types.BoolValue(true),
+	}
+	cases := []struct {
+		name       string
+		flavor     Flavor
+		authOption *AuthOptionModel
+		lock       bool
+		create     sqlStatement
+		alter      sqlStatement
+	}{
+		{
+			name:       "MySQL password",
+			flavor:     FlavorMySQL,
+			authOption: password,
+			create:     sqlStatement{query: "CREATE USER ?@? IDENTIFIED BY ?", args: []any{"user", "%", "secret"}},
+			alter:      sqlStatement{query: "ALTER USER ?@? IDENTIFIED BY ? ACCOUNT UNLOCK", args: []any{"user", "%", "secret"}},
+		},
+		{
+			name:       "MySQL random password",
+			flavor:     FlavorMySQL,
+			authOption: random,
+			lock:       true,
+			create:     sqlStatement{query: "CREATE USER ?@? IDENTIFIED BY RANDOM PASSWORD ACCOUNT LOCK", args: []any{"user", "%"}},
+			alter:      sqlStatement{query: "ALTER USER ?@? IDENTIFIED BY RANDOM PASSWORD ACCOUNT LOCK", args: []any{"user", "%"}},
+		},
+		{
+			name:       "MySQL plugin",
+			flavor:     FlavorMySQL,
+			authOption: withPlugin,
+			create:     sqlStatement{query: "CREATE USER ?@? IDENTIFIED WITH ed25519 BY ?", args: []any{"user", "%", "secret"}},
+			alter:      sqlStatement{query: "ALTER USER ?@? IDENTIFIED WITH ed25519 BY ? ACCOUNT UNLOCK", args: []any{"user", "%", "secret"}},
+		},
+		{
+			name:   "MariaDB without auth option",
+			flavor: FlavorMariaDB,
+			create: sqlStatement{query: "CREATE USER 'user'@'%'"},
+			alter:  sqlStatement{query: "ALTER USER 'user'@'%' ACCOUNT UNLOCK"},
+		},
+		{
+			name:       "MariaDB password",
+			flavor:     FlavorMariaDB,
+			authOption: password,
+			lock:       true,
+			create:     sqlStatement{query: "CREATE USER 'user'@'%' IDENTIFIED BY 'secret' ACCOUNT LOCK"},
+			alter:      sqlStatement{query: "ALTER USER 'user'@'%' IDENTIFIED BY 'secret' ACCOUNT LOCK"},
+		},
+		{
+			name:       "MariaDB plugin",
+			flavor:     FlavorMariaDB,
+			authOption: withPlugin,
+			create:     sqlStatement{query: "CREATE USER 'user'@'%' IDENTIFIED VIA ed25519 USING PASSWORD('secret')"},
+			alter:      sqlStatement{query: "ALTER USER 'user'@'%' IDENTIFIED VIA ed25519 USING PASSWORD('secret') ACCOUNT UNLOCK"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testStatement(t, createUserStatement(c.flavor, "user", "%", c.authOption, nil, "", c.lock, ""), c.create)
+			testStatement(t, alterUserStatement(c.flavor, "user", "%", c.authOption, "", c.lock, ""), c.alter)
+		})
+	}
+
+	if stmt := readUserStatement(FlavorMariaDB, Capabilities{}, "user", "%"); !regexp.MustCompile(`mysql\.global_priv`).MatchString(stmt.query) {
+		t.Errorf("unexpected MariaDB query: %s", stmt.query)
+	}
+	if stmt := readUserStatement(FlavorPercona, Capabilities{}, "user", "%"); !regexp.MustCompile(`mysql\.user`).MatchString(stmt.query) {
+		t.Errorf("unexpected Percona query: %s", stmt.query)
+	}
+}
+
+func TestAccUserResource_WriteOnlyAuthString(t *testing.T) {
+	user := NewRandomUser("test-user", "%")
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		CheckDestroy:             testAccUserResource_CheckDestroy([]UserModel{user}),
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_11_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUserResource_ConfigWithWriteOnlyAuth(t, user.GetName(), 1),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_user.test", "id", user.GetID()),
+					resource.TestCheckNoResourceAttr("mysql_user.test", "auth_option.auth_string"),
+					resource.TestCheckNoResourceAttr("mysql_user.test", "auth_option.auth_string_wo"),
+					resource.TestCheckResourceAttr("mysql_user.test", "auth_option.auth_string_wo_version", "1"),
+				),
+			},
+			{
+				Config: testAccUserResource_ConfigWithWriteOnlyAuth(t, user.GetName(), 2),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("mysql_user.test", "auth_option.auth_string_wo"),
+					resource.TestCheckResourceAttr("mysql_user.test", "auth_option.auth_string_wo_version", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccUserResource_ConfigWithWriteOnlyAuth(t *testing.T, name string, version int) string {
+	source := `
+ephemeral "mysql_random_password" "test" {
+  length = 24
+}
+
+resource "mysql_user" "test" {
+  name = "{{ .Name }}"
+  auth_option {
+    auth_string_wo         = ephemeral.mysql_random_password.test.result
+    auth_string_wo_version = {{ .Version }}
+  }
+}
+`
+	data := struct {
+		Name    string
+		Version int
+	}{
+		Name:    name,
+		Version: version,
+	}
+	config, err := utils.Render(source, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// testUserResourceValue returns the value of the resource of user@% with
+// authOption, whose unset attributes are null.
+func testUserResourceValue(t *testing.T, s schema.Schema, authOption AuthOptionModel) tftypes.Value {
+	t.Helper()
+	ctx := t.Context()
+	authOptionValue, diags := types.ObjectValueFrom(ctx, AuthOptionModelTypes, authOption)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	state := tfsdk.State{
+		Schema: s,
+		Raw:    tftypes.NewValue(s.Type().TerraformType(ctx), nil),
+	}
+	diags = state.Set(ctx, &UserResourceModel{
+		ID:         types.StringValue("user@%"),
+		Name:       types.StringValue("user"),
+		Host:       types.StringValue("%"),
+		Lock:       types.BoolValue(false),
+		AuthOption: authOptionValue,
+
+		TLSRequirement: types.ObjectNull(TLSRequirementModelTypes),
+		Attributes:     types.MapNull(types.StringType),
+
+		AdditionalFactors: types.ListNull(types.ObjectType{AttrTypes: AuthFactorModelTypes}),
+	})
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	return state.Raw
+}
+
+func TestUserResourceUpdate_WriteOnlyAuthString(t *testing.T) {
+	ctx := t.Context()
+	conf := testMySQLConfig()
+	conf.Config.User = "update_write_only"
+	var queries []string
+	testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		queries = append(queries, query)
+		return nil, nil
+	})
+
+	r := &UserResource{mysqlConfig: conf}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	value := func(authString types.String, version int64) tftypes.Value {
+		return testUserResourceValue(t, schemaResp.Schema, AuthOptionModel{
+			AuthStringWO:        authString,
+			AuthStringWOVersion: types.Int64Value(version),
+		})
+	}
+
+	for _, c := range []struct {
+		name     string
+		version  int64
+		expected string
+	}{
+		{"same version", 1, "ALTER USER ?@? ACCOUNT UNLOCK"},
+		{"new version", 2, "ALTER USER ?@? IDENTIFIED BY ? ACCOUNT UNLOCK"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			queries = nil
+			req := fwresource.UpdateRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: value(types.StringValue("secret"), c.version)},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: value(types.StringNull(), c.version)},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: value(types.StringNull(), 1)},
+			}
+			resp := fwresource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: req.Plan.Raw}}
+			r.Update(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("%v", resp.Diagnostics)
+			}
+			if len(queries) != 1 || queries[0] != c.expected {
+				t.Errorf("unexpected statements: %q", queries)
+			}
+			var authString types.String
+			resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("auth_option").AtName("auth_string_wo"), &authString)...)
+			if !authString.IsNull() {
+				t.Error("the write-only password must not be saved")
+			}
+		})
+	}
+}
+
+func TestUserResourceCreate_RandomPassword(t *testing.T) {
+	ctx := t.Context()
+	conf := testMySQLConfig()
+	conf.Config.User = "create_random_password"
+	testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		if query != "CREATE USER ?@? IDENTIFIED BY RANDOM PASSWORD" {
+			return nil, fmt.Errorf("unexpected statement: %s", query)
+		}
+		return &fakeRows{
+			columns: []string{"user", "host", "generated password", "auth_factor"},
+			values:  [][]driver.Value{{"user", "%", "g3nerated", int64(1)}},
+		}, nil
+	})
+	entity, armored, _ := testPGPKey(t)
+
+	r := &UserResource{mysqlConfig: conf}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	for _, c := range []struct {
+		name   string
+		pgpKey types.String
+	}{
+		{"plain", types.StringNull()},
+		{"encrypted", types.StringValue(armored)},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			authOption := AuthOptionModel{
+				RandomPassword:    types.BoolValue(true),
+				PGPKey:            c.pgpKey,
+				GeneratedPassword: types.StringUnknown(),
+				EncryptedPassword: types.StringUnknown(),
+			}
+			req := fwresource.CreateRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: testUserResourceValue(t, schemaResp.Schema, AuthOptionModel{RandomPassword: authOption.RandomPassword, PGPKey: c.pgpKey})},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: testUserResourceValue(t, schemaResp.Schema, authOption)},
+			}
+			resp := fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: req.Plan.Raw}}
+			r.Create(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("%v", resp.Diagnostics)
+			}
+
+			var state AuthOptionModel
+			resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("auth_option"), &state)...)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("%v", resp.Diagnostics)
+			}
+			if c.pgpKey.IsNull() {
+				if state.GeneratedPassword.ValueString() != "g3nerated" || !state.EncryptedPassword.IsNull() {
+					t.Errorf("unexpected passwords: %v, %v", state.GeneratedPassword, state.EncryptedPassword)
+				}
+				return
+			}
+			if !state.GeneratedPassword.IsNull() {
+				t.Errorf("the generated password must not be saved: %v", state.GeneratedPassword)
+			}
+			if plaintext := testDecryptWithPGPKey(t, entity, state.EncryptedPassword.ValueString()); plaintext != "g3nerated" {
+				t.Errorf("unexpected plaintext: %q", plaintext)
+			}
+		})
+	}
+}
+
+func TestRegeneratesPassword(t *testing.T) {
+	random := &AuthOptionModel{RandomPassword: types.BoolValue(true)}
+	cases := []struct {
+		name     string
+		state    *AuthOptionModel
+		plan     *AuthOptionModel
+		expected bool
+	}{
+		{"create", nil, random, true},
+		{"newly random", &AuthOptionModel{AuthString: types.StringValue("secret")}, random, true},
+		{"unchanged", random, random, false},
+		{"plugin changed", random, &AuthOptionModel{RandomPassword: types.BoolValue(true), Plugin: types.StringValue("sha256_password")}, true},
+		{"pgp_key changed", random, &AuthOptionModel{RandomPassword: types.BoolValue(true), PGPKey: types.StringValue("key")}, true},
+		{"not random", random, &AuthOptionModel{AuthString: types.StringValue("secret")}, false},
+	}
+	for _, c := range cases {
+		if got := regeneratesPassword(c.state, c.plan); got != c.expected {
+			t.Errorf("%s: regeneratesPassword = %v", c.name, got)
+		}
+	}
+}
+
+func TestAccUserResource_AccountOptions(t *testing.T) {
+	user := NewRandomUser("test-user", "%")
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		CheckDestroy:             testAccUserResource_CheckDestroy([]UserModel{user}),
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), `
+  max_queries_per_hour     = 100
+  max_user_connections     = 5
+  password_expire_interval = 90
+  password_history         = 3
+  password_reuse_interval  = 30
+  password_require_current = true
+  failed_login_attempts    = 3
+  password_lock_time       = -1
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_user.test", "max_queries_per_hour", "100"),
+					resource.TestCheckResourceAttr("mysql_user.test", "max_updates_per_hour", "0"),
+					resource.TestCheckResourceAttr("mysql_user.test", "max_user_connections", "5"),
+					resource.TestCheckResourceAttr("mysql_user.test", "password_expire_interval", "90"),
+					resource.TestCheckResourceAttr("mysql_user.test", "password_history", "3"),
+					resource.TestCheckResourceAttr("mysql_user.test", "password_reuse_interval", "30"),
+					resource.TestCheckResourceAttr("mysql_user.test", "password_require_current", "true"),
+					resource.TestCheckResourceAttr("mysql_user.test", "failed_login_attempts", "3"),
+					resource.TestCheckResourceAttr("mysql_user.test", "password_lock_time", "-1"),
+				),
+			},
+			{
+				ResourceName:      "mysql_user.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), `
+  max_updates_per_hour     = 10
+  password_expire_interval = 0
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_user.test", "max_queries_per_hour", "0"),
+					resource.TestCheckResourceAttr("mysql_user.test", "max_updates_per_hour", "10"),
+					resource.TestCheckResourceAttr("mysql_user.test", "password_expire_interval", "0"),
+					resource.TestCheckNoResourceAttr("mysql_user.test", "password_history"),
+					resource.TestCheckNoResourceAttr("mysql_user.test", "password_require_current"),
+					resource.TestCheckResourceAttr("mysql_user.test", "failed_login_attempts", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_TLSRequirement(t *testing.T) {
+	user := NewRandomUser("test-user", "%")
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		CheckDestroy:             testAccUserResource_CheckDestroy([]UserModel{user}),
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), `
+  tls_requirement {
+    type = "X509"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_user.test", "tls_requirement.type", "X509"),
+				),
+			},
+			{
+				ResourceName:      "mysql_user.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), `
+  tls_requirement {
+    issuer  = "/C=US/O=Example/CN=CA"
+    subject = "/C=US/O=Example/CN=client"
+    cipher  = "ECDHE-RSA-AES256-GCM-SHA384"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("mysql_user.test", "tls_requirement.type"),
+					resource.TestCheckResourceAttr("mysql_user.test", "tls_requirement.issuer", "/C=US/O=Example/CN=CA"),
+					resource.TestCheckResourceAttr("mysql_user.test", "tls_requirement.subject", "/C=US/O=Example/CN=client"),
+					resource.TestCheckResourceAttr("mysql_user.test", "tls_requirement.cipher", "ECDHE-RSA-AES256-GCM-SHA384"),
+				),
+			},
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("mysql_user.test", "tls_requirement.type"),
+					resource.TestCheckNoResourceAttr("mysql_user.test", "tls_requirement.cipher"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_Attributes(t *testing.T) {
+	user := NewRandomUser("test-user", "%")
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		CheckDestroy:             testAccUserResource_CheckDestroy([]UserModel{user}),
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), `
+  comment    = "application user"
+  attributes = {
+    team   = "dba"
+    ticket = "OPS-1"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("mysql_user.test", "comment", "application user"),
+					resource.TestCheckResourceAttr("mysql_user.test", "attributes.%", "2"),
+					resource.TestCheckResourceAttr("mysql_user.test", "attributes.team", "dba"),
+				),
+			},
+			{
+				ResourceName:      "mysql_user.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccUserResource_ConfigWithAccountOptions(t, user.GetName(), `
+  attributes = {
+    team = "app"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("mysql_user.test", "comment"),
+					resource.TestCheckResourceAttr("mysql_user.test", "attributes.%", "1"),
+					resource.TestCheckResourceAttr("mysql_user.test", "attributes.team", "app"),
+				),
+			},
+		},
+	})
+}
+
+func testAccUserResource_ConfigWithAccountOptions(t *testing.T, name, options string) string {
+	source := `
+resource "mysql_user" "test" {
+  name = "{{ .Name }}"
+{{ .Options }}
+}
+`
+	data := struct {
+		Name    string
+		Options string
+	}{
+		Name:    name,
+		Options: options,
+	}
+	config, err := utils.Render(source, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestAccountOptionsClause(t *testing.T) {
+	all := userDefaults
+	all.MaxQueriesPerHour = types.Int64Value(100)
+	all.MaxUserConnections = types.Int64Value(5)
+	all.PasswordExpireInterval = types.Int64Value(90)
+	all.PasswordHistory = types.Int64Value(3)
+	all.PasswordReuseInterval = types.Int64Value(30)
+	all.PasswordRequireCurrent = types.BoolValue(false)
+	all.FailedLoginAttempts = types.Int64Value(3)
+	all.PasswordLockTime = types.Int64Value(-1)
+
+	never := userDefaults
+	never.PasswordExpireInterval = types.Int64Value(0)
+	never.PasswordRequireCurrent = types.BoolValue(true)
+
+	cases := []struct {
+		name     string
+		plan     UserResourceModel
+		state    UserResourceModel
+		expected string
+	}{
+		{"defaults", userDefaults, userDefaults, ""},
+		{
+			"create",
+			all,
+			userDefaults,
+			" WITH MAX_QUERIES_PER_HOUR 100 MAX_USER_CONNECTIONS 5 PASSWORD EXPIRE INTERVAL 90 DAY PASSWORD HISTORY 3" +
+				" PASSWORD REUSE INTERVAL 30 DAY PASSWORD REQUIRE CURRENT OPTIONAL FAILED_LOGIN_ATTEMPTS 3 PASSWORD_LOCK_TIME UNBOUNDED",
+		},
+		{"unchanged", all, all, ""},
+		{
+			"reset",
+			userDefaults,
+			all,
+			" WITH MAX_QUERIES_PER_HOUR 0 MAX_USER_CONNECTIONS 0 PASSWORD EXPIRE DEFAULT PASSWORD HISTORY DEFAULT" +
+				" PASSWORD REUSE INTERVAL DEFAULT PASSWORD REQUIRE CURRENT DEFAULT FAILED_LOGIN_ATTEMPTS 0 PASSWORD_LOCK_TIME 0",
+		},
+		{"never", never, userDefaults, " PASSWORD EXPIRE NEVER PASSWORD REQUIRE CURRENT"},
+	}
+	for _, c := range cases {
+		got, diags := accountOptionsClause(t.Context(), &c.plan, &c.state)
+		if diags.HasError() {
+			t.Fatalf("%s: %v", c.name, diags)
+		}
+		if got != c.expected {
+			t.Errorf("%s: accountOptionsClause =\n%q, want\n%q", c.name, got, c.expected)
+		}
+	}
+}
+
+func TestRequireClause(t *testing.T) {
+	cases := []struct {
+		name     string
+		tls      types.Object
+		expected string
+	}{
+		{"null", types.ObjectNull(TLSRequirementModelTypes), "REQUIRE NONE"},
+		{"empty", tlsRequirementValue("SPECIFIED", "", "", ""), "REQUIRE NONE"},
+		{"none", tlsRequirementValue("", "", "", ""), "REQUIRE NONE"},
+		{"ssl", tlsRequirementValue("ANY", "", "", ""), "REQUIRE SSL"},
+		{"x509", tlsRequirementValue("X509", "", "", ""), "REQUIRE X509"},
+		{
+			"specified",
+			tlsRequirementValue("SPECIFIED", "/CN=CA", "/CN=it's me", "ECDHE-RSA-AES256-GCM-SHA384"),
+			`REQUIRE ISSUER '/CN=CA' AND SUBJECT '/CN=it''s me' AND CIPHER 'ECDHE-RSA-AES256-GCM-SHA384'`,
+		},
+		{"cipher", tlsRequirementValue("SPECIFIED", "", "", "ECDHE-RSA-AES256-GCM-SHA384"), "REQUIRE CIPHER 'ECDHE-RSA-AES256-GCM-SHA384'"},
+	}
+	for _, c := range cases {
+		got, diags := requireClause(t.Context(), c.tls)
+		if diags.HasError() {
+			t.Fatalf("%s: %v", c.name, diags)
+		}
+		if got != c.expected {
+			t.Errorf("%s: requireClause = %q, want %q", c.name, got, c.expected)
+		}
+	}
+
+	plan := userDefaults
+	plan.TLSRequirement = tlsRequirementValue("X509", "", "", "")
+	plan.MaxUserConnections = types.Int64Value(5)
+	got, diags := accountOptionsClause(t.Context(), &plan, &userDefaults)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	if expected := " REQUIRE X509 WITH MAX_USER_CONNECTIONS 5"; got != expected {
+		t.Errorf("accountOptionsClause = %q, want %q", got, expected)
+	}
+}
+
+func TestUserAttributeClause(t *testing.T) {
+	ctx := t.Context()
+	attributes := func(values map[string]string) types.Map {
+		m, diags := types.MapValueFrom(ctx, types.StringType, values)
+		if diags.HasError() {
+			t.Fatalf("%v", diags)
+		}
+		return m
+	}
+	tagged := userDefaults
+	tagged.Comment = types.StringValue("it's the app")
+	tagged.Attributes = attributes(map[string]string{"team": "dba", "ticket": "OPS-1"})
+
+	retagged := userDefaults
+	retagged.Attributes = attributes(map[string]string{"team": "app"})
+
+	cases := []struct {
+		name     string
+		plan     UserResourceModel
+		state    UserResourceModel
+		expected string
+	}{
+		{"defaults", userDefaults, userDefaults, ""},
+		{"create", tagged, userDefaults, ` ATTRIBUTE '{"comment":"it''s the app","team":"dba","ticket":"OPS-1"}'`},
+		{"unchanged", tagged, tagged, ""},
+		{"update", retagged, tagged, ` ATTRIBUTE '{"comment":null,"team":"app","ticket":null}'`},
+		{"remove", userDefaults, retagged, ` ATTRIBUTE '{"team":null}'`},
+	}
+	for _, c := range cases {
+		got, diags := userAttributeClause(ctx, &c.plan, &c.state)
+		if diags.HasError() {
+			t.Fatalf("%s: %v", c.name, diags)
+		}
+		if got != c.expected {
+			t.Errorf("%s: userAttributeClause = %q, want %q", c.name, got, c.expected)
+		}
+	}
+}
+
+func TestUserMetadataValues(t *testing.T) {
+	comment, attributes, err := userMetadataValues(`{"comment": "app", "team": "dba", "replicas": 3}`, types.MapNull(types.StringType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.ValueString() != "app" {
+		t.Errorf("unexpected comment: %v", comment)
+	}
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{"team": types.StringValue("dba"), "replicas": types.StringValue("3")})
+	if !attributes.Equal(expected) {
+		t.Errorf("unexpected attributes: %v", attributes)
+	}
+
+	comment, attributes, err = userMetadataValues("", types.MapNull(types.StringType))
+	if err != nil || !comment.IsNull() || !attributes.IsNull() {
+		t.Errorf("unexpected values of empty metadata: %v, %v, %v", comment, attributes, err)
+	}
+	empty := types.MapValueMust(types.StringType, map[string]attr.Value{})
+	if _, attributes, _ = userMetadataValues("", empty); !attributes.Equal(empty) {
+		t.Errorf("empty attributes must be kept: %v", attributes)
+	}
+}
+
+func TestMultiFactorStatements(t *testing.T) {
+	ldap := AuthFactorModel{
+		Plugin:           types.StringValue("authentication_ldap_sasl"),
+		AuthString:       types.StringNull(),
+		StoredAuthString: types.StringValue("uid=user,ou=People,dc=example,dc=com"),
+	}
+	webauthn := AuthFactorModel{
+		Plugin:           types.StringValue("authentication_webauthn"),
+		AuthString:       types.StringNull(),
+		StoredAuthString: types.StringNull(),
+	}
+	password := func(authString string) AuthFactorModel {
+		return AuthFactorModel{
+			Plugin:           types.StringValue("caching_sha2_password"),
+			AuthString:       types.StringValue(authString),
+			StoredAuthString: types.StringNull(),
+		}
+	}
+	authOption := &AuthOptionModel{AuthString: types.StringValue("secret")}
+
+	testStatement(t, createUserStatement(FlavorMySQL, "user", "%", authOption, []AuthFactorModel{ldap, webauthn}, "", false, ""), sqlStatement{
+		query: "CREATE USER ?@? IDENTIFIED BY ? AND IDENTIFIED WITH authentication_ldap_sasl AS ? AND IDENTIFIED WITH authentication_webauthn",
+		args:  []any{"user", "%", "secret", "uid=user,ou=People,dc=example,dc=com"},
+	})
+	fido := &AuthOptionModel{Plugin: types.StringValue("authentication_webauthn"), InitialAuthString: types.StringValue("initial")}
+	stmt := createUserStatement(FlavorMySQL, "user", "%", fido, nil, "", false, "")
+	testStatement(t, stmt, sqlStatement{
+		query: "CREATE USER ?@? IDENTIFIED WITH authentication_webauthn INITIAL AUTHENTICATION IDENTIFIED BY ?",
+		args:  []any{"user", "%", "initial"},
+	})
+	if got := stmt.redacted(); strings.Contains(got, "initial") {
+		t.Errorf("the initial password must be redacted: %s", got)
+	}
+
+	cases := []struct {
+		name     string
+		plan     []AuthFactorModel
+		state    []AuthFactorModel
+		expected []sqlStatement
+	}{
+		{"unchanged", []AuthFactorModel{ldap, webauthn}, []AuthFactorModel{ldap, webauthn}, nil},
+		{
+			"add",
+			[]AuthFactorModel{ldap, webauthn},
+			nil,
+			[]sqlStatement{{
+				query: "ALTER USER ?@? ADD 2 FACTOR IDENTIFIED WITH authentication_ldap_sasl AS ? ADD 3 FACTOR IDENTIFIED WITH authentication_webauthn",
+				args:  []any{"user", "%", "uid=user,ou=People,dc=example,dc=com"},
+			}},
+		},
+		{
+			"drop",
+			nil,
+			[]AuthFactorModel{ldap, webauthn},
+			[]sqlStatement{{query: "ALTER USER ?@? DROP 2 FACTOR DROP 3 FACTOR", args: []any{"user", "%"}}},
+		},
+		{
+			"modify",
+			[]AuthFactorModel{password("new"), webauthn},
+			[]AuthFactorModel{password("old"), webauthn},
+			[]sqlStatement{{query: "ALTER USER ?@? MODIFY 2 FACTOR IDENTIFIED BY ?", args: []any{"user", "%", "new"}}},
+		},
+		{
+			"replace",
+			[]AuthFactorModel{password("new"), ldap},
+			[]AuthFactorModel{password("old"), webauthn},
+			[]sqlStatement{
+				{query: "ALTER USER ?@? MODIFY 2 FACTOR IDENTIFIED BY ?", args: []any{"user", "%", "new"}},
+				{query: "ALTER USER ?@? DROP 3 FACTOR", args: []any{"user", "%"}},
+				{
+					query: "ALTER USER ?@? ADD 3 FACTOR IDENTIFIED WITH authentication_ldap_sasl AS ?",
+					args:  []any{"user", "%", "uid=user,ou=People,dc=example,dc=com"},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := alterFactorsStatements(FlavorMySQL, "user", "%", c.plan, c.state)
+			if len(got) != len(c.expected) {
+				t.Fatalf("unexpected statements: %v", got)
+			}
+			for i := range got {
+				testStatement(t, got[i], c.expected[i])
+			}
+		})
+	}
+}
+
+func TestAuthFactorsValue(t *testing.T) {
+	ctx := t.Context()
+	factors, err := parseAuthFactors(`[{"plugin": "authentication_ldap_sasl", "passwordless": 0, "authentication_string": "uid=user", "requires_registration": 0}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: AuthFactorModelTypes}, []AuthFactorModel{{
+		Plugin:           types.StringValue("authentication_webauthn"),
+		AuthString:       types.StringNull(),
+		StoredAuthString: types.StringValue("uid=old"),
+	}})
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	value, diags := authFactorsValue(ctx, factors, state)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	got, diags := authFactors(ctx, value)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	if len(got) != 1 || got[0].Plugin.ValueString() != "authentication_ldap_sasl" || got[0].StoredAuthString.ValueString() != "uid=user" {
+		t.Errorf("unexpected factors: %v", got)
+	}
+
+	null := types.ListNull(types.ObjectType{AttrTypes: AuthFactorModelTypes})
+	if value, _ := authFactorsValue(ctx, nil, null); !value.IsNull() {
+		t.Errorf("factors must be kept null: %v", value)
+	}
+}
+
+func TestReadUserStatement_Capabilities(t *testing.T) {
+	old := readUserStatement(FlavorMySQL, newCapabilities(version.Must(version.NewVersion("5.7.44")), FlavorMySQL), "user", "%")
+	latest := readUserStatement(FlavorMySQL, newCapabilities(version.Must(version.NewVersion("8.0.36")), FlavorMySQL), "user", "%")
+	for _, column := range []string{"Password_reuse_history", "Password_require_current", "User_attributes"} {
+		if strings.Contains(old.query, column) {
+			t.Errorf("MySQL 5.7 has no %s: %s", column, old.query)
+		}
+		if !strings.Contains(latest.query, column) {
+			t.Errorf("MySQL 8.0 must read %s: %s", column, latest.query)
+		}
+	}
+	if !strings.Contains(old.query, "password_lifetime") {
+		t.Errorf("MySQL 5.7 must read password_lifetime: %s", old.query)
+	}
+}
+
+func TestUserResourceRead_PasswordlessFactor(t *testing.T) {
+	ctx := t.Context()
+	conf := testMySQLConfig()
+	conf.Config.User = "read_passwordless_factor"
+	// CREATE USER ... IDENTIFIED WITH authentication_webauthn INITIAL
+	// AUTHENTICATION IDENTIFIED BY stores the initial password as the plugin of
+	// the user and the passwordless factor as the first of the factors.
+	testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		if !strings.Contains(query, "FROM\n   mysql.user") {
+			return nil, fmt.Errorf("unexpected statement: %s", query)
+		}
+		return &fakeRows{
+			columns: make([]string, 21),
+			values: [][]driver.Value{{
+				"%", "user", "caching_sha2_password", "$A$005$hash", "N",
+				int64(0), int64(0), int64(0), int64(0),
+				nil, nil, nil, nil,
+				int64(0), int64(0),
+				"", "", "", "",
+				"",
+				`[{"plugin": "authentication_webauthn", "passwordless": 1, "authentication_string": "", "requires_registration": 1}]`,
+			}},
+		}, nil
+	})
+
+	r := &UserResource{mysqlConfig: conf}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	planned := tfsdk.State{Schema: schemaResp.Schema, Raw: testUserResourceValue(t, schemaResp.Schema, AuthOptionModel{
+		Plugin:            types.StringValue("authentication_webauthn"),
+		InitialAuthString: types.StringValue("initial"),
+	})}
+	// An omitted block is planned as an empty list.
+	noFactors := types.ListValueMust(types.ObjectType{AttrTypes: AuthFactorModelTypes}, nil)
+	if diags := planned.SetAttribute(ctx, path.Root("additional_factor"), noFactors); diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	resp := fwresource.ReadResponse{State: planned}
+	r.Read(ctx, fwresource.ReadRequest{State: planned}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+
+	// The passwordless factor is read back into auth_option, so the plan has
+	// no diff.
+	for _, p := range []path.Path{path.Root("auth_option"), path.Root("additional_factor")} {
+		var expected, got attr.Value
+		resp.Diagnostics.Append(planned.GetAttribute(ctx, p, &expected)...)
+		resp.Diagnostics.Append(resp.State.GetAttribute(ctx, p, &got)...)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("%v", resp.Diagnostics)
+		}
+		if !got.Equal(expected) {
+			t.Errorf("%s differs from the plan:\n got: %s\nwant: %s", p, got, expected)
+		}
+	}
+}
+
+func TestUserResourceRead_AccountOptions(t *testing.T) {
+	ctx := t.Context()
+	conf := testMySQLConfig()
+	conf.Config.User = "read_account_options"
+	testFakeConnection(t, conf, func(query string) (*fakeRows, error) {
+		if !strings.Contains(query, "FROM\n   mysql.user") {
+			return nil, fmt.Errorf("unexpected statement: %s", query)
+		}
+		return &fakeRows{
+			columns: make([]string, 21),
+			values: [][]driver.Value{{
+				"%", "user", "caching_sha2_password", "", "N",
+				int64(100), int64(0), int64(0), int64(5),
+				int64(0), nil, int64(30), "N",
+				int64(3), int64(-1),
+				"X509", "", "", "",
+				`{"comment": "app", "team": "dba"}`,
+				`[{"plugin": "authentication_ldap_sasl", "passwordless": 0, "authentication_string": "uid=user", "requires_registration": 0}]`,
+			}},
+		}, nil
+	})
+
+	r := &UserResource{mysqlConfig: conf}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: testUserResourceValue(t, schemaResp.Schema, AuthOptionModel{})}
+	resp := fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+
+	var data UserResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("%v", resp.Diagnostics)
+	}
+	expected := userDefaults
+	expected.MaxQueriesPerHour = types.Int64Value(100)
+	expected.MaxUserConnections = types.Int64Value(5)
+	expected.PasswordExpireInterval = types.Int64Value(0)
+	expected.PasswordReuseInterval = types.Int64Value(30)
+	expected.PasswordRequireCurrent = types.BoolValue(false)
+	expected.FailedLoginAttempts = types.Int64Value(3)
+	expected.PasswordLockTime = types.Int64Value(-1)
+	expected.TLSRequirement = tlsRequirementValue("X509", "", "", "")
+	expected.Comment = types.StringValue("app")
+	expected.Attributes = types.MapValueMust(types.StringType, map[string]attr.Value{"team": types.StringValue("dba")})
+	accountOptions, diags := accountOptionsClause(ctx, &data, &expected)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	if accountOptions != "" || !data.PasswordHistory.IsNull() {
+		t.Errorf("unexpected options: %+v", data)
+	}
+	attribute, diags := userAttributeClause(ctx, &data, &expected)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	if attribute != "" {
+		t.Errorf("unexpected attributes: %+v", data)
+	}
+	factors, diags := authFactors(ctx, data.AdditionalFactors)
+	if diags.HasError() {
+		t.Fatalf("%v", diags)
+	}
+	if len(factors) != 1 || factors[0].Plugin.ValueString() != "authentication_ldap_sasl" {
+		t.Errorf("unexpected factors: %v", factors)
+	}
+}
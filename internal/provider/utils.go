@@ -3,52 +3,131 @@ package provider
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+// errUnknownConfiguration is returned when connecting while the provider
+// configuration is unknown.
+var errUnknownConfiguration = errors.New("the provider configuration depends on values known only after apply")
+
+// readWhileUnknown reports whether mysqlConf is unknown, so that reads return
+// without connecting. The server may not exist yet while planning, e.g. when
+// it is created in the same apply, and resources keep the prior state then.
+func readWhileUnknown(mysqlConf *MySQLConfiguration) bool {
+	return mysqlConf.Unknown
+}
+
+// deferDataSourceRead reports whether the read of a data source returns
+// without connecting, like readWhileUnknown, and defers the data source when
+// the client supports it. Otherwise the computed attributes are left null.
+func deferDataSourceRead(mysqlConf *MySQLConfiguration, req datasource.ReadRequest, resp *datasource.ReadResponse) bool {
+	if !readWhileUnknown(mysqlConf) {
+		return false
+	}
+	if req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &datasource.Deferred{Reason: datasource.DeferredReasonProviderConfigUnknown}
+	}
+	return true
+}
+
+// errNotFound is wrapped by the errors reporting that the object read from the
+// server does not exist. Reads remove resources from the state only for it,
+// so that a lost connection or a missing permission never plans to create
+// the resources again.
+var errNotFound = errors.New("not found")
+
+// The server errors which mean that the object does not exist.
+const (
+	erBadDB                 = 1049
+	erNonexistingGrant      = 1141
+	erUnknownSystemVariable = 1193
+)
+
+// notFound wraps err with errNotFound when err means that the object read
+// from the server does not exist.
+func notFound(err error) error {
+	if err == nil || errors.Is(err, errNotFound) {
+		return err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", errNotFound, err)
+	}
+	switch mysqlErrorNumber(err) {
+	case erBadDB, erNonexistingGrant, erUnknownSystemVariable:
+		return fmt.Errorf("%w: %w", errNotFound, err)
+	default:
+		return err
+	}
+}
+
 func getDatabase(ctx context.Context, mysqlConf *MySQLConfiguration) (*sql.DB, error) {
+	oneConnection, err := getConnection(ctx, mysqlConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return oneConnection.Db, nil
+}
+
+// getConnection returns the connection with the version and the flavor of
+// the server.
+func getConnection(ctx context.Context, mysqlConf *MySQLConfiguration) (*OneConnection, error) {
+	if mysqlConf.Unknown {
+		return nil, errUnknownConfiguration
+	}
 	oneConnection, err := connectToMySQLInternal(ctx, mysqlConf)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MySQL: %v", err)
 	}
 
-	return oneConnection.Db, nil
+	return oneConnection, nil
 }
 
-/*
-	func getDatabaseVersion(ctx context.Context, mysqlConf *MySQLConfiguration) *version.Version {
-		oneConnection, err := connectToMySQLInternal(ctx, mysqlConf)
+// planConnectTimeout bounds the dial of getPlanConnection.
+var planConnectTimeout = 5 * time.Second
 
-		if err != nil {
-			tflog.Info(ctx, fmt.Sprintf("getting DB got us error: %v", err))
-		}
+var (
+	planConnectionFailuresMtx sync.Mutex
+	// planConnectionFailures holds the errors of the plan connections which
+	// failed by the cache keys, so that every resource does not wait for an
+	// unreachable server again.
+	planConnectionFailures = map[string]error{}
+)
 
-		return oneConnection.Version
+// getPlanConnection returns the connection to check a plan against the
+// capabilities of the server, or nil when the server cannot be reached. Plans
+// do not wait for the server, which may be created in the same apply.
+func getPlanConnection(ctx context.Context, mysqlConf *MySQLConfiguration) *OneConnection {
+	if mysqlConf == nil || mysqlConf.Unknown {
+		return nil
 	}
-*/
-
-func quoteIdentifier(ctx context.Context, db *sql.DB, identifier string) (string, error) {
-	var quotedIdentifier string
-	stmt, err := db.PrepareContext(ctx, "SELECT sys.quote_identifier(?)")
-	if err != nil {
-		return "", err
+	key := mysqlConf.cacheKey()
+	planConnectionFailuresMtx.Lock()
+	err, failed := planConnectionFailures[key]
+	planConnectionFailuresMtx.Unlock()
+	if failed {
+		tflog.Debug(ctx, "Skipping the server capability checks", map[string]any{"error": err.Error()})
+		return nil
 	}
-	defer func() { _ = stmt.Close() }()
-	if err := stmt.QueryRowContext(ctx, identifier).Scan(&quotedIdentifier); err != nil {
-		return "", err
-	}
-	return quotedIdentifier, nil
-}
 
-func quoteIdentifiers(ctx context.Context, db *sql.DB, identifiers ...string) ([]string, error) {
-	quotedIdentifiers := make([]string, len(identifiers))
-	var err error
-	for i, identifier := range identifiers {
-		quotedIdentifiers[i], err = quoteIdentifier(ctx, db, identifier)
-		if err != nil {
-			return quotedIdentifiers, err
-		}
+	planConf := *mysqlConf
+	planConf.ConnectRetryTimeout = 0
+	ctx, cancel := context.WithTimeout(ctx, planConnectTimeout)
+	defer cancel()
+	oneConnection, err := connectToMySQLInternal(ctx, &planConf)
+	if err != nil {
+		planConnectionFailuresMtx.Lock()
+		planConnectionFailures[key] = err
+		planConnectionFailuresMtx.Unlock()
+		tflog.Warn(ctx, "Skipping the server capability checks", map[string]any{"error": err.Error()})
+		return nil
 	}
-	return quotedIdentifiers, nil
+	return oneConnection
 }